@@ -5,7 +5,9 @@ import (
 	"log"
 	"luthierSaas/internal/di"
 	"luthierSaas/internal/infrastructure/config"
+	"luthierSaas/internal/infrastructure/logger"
 	"luthierSaas/internal/infrastructure/persistance"
+	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/http/middlewares"
 	"luthierSaas/internal/interfaces/http/routes"
 
@@ -19,6 +21,11 @@ func main() {
         log.Fatalf("Error loading config: %v", err)
     }
 
+    appLogger := logger.NewLogger()
+    if err := security.InitKeyRing(cfg.JWTKeysDir, cfg.JWTActiveKeyID, cfg.JWTIssuer, cfg.JWTEphemeralKey, appLogger); err != nil {
+        log.Fatalf("Error loading JWT signing keys: %v", err)
+    }
+
     db, err := persistance.NewDatabase(cfg)
     if err != nil {
         log.Fatalf("Error connecting to MySQL: %v", err)
@@ -27,6 +34,7 @@ func main() {
 
     container, emailService := di.NewContainer(db.DB, cfg)
     go emailService.StartWorker(context.Background())
+    container.Scheduler.Start(context.Background())
 
     r := gin.Default()
     r.SetTrustedProxies([]string{"127.0.0.1"})
@@ -34,10 +42,10 @@ func main() {
     // Configurar CORS
     corsConfig := cors.Config{
         AllowOrigins:     []string{"http://localhost:5173"},
-        AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+        AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
         AllowHeaders:     []string{"Content-Type", "Authorization"},
         AllowCredentials: true,
-        ExposeHeaders:    []string{"Set-Cookie"}, // Opcional, para depuración
+        ExposeHeaders:    []string{"Set-Cookie", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
     }
     r.Use(cors.New(corsConfig))
 
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type GetUserUseCase struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	lockout     *security.LoginLockout
+}
+
+func NewGetUserUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, lockout *security.LoginLockout) *GetUserUseCase {
+	return &GetUserUseCase{userRepo, sessionRepo, lockout}
+}
+
+func (uc *GetUserUseCase) Execute(ctx context.Context, userID int) (*dtos.AdminUserDetailResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	sessions, err := uc.sessionRepo.FindByUserID(ctx, int64(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sessions: %w", err)
+	}
+
+	now := time.Now()
+	activeSessions := make([]dtos.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.RefreshExpiresAt.IsZero() && now.After(session.RefreshExpiresAt) {
+			continue
+		}
+
+		lastActiveAt := session.UpdatedAt
+		if lastActiveAt.IsZero() {
+			lastActiveAt = session.CreatedAt
+		}
+
+		activeSessions = append(activeSessions, dtos.SessionResponse{
+			ID:           session.ID,
+			DeviceInfo:   session.DeviceInfo,
+			CreatedAt:    session.CreatedAt,
+			LastActiveAt: lastActiveAt,
+			ExpiresAt:    session.RefreshExpiresAt,
+			Impersonated: session.ImpersonatorID != 0,
+		})
+	}
+
+	lock, err := uc.lockout.Status(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login lock status: %w", err)
+	}
+
+	return &dtos.AdminUserDetailResponse{
+		AdminUserResponse: newAdminUserResponse(user),
+		Phone:             user.Phone,
+		Address:           user.Address,
+		Country:           user.Country,
+		WorkshopName:      user.WorkshopName,
+		Sessions:          activeSessions,
+		LoginLock: dtos.AdminLoginLockResponse{
+			Locked:         lock.Locked,
+			LockedUntil:    lock.LockedUntil,
+			FailedAttempts: lock.FailedAttempts,
+		},
+	}, nil
+}
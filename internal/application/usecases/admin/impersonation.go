@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Las sesiones de impersonación no se renuevan, al vencer hay que volver a iniciarlas
+const impersonationTTL = 30 * time.Minute
+
+var (
+	ErrCannotImpersonate = errors.New("only active accounts with the user role can be impersonated")
+	ErrNotImpersonating  = errors.New("current session is not an impersonation session")
+)
+
+// recordAudit guarda la acción en audit_logs. Si falla la acción no se revierte, pero queda en el log.
+func recordAudit(ctx context.Context, auditRepo repository.AuditLogRepository, logger *zerolog.Logger, entry *entities.AuditLog, metadata map[string]any) {
+	if metadata != nil {
+		raw, err := json.Marshal(metadata)
+		if err == nil {
+			entry.Metadata = string(raw)
+		}
+	}
+	if err := auditRepo.Create(ctx, entry); err != nil {
+		logger.Error().
+			Err(err).
+			Int("actor_id", entry.ActorID).
+			Int("target_user_id", entry.TargetUserID).
+			Str("action", entry.Action).
+			Msg("Failed to write audit log")
+	}
+}
+
+// StartImpersonationUseCase abre una sesión a nombre del usuario para que soporte vea lo mismo que él.
+// La sesión queda marcada con el id del administrador en la base y en el JWT.
+type StartImpersonationUseCase struct {
+	userManager
+	auditRepo repository.AuditLogRepository
+}
+
+func NewStartImpersonationUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, auditRepo repository.AuditLogRepository, cacheService *cache.Cache, logger *zerolog.Logger) *StartImpersonationUseCase {
+	return &StartImpersonationUseCase{userManager{userRepo, sessionRepo, tokenRepo, cacheService, logger}, auditRepo}
+}
+
+func (uc *StartImpersonationUseCase) Execute(ctx context.Context, adminID, userID int, input dtos.ImpersonationInput, deviceInfo, ip string) (*dtos.ImpersonationResponse, error) {
+	if adminID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := uc.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Impersonar a otro administrador serviría para escalar permisos
+	if user.Role != entities.RoleUser || !user.IsActive || user.Deleted {
+		return nil, ErrCannotImpersonate
+	}
+
+	expiresAt := time.Now().Add(impersonationTTL)
+	accessToken, err := security.CreateImpersonationToken(user.ID, user.Role, adminID, impersonationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonation token: %w", err)
+	}
+	accessTokenHash, err := security.HashToken(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash access token: %w", err)
+	}
+
+	// La columna no admite vacíos, se guarda el hash de un valor que nunca se entrega
+	unusedRefresh, err := security.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session: %w", err)
+	}
+	refreshTokenHash, err := security.HashToken(unusedRefresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash refresh token: %w", err)
+	}
+
+	session := &entities.Session{
+		UserID:           user.ID,
+		AccessTokenHash:  accessTokenHash,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        expiresAt,
+		RefreshExpiresAt: expiresAt,
+		IsValid:          true,
+		DeviceInfo:       deviceInfo,
+		ImpersonatorID:   adminID,
+	}
+	if err := uc.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	recordAudit(ctx, uc.auditRepo, uc.logger, &entities.AuditLog{
+		ActorID:      adminID,
+		Action:       entities.AuditActionImpersonationStart,
+		TargetUserID: user.ID,
+		SessionID:    session.ID,
+		IP:           ip,
+	}, map[string]any{"reason": reason, "expires_at": expiresAt})
+
+	uc.logger.Info().
+		Int("admin_id", adminID).
+		Int("user_id", user.ID).
+		Int("session_id", session.ID).
+		Str("reason", reason).
+		Msg("Admin started impersonation")
+
+	return &dtos.ImpersonationResponse{
+		AccessToken:    accessToken,
+		UserID:         user.ID,
+		ImpersonatorID: adminID,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+// StopImpersonationUseCase cierra la sesión de impersonación actual. La sesión propia del
+// administrador no se toca, el handler le devuelve su refresh token.
+type StopImpersonationUseCase struct {
+	sessionRepo repository.SessionRepository
+	auditRepo   repository.AuditLogRepository
+	logger      *zerolog.Logger
+}
+
+func NewStopImpersonationUseCase(sessionRepo repository.SessionRepository, auditRepo repository.AuditLogRepository, logger *zerolog.Logger) *StopImpersonationUseCase {
+	return &StopImpersonationUseCase{sessionRepo, auditRepo, logger}
+}
+
+func (uc *StopImpersonationUseCase) Execute(ctx context.Context, userID, sessionID int, ip string) error {
+	sessions, err := uc.sessionRepo.FindByUserID(ctx, int64(userID))
+	if err != nil {
+		return fmt.Errorf("failed to find sessions: %w", err)
+	}
+
+	var current *entities.Session
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			current = session
+			break
+		}
+	}
+	if current == nil || current.ImpersonatorID == 0 {
+		return ErrNotImpersonating
+	}
+
+	if err := uc.sessionRepo.Invalidate(ctx, current.AccessTokenHash); err != nil {
+		return fmt.Errorf("failed to close impersonation session: %w", err)
+	}
+
+	recordAudit(ctx, uc.auditRepo, uc.logger, &entities.AuditLog{
+		ActorID:      current.ImpersonatorID,
+		Action:       entities.AuditActionImpersonationStop,
+		TargetUserID: userID,
+		SessionID:    current.ID,
+		IP:           ip,
+	}, map[string]any{"duration_seconds": int(time.Since(current.CreatedAt).Seconds())})
+
+	uc.logger.Info().
+		Int("admin_id", current.ImpersonatorID).
+		Int("user_id", userID).
+		Int("session_id", current.ID).
+		Msg("Admin stopped impersonation")
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type ListUsersUseCase struct {
+	userRepo repository.UserRepository
+}
+
+func NewListUsersUseCase(userRepo repository.UserRepository) *ListUsersUseCase {
+	return &ListUsersUseCase{userRepo}
+}
+
+func (uc *ListUsersUseCase) Execute(ctx context.Context, query dtos.AdminUserQuery) (*dtos.AdminUserListResponse, error) {
+	page := query.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := query.PageSize
+	if pageSize < 1 || pageSize > maxPageSize {
+		pageSize = defaultPageSize
+	}
+
+	filter := repository.UserFilter{
+		Email:    query.Email,
+		Verified: query.Verified,
+		Deleted:  query.Deleted,
+		Active:   query.Active,
+		Plan:     query.Plan,
+		Limit:    pageSize,
+		Offset:   (page - 1) * pageSize,
+	}
+	if query.CreatedFrom != nil {
+		filter.CreatedFrom = *query.CreatedFrom
+	}
+	if query.CreatedTo != nil {
+		// La fecha llega sin hora, se incluye el día completo
+		filter.CreatedTo = query.CreatedTo.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	users, total, err := uc.userRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	result := make([]dtos.AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		result = append(result, newAdminUserResponse(user))
+	}
+
+	return &dtos.AdminUserListResponse{
+		Users:    result,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func newAdminUserResponse(user *entities.User) dtos.AdminUserResponse {
+	return dtos.AdminUserResponse{
+		ID:           user.ID,
+		Email:        user.Email,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Role:         user.Role,
+		Providers:    user.Providers,
+		IsActive:     user.IsActive,
+		Deleted:      user.Deleted,
+		Verified:     user.Verified,
+		MFAEnabled:   user.MFAEnabled,
+		CreatedAt:    user.CreatedAt,
+		LastLogin:    user.LastLogin,
+		Subscription: user.Subscription,
+	}
+}
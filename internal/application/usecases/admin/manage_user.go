@@ -0,0 +1,241 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrCannotModifySelf = errors.New("admins cannot apply this action to their own account")
+	ErrInsufficientRole = errors.New("admins cannot manage accounts with the same or a higher role")
+)
+
+// userManager agrupa las dependencias comunes de las acciones que modifican el estado de una cuenta
+type userManager struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	tokenRepo   repository.PersonalAccessTokenRepository
+	cache       *cache.Cache
+	logger      *zerolog.Logger
+}
+
+func (m *userManager) findUser(userID int) (*entities.User, error) {
+	user, err := m.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// findManageableUser es findUser para las acciones que cortan el acceso de otra cuenta: si no, un
+// administrador podría desactivar o desloguear a otro administrador o a un superadmin. Sobre la
+// propia cuenta valen las reglas de cada acción.
+func (m *userManager) findManageableUser(adminID, userID int) (*entities.User, error) {
+	user, err := m.findUser(userID)
+	if err != nil || adminID == userID {
+		return user, err
+	}
+
+	actor, err := m.userRepo.FindByID(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find admin: %w", err)
+	}
+	if actor == nil || entities.RoleRank(user.Role) >= entities.RoleRank(actor.Role) {
+		m.logger.Warn().
+			Int("admin_id", adminID).
+			Int("user_id", userID).
+			Str("user_role", user.Role).
+			Msg("Admin action refused on an account with the same or a higher role")
+		return nil, ErrInsufficientRole
+	}
+	return user, nil
+}
+
+// revokeAccess cierra todas las sesiones del usuario, revoca sus tokens personales y descarta su
+// perfil cacheado
+func (m *userManager) revokeAccess(ctx context.Context, userID int) error {
+	if err := m.sessionRepo.InvalidateByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+	if err := m.tokenRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	m.clearProfileCache(ctx, userID)
+	return nil
+}
+
+func (m *userManager) clearProfileCache(ctx context.Context, userID int) {
+	if err := m.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID)); err != nil {
+		m.logger.Warn().Err(err).Int("user_id", userID).Msg("Failed to clear profile cache")
+	}
+}
+
+type SetUserActiveUseCase struct {
+	userManager
+}
+
+func NewSetUserActiveUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, cacheService *cache.Cache, logger *zerolog.Logger) *SetUserActiveUseCase {
+	return &SetUserActiveUseCase{userManager{userRepo, sessionRepo, tokenRepo, cacheService, logger}}
+}
+
+func (uc *SetUserActiveUseCase) Execute(ctx context.Context, adminID int, userID int, active bool) error {
+	if adminID == userID && !active {
+		return ErrCannotModifySelf
+	}
+
+	if _, err := uc.findManageableUser(adminID, userID); err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.UpdateActive(userID, active); err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	if active {
+		uc.clearProfileCache(ctx, userID)
+	} else if err := uc.revokeAccess(ctx, userID); err != nil {
+		return err
+	}
+
+	uc.logger.Info().
+		Int("admin_id", adminID).
+		Int("user_id", userID).
+		Bool("active", active).
+		Msg("Admin changed user active status")
+
+	return nil
+}
+
+type SetUserDeletedUseCase struct {
+	userManager
+}
+
+func NewSetUserDeletedUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, cacheService *cache.Cache, logger *zerolog.Logger) *SetUserDeletedUseCase {
+	return &SetUserDeletedUseCase{userManager{userRepo, sessionRepo, tokenRepo, cacheService, logger}}
+}
+
+func (uc *SetUserDeletedUseCase) Execute(ctx context.Context, adminID int, userID int, deleted bool) error {
+	if adminID == userID && deleted {
+		return ErrCannotModifySelf
+	}
+
+	if _, err := uc.findManageableUser(adminID, userID); err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.UpdateDeleted(userID, deleted); err != nil {
+		return fmt.Errorf("failed to update user deleted flag: %w", err)
+	}
+
+	if deleted {
+		if err := uc.revokeAccess(ctx, userID); err != nil {
+			return err
+		}
+	} else {
+		uc.clearProfileCache(ctx, userID)
+	}
+
+	uc.logger.Info().
+		Int("admin_id", adminID).
+		Int("user_id", userID).
+		Bool("deleted", deleted).
+		Msg("Admin changed user deleted status")
+
+	return nil
+}
+
+type ForceVerifyEmailUseCase struct {
+	userManager
+	emailVerificationRepo repository.EmailVerificationRepository
+}
+
+func NewForceVerifyEmailUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, emailVerificationRepo repository.EmailVerificationRepository, cacheService *cache.Cache, logger *zerolog.Logger) *ForceVerifyEmailUseCase {
+	return &ForceVerifyEmailUseCase{userManager{userRepo, sessionRepo, tokenRepo, cacheService, logger}, emailVerificationRepo}
+}
+
+func (uc *ForceVerifyEmailUseCase) Execute(ctx context.Context, adminID int, userID int) error {
+	user, err := uc.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Verified {
+		return nil
+	}
+
+	if err := uc.userRepo.UpdateEmailVerified(userID, true); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	if err := uc.emailVerificationRepo.MarkAsVerified(ctx, userID); err != nil {
+		return fmt.Errorf("failed to mark email verification: %w", err)
+	}
+	uc.clearProfileCache(ctx, userID)
+
+	uc.logger.Info().
+		Int("admin_id", adminID).
+		Int("user_id", userID).
+		Msg("Admin forced email verification")
+
+	return nil
+}
+
+type ForceLogoutUseCase struct {
+	userManager
+}
+
+func NewForceLogoutUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, cacheService *cache.Cache, logger *zerolog.Logger) *ForceLogoutUseCase {
+	return &ForceLogoutUseCase{userManager{userRepo, sessionRepo, tokenRepo, cacheService, logger}}
+}
+
+func (uc *ForceLogoutUseCase) Execute(ctx context.Context, adminID int, userID int) error {
+	if _, err := uc.findManageableUser(adminID, userID); err != nil {
+		return err
+	}
+
+	if err := uc.revokeAccess(ctx, userID); err != nil {
+		return err
+	}
+
+	uc.logger.Info().
+		Int("admin_id", adminID).
+		Int("user_id", userID).
+		Msg("Admin forced logout")
+
+	return nil
+}
+
+// UnlockLoginUseCase levanta el bloqueo por intentos fallidos antes de que venza
+type UnlockLoginUseCase struct {
+	userManager
+	lockout *security.LoginLockout
+}
+
+func NewUnlockLoginUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, cacheService *cache.Cache, lockout *security.LoginLockout, logger *zerolog.Logger) *UnlockLoginUseCase {
+	return &UnlockLoginUseCase{userManager{userRepo, sessionRepo, tokenRepo, cacheService, logger}, lockout}
+}
+
+func (uc *UnlockLoginUseCase) Execute(ctx context.Context, adminID int, userID int) error {
+	if _, err := uc.findUser(userID); err != nil {
+		return err
+	}
+
+	if err := uc.lockout.Unlock(ctx, userID); err != nil {
+		return fmt.Errorf("failed to unlock login: %w", err)
+	}
+
+	uc.logger.Info().
+		Int("admin_id", adminID).
+		Int("user_id", userID).
+		Msg("Admin unlocked login")
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
+
+	"github.com/rs/zerolog"
+)
+
+type AdminUseCases struct {
+	ListUsers          *ListUsersUseCase
+	GetUser            *GetUserUseCase
+	SetUserActive      *SetUserActiveUseCase
+	SetUserDeleted     *SetUserDeletedUseCase
+	ForceVerifyEmail   *ForceVerifyEmailUseCase
+	ForceLogout        *ForceLogoutUseCase
+	UnlockLogin        *UnlockLoginUseCase
+	StartImpersonation *StartImpersonationUseCase
+	StopImpersonation  *StopImpersonationUseCase
+}
+
+func NewAdminUseCases(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	emailVerificationRepo repository.EmailVerificationRepository,
+	auditRepo repository.AuditLogRepository,
+	cacheService *cache.Cache,
+	loginLockout *security.LoginLockout,
+	logger *zerolog.Logger) *AdminUseCases {
+
+	return &AdminUseCases{
+		ListUsers:          NewListUsersUseCase(userRepo),
+		GetUser:            NewGetUserUseCase(userRepo, sessionRepo, loginLockout),
+		SetUserActive:      NewSetUserActiveUseCase(userRepo, sessionRepo, tokenRepo, cacheService, logger),
+		SetUserDeleted:     NewSetUserDeletedUseCase(userRepo, sessionRepo, tokenRepo, cacheService, logger),
+		ForceVerifyEmail:   NewForceVerifyEmailUseCase(userRepo, sessionRepo, tokenRepo, emailVerificationRepo, cacheService, logger),
+		ForceLogout:        NewForceLogoutUseCase(userRepo, sessionRepo, tokenRepo, cacheService, logger),
+		UnlockLogin:        NewUnlockLoginUseCase(userRepo, sessionRepo, tokenRepo, cacheService, loginLockout, logger),
+		StartImpersonation: NewStartImpersonationUseCase(userRepo, sessionRepo, tokenRepo, auditRepo, cacheService, logger),
+		StopImpersonation:  NewStopImpersonationUseCase(sessionRepo, auditRepo, logger),
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+type ForgotPasswordUseCase struct {
+	userRepo          repository.UserRepository
+	passwordResetRepo repository.PasswordResetRepository
+	emailService      *email.EmailService
+	logger            *zerolog.Logger
+	appClientURL      string
+}
+
+func NewForgotPasswordUseCase(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+) *ForgotPasswordUseCase {
+	return &ForgotPasswordUseCase{
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		emailService:      emailService,
+		logger:            logger,
+		appClientURL:      appClientURL,
+	}
+}
+
+// Execute no informa si el email existe o no, para no permitir enumerar cuentas
+func (uc *ForgotPasswordUseCase) Execute(ctx context.Context, userEmail string) error {
+	user, err := uc.userRepo.FindByEmail(userEmail)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Str("email", userEmail).
+			Msg("Failed to find user by email")
+		return err
+	}
+
+	if user == nil || user.Deleted {
+		uc.logger.Warn().
+			Str("email", userEmail).
+			Msg("Password reset requested for unknown email")
+		return nil
+	}
+
+	return uc.sendResetLink(ctx, user,
+		"Restablecé tu contraseña",
+		"Recibimos un pedido para restablecer tu contraseña.",
+		"Si no fuiste vos, ignorá este email.",
+	)
+}
+
+// sendResetLink emite un token nuevo y lo manda por email. También lo usa el aviso de
+// "no fui yo", que obliga a restablecer la contraseña.
+func (uc *ForgotPasswordUseCase) sendResetLink(ctx context.Context, user *entities.User, subject, intro, outro string) error {
+	// Solo el último link enviado debe ser válido
+	if err := uc.passwordResetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to invalidate previous password resets")
+		return fmt.Errorf("failed to invalidate previous password resets: %w", err)
+	}
+
+	token, err := security.GenerateSecureToken(32)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to generate reset token")
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	tokenHash, err := security.HashToken(token)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to hash reset token")
+		return fmt.Errorf("failed to hash reset token: %w", err)
+	}
+
+	reset := &entities.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+		Used:      false,
+	}
+
+	if err := uc.passwordResetRepo.Create(ctx, reset); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to create password reset")
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	resetLink := fmt.Sprintf("%s/auth/reset-password?token=%s", uc.appClientURL, url.QueryEscape(token))
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf(
+			"%s Ingresá al siguiente link para elegir una nueva: <a href=\"%s\">%s</a>. El link vence en %d minutos. %s",
+			intro, resetLink, resetLink, int(passwordResetTTL.Minutes()), outro,
+		),
+	}
+
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("email", user.Email).
+			Msg("Failed to send password reset email")
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	uc.logger.Info().
+		Int("user_id", user.ID).
+		Str("email", user.Email).
+		Msg("Password reset email sent successfully")
+
+	return nil
+}
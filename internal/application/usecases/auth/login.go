@@ -19,6 +19,8 @@ type LoginUseCase struct {
 	emailVerificationRepo  repository.EmailVerificationRepository
 	sessionRepo 			repository.SessionRepository
 	emailService *email.EmailService
+	lockout     *security.LoginLockout
+	newDevices  *newDeviceNotifier
 	logger      *zerolog.Logger
 }
 
@@ -28,6 +30,8 @@ func NewLoginUseCase(
 	emailVerificationRepo repository.EmailVerificationRepository, 
 	sessionRepo repository.SessionRepository, 
 	emailService *email.EmailService,
+	lockout *security.LoginLockout,
+	newDevices *newDeviceNotifier,
 	logger *zerolog.Logger) *LoginUseCase {
 
 	return &LoginUseCase{
@@ -35,11 +39,15 @@ func NewLoginUseCase(
 		emailVerificationRepo: emailVerificationRepo,
 		sessionRepo: sessionRepo,
 		emailService: emailService,
+		lockout: lockout,
+		newDevices: newDevices,
 		logger: logger,
 	}
 }
 
-func (uc *LoginUseCase) Execute(input dtos.LoginInput, deviceInfo string) (*dtos.LoginResponse, error) {
+func (uc *LoginUseCase) Execute(input dtos.LoginInput, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
+	ctx := context.TODO()
+
 	user, err := uc.userRepo.FindByEmail(input.Email)
 	if err != nil {
 		uc.logger.Error().
@@ -56,81 +64,135 @@ func (uc *LoginUseCase) Execute(input dtos.LoginInput, deviceInfo string) (*dtos
 		return nil, errors.New("user not found")
 	}
 
-	if user.LoginMethod != nil {
+	// Las cuentas creadas con un proveedor externo no tienen contraseña hasta que el usuario la vincula
+	if user.Password == "" {
 		uc.logger.Error().
             Int("user_id", user.ID).
             Str("email", input.Email).
-            Str("login_method", *user.LoginMethod).
-            Msg("Invalid login method")
+            Strs("providers", user.Providers).
+            Msg("User without password credential tried to login")
 		return nil, errors.New("invalid login method")
 	}
 
-	if user.Deleted {
+	// Una cuenta que borró su dueño se puede recuperar iniciando sesión hasta que vence el plazo
+	if user.Deleted && !accountRestorable(user) {
 		uc.logger.Error().
             Int("user_id", user.ID).
             Str("email", input.Email).
             Msg("User deleted tried to login")
-		return nil, errors.New("account deleted")
+		return nil, ErrAccountDeleted
 	}	
 
+	if !user.IsActive {
+		uc.logger.Error().
+            Int("user_id", user.ID).
+            Str("email", input.Email).
+            Msg("Deactivated user tried to login")
+		return nil, errors.New("account deactivated")
+	}
+
+	// Mientras la cuenta está bloqueada ni siquiera se compara la contraseña
+	if err := uc.lockout.Check(ctx, user.ID); err != nil {
+		uc.logger.Warn().
+            Err(err).
+            Int("user_id", user.ID).
+            Str("email", input.Email).
+            Str("ip", clientIP).
+            Str("device_info", deviceInfo).
+            Msg("Login attempt on throttled account")
+		return nil, err
+	}
+
 	if !security.ComparePasswords(user.Password, input.Password) {
 		uc.logger.Error().
             Int("user_id", user.ID).
             Str("email", input.Email).
+            Str("ip", clientIP).
+            Str("device_info", deviceInfo).
             Msg("Invalid credentials")
+
+		locked, err := uc.lockout.RegisterFailure(ctx, user.ID)
+		if err != nil {
+			uc.logger.Error().
+                Err(err).
+                Int("user_id", user.ID).
+                Msg("Failed to register login failure")
+		} else if locked {
+			uc.notifyLockout(ctx, user, deviceInfo, clientIP)
+		}
 		return nil, errors.New("invalid credentials")
 	}
 
+	if err := uc.lockout.Reset(ctx, user.ID); err != nil {
+		uc.logger.Warn().
+            Err(err).
+            Int("user_id", user.ID).
+            Msg("Failed to reset login failures")
+	}
+
+	// El usuario denunció un acceso ajeno, la contraseña actual puede estar comprometida
+	if user.PasswordResetRequired {
+		uc.logger.Warn().
+            Int("user_id", user.ID).
+            Str("ip", clientIP).
+            Str("device_info", deviceInfo).
+            Msg("Password login blocked until password reset")
+		return nil, ErrPasswordResetRequired
+	}
+
+	return uc.completeLogin(ctx, user, deviceInfo, clientIP)
+}
+
+// notifyLockout avisa al dueño de la cuenta que alguien está probando contraseñas
+func (uc *LoginUseCase) notifyLockout(ctx context.Context, user *entities.User, deviceInfo, clientIP string) {
+	uc.logger.Warn().
+        Int("user_id", user.ID).
+        Str("email", user.Email).
+        Str("ip", clientIP).
+        Str("device_info", deviceInfo).
+        Msg("Account locked after too many failed login attempts")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Bloqueamos temporalmente el acceso a tu cuenta",
+		Body: fmt.Sprintf(
+			"Detectamos demasiados intentos fallidos de iniciar sesión en tu cuenta, el último desde la IP %s (%s). Por seguridad bloqueamos el ingreso con contraseña durante un rato. Si no fuiste vos, te recomendamos restablecer tu contraseña y activar la verificación en dos pasos.",
+			clientIP, deviceInfo,
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+            Err(err).
+            Int("user_id", user.ID).
+            Str("email", user.Email).
+            Msg("Failed to send lockout email")
+	}
+}
+
+// completeLogin continúa el login una vez validada la credencial: verificación de email pendiente,
+// segundo factor o creación de la sesión. Lo comparten la contraseña y el magic link.
+func (uc *LoginUseCase) completeLogin(ctx context.Context, user *entities.User, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
 	if !user.Verified {
-        ctx := context.TODO()
-        emailVerification, err := uc.emailVerificationRepo.GetByUserID(ctx, user.ID)
-        if err != nil {
+		// Solo se guarda el hash del código, así que cada login sin verificar manda uno nuevo
+		verificationCode, expiresAt, err := issueVerificationCode(ctx, uc.userRepo, uc.emailVerificationRepo, user.ID)
+		if err != nil {
 			uc.logger.Error().
                 Err(err).
                 Int("user_id", user.ID).
-                Str("email", input.Email).
-                Msg("Failed to get email verification by user id")
-            return nil, err
-        }
-
-        var verificationToken, verificationCode string
-		expiresAt := time.Now().Add(15 * time.Minute) 
-
-		verificationToken, err = security.CreateVerificationToken(user.ID, user.Email, expiresAt)
+                Str("email", user.Email).
+                Msg("Failed to issue verification code")
+			return nil, err
+		}
 
+		verificationToken, err := security.CreateVerificationToken(user.ID, user.Email, expiresAt)
 		if err != nil {
 			uc.logger.Error().
                 Err(err).
                 Int("user_id", user.ID).
-                Str("email", input.Email).
+                Str("email", user.Email).
                 Msg("Failed to create verification token")
 			return nil, err
 		}
-        if emailVerification == nil || time.Now().After(emailVerification.ExpiresAt) {
-            verificationCode, err = security.GenerateVerificationCode(6)
-			if err != nil {
-				uc.logger.Error().
-                    Err(err).
-                    Int("user_id", user.ID).
-                    Str("email", input.Email).
-                    Msg("Failed to generate verification code")
-                return nil, err
-            }
-
-
-            err = uc.emailVerificationRepo.UpdateCode(ctx, emailVerification.ID, verificationCode, expiresAt)
-			
-            if err != nil {
-				uc.logger.Error().
-                    Err(err).
-                    Int("user_id", user.ID).
-                    Str("email", input.Email).
-                    Msg("Failed to update verification code")
-                return nil, err
-            }
-        } else {
-            verificationCode = emailVerification.Code
-        }
 
         emailJob := email.EmailJob{
 			To:      user.Email,
@@ -138,18 +200,18 @@ func (uc *LoginUseCase) Execute(input dtos.LoginInput, deviceInfo string) (*dtos
 			Body:    fmt.Sprintf("Tu código de verificación es: %s", verificationCode),
 		}
 
-		if err := uc.emailService.SendEmailAsync(context.Background(), emailJob); err != nil {
+		if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
 			uc.logger.Error().
                 Err(err).
                 Int("user_id", user.ID).
-                Str("email", input.Email).
+                Str("email", user.Email).
                 Msg("Failed to send verification email")
 			return nil, fmt.Errorf("falló el envío del email de verificación: %w", err)
 		}
 
 		uc.logger.Info().
             Int("user_id", user.ID).
-            Str("email", input.Email).
+            Str("email", user.Email).
             Msg("Verification email sent successfully")
 
 		return &dtos.LoginResponse{
@@ -160,106 +222,73 @@ func (uc *LoginUseCase) Execute(input dtos.LoginInput, deviceInfo string) (*dtos
         }, nil
     }
 
-	// Update LastLogin timestamp
-    currentTime := time.Now()
-    err = uc.userRepo.UpdateLastLogin(context.TODO(), user.ID, currentTime)
-    if err != nil {
-		uc.logger.Error().
-            Err(err).
-            Int("user_id", user.ID).
-            Str("email", input.Email).
-            Msg("Failed to update last login")
-        return nil, err
-    }
+	if user.MFAEnabled {
+		mfaExpiresAt := time.Now().Add(5 * time.Minute)
+		mfaToken, err := security.CreateMFAToken(user.ID, mfaExpiresAt)
+		if err != nil {
+			uc.logger.Error().
+	            Err(err).
+	            Int("user_id", user.ID).
+	            Str("email", user.Email).
+	            Msg("Failed to create mfa token")
+			return nil, err
+		}
 
-	user.LastLogin = currentTime.Format(time.RFC3339)
+		uc.logger.Info().
+	        Int("user_id", user.ID).
+	        Str("email", user.Email).
+	        Msg("MFA required to complete login")
 
-	accessToken, err := security.CreateAccessToken(user.ID)
-	if err != nil {
-		uc.logger.Error().
-            Err(err).
-            Int("user_id", user.ID).
-            Str("email", input.Email).
-            Msg("Failed to create access token")
-		return nil, err
+		return &dtos.LoginResponse{
+			MFARequired:  true,
+			MFAToken:     mfaToken,
+			MFAExpiresAt: mfaExpiresAt,
+		}, nil
 	}
 
-	refreshToken, err := security.CreateRefreshToken(user.ID)
-	if err != nil {
-		uc.logger.Error().
-            Err(err).
-            Int("user_id", user.ID).
-            Str("email", input.Email).
-            Msg("Failed to create refresh token")
+	return uc.startSession(ctx, user, deviceInfo, clientIP)
+}
+
+// startSession registra el último login y emite los tokens. Las passkeys llegan directo acá porque
+// la verificación del autenticador ya cuenta como segundo factor.
+func (uc *LoginUseCase) startSession(ctx context.Context, user *entities.User, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
+	if err := restoreDeletedAccount(ctx, uc.userRepo, uc.emailService, uc.logger, user); err != nil {
 		return nil, err
 	}
 
-	accessTokenHash, err := security.HashToken(accessToken)
-    if err != nil {
-		uc.logger.Error().
-            Err(err).
-            Int("user_id", user.ID).
-            Str("email", input.Email).
-            Msg("Failed to hash access token")
-        return nil, fmt.Errorf("failed to hash access token: %w", err)
-    }
-
-    refreshTokenHash, err := security.HashToken(refreshToken)
-    if err != nil {
+	// Update LastLogin timestamp
+    currentTime := time.Now()
+    if err := uc.userRepo.UpdateLastLogin(ctx, user.ID, currentTime); err != nil {
 		uc.logger.Error().
             Err(err).
             Int("user_id", user.ID).
-            Str("email", input.Email).
-            Msg("Failed to hash refresh token")
-        return nil, fmt.Errorf("failed to hash refresh token: %w", err)
+            Str("email", user.Email).
+            Msg("Failed to update last login")
+        return nil, err
     }
 
-    session := &entities.Session{
-        UserID:           user.ID,
-        AccessTokenHash:  accessTokenHash,
-        RefreshTokenHash: refreshTokenHash,
-        ExpiresAt:        time.Now().Add(15 * time.Minute), 
-        RefreshExpiresAt: time.Now().Add(7 * 24 * time.Hour), 
-        IsValid:          true,
-		DeviceInfo:       deviceInfo,
-    }
+	user.LastLogin = currentTime.Format(time.RFC3339)
 
-    ctx := context.TODO()
-    err = uc.sessionRepo.Create(ctx, session)
-    if err != nil {
+	accessToken, refreshToken, session, err := createSession(ctx, uc.sessionRepo, user, deviceInfo, clientIP, "")
+	if err != nil {
 		uc.logger.Error().
             Err(err).
             Int("user_id", user.ID).
-            Str("email", input.Email).
+            Str("email", user.Email).
             Str("device_info", deviceInfo).
             Msg("Failed to create session")
-        return nil, fmt.Errorf("failed to create session: %w", err)
-    }
+		return nil, err
+	}
+	uc.newDevices.Notify(ctx, user, session)
 
 	uc.logger.Info().
         Int("user_id", user.ID).
-        Str("email", input.Email).
+        Str("email", user.Email).
         Str("device_info", deviceInfo).
-        Str("access_token_hash", accessTokenHash).
         Msg("User logged in successfully")
 		
-	profile := &dtos.ProfileResponse{
-        ID:           user.ID,
-        Email:        user.Email,
-        FirstName:    user.FirstName,
-        LastName:     user.LastName,
-        Phone:        user.Phone,
-        Address:      user.Address,
-        Country:      user.Country,
-        WorkshopName: user.WorkshopName,
-        LastLogin:    user.LastLogin,
-		LoginMethod:  func() string { if user.LoginMethod != nil { return *user.LoginMethod }; return "" }(),
-        HasPassword:  user.Password != "",
-		Subscription: user.Subscription,
-    }
-
 	return &dtos.LoginResponse{
-		Profile: profile,
+		Profile: newProfileResponse(user),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}, nil
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const magicLinkTTL = 15 * time.Minute
+
+var ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+
+// En Redis se guarda solo el hash del token, quien lea el cache no puede armar el link
+func magicLinkKey(tokenHash string) string {
+	return "magic_link:" + tokenHash
+}
+
+func magicLinkUserKey(userID int) string {
+	return fmt.Sprintf("magic_link:user:%d", userID)
+}
+
+type RequestMagicLinkUseCase struct {
+	userRepo     repository.UserRepository
+	cacheService *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+	appClientURL string
+}
+
+func NewRequestMagicLinkUseCase(
+	userRepo repository.UserRepository,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+) *RequestMagicLinkUseCase {
+	return &RequestMagicLinkUseCase{
+		userRepo:     userRepo,
+		cacheService: cacheService,
+		emailService: emailService,
+		logger:       logger,
+		appClientURL: appClientURL,
+	}
+}
+
+// Execute no informa si el email existe o no, para no permitir enumerar cuentas
+func (uc *RequestMagicLinkUseCase) Execute(ctx context.Context, userEmail string) error {
+	user, err := uc.userRepo.FindByEmail(userEmail)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Str("email", userEmail).
+			Msg("Failed to find user by email")
+		return err
+	}
+
+	if user == nil || (user.Deleted && !accountRestorable(user)) || !user.IsActive {
+		uc.logger.Warn().
+			Str("email", userEmail).
+			Msg("Magic link requested for unknown or disabled account")
+		return nil
+	}
+
+	token, err := security.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate magic link token: %w", err)
+	}
+	tokenHash, err := security.HashToken(token)
+	if err != nil {
+		return fmt.Errorf("failed to hash magic link token: %w", err)
+	}
+
+	// Solo el último link enviado debe ser válido
+	if previousHash, err := uc.cacheService.Get(ctx, magicLinkUserKey(user.ID)); err == nil {
+		_ = uc.cacheService.Delete(ctx, magicLinkKey(previousHash))
+	}
+
+	if err := uc.cacheService.Set(ctx, magicLinkKey(tokenHash), strconv.Itoa(user.ID), magicLinkTTL); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to store magic link")
+		return fmt.Errorf("failed to store magic link: %w", err)
+	}
+	if err := uc.cacheService.Set(ctx, magicLinkUserKey(user.ID), tokenHash, magicLinkTTL); err != nil {
+		return fmt.Errorf("failed to store magic link: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/auth/magic-link?token=%s", uc.appClientURL, url.QueryEscape(token))
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Tu link para iniciar sesión",
+		Body: fmt.Sprintf(
+			"Ingresá al siguiente link para iniciar sesión sin contraseña: <a href=\"%s\">%s</a>. El link vence en %d minutos y se puede usar una sola vez. Si no lo pediste, ignorá este email.",
+			link, link, int(magicLinkTTL.Minutes()),
+		),
+	}
+
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("email", user.Email).
+			Msg("Failed to send magic link email")
+		return fmt.Errorf("failed to send magic link email: %w", err)
+	}
+
+	uc.logger.Info().
+		Int("user_id", user.ID).
+		Str("email", user.Email).
+		Msg("Magic link email sent successfully")
+
+	return nil
+}
+
+type MagicLinkLoginUseCase struct {
+	userRepo     repository.UserRepository
+	cacheService *cache.Cache
+	login        *LoginUseCase
+	logger       *zerolog.Logger
+}
+
+func NewMagicLinkLoginUseCase(
+	userRepo repository.UserRepository,
+	cacheService *cache.Cache,
+	login *LoginUseCase,
+	logger *zerolog.Logger,
+) *MagicLinkLoginUseCase {
+	return &MagicLinkLoginUseCase{
+		userRepo:     userRepo,
+		cacheService: cacheService,
+		login:        login,
+		logger:       logger,
+	}
+}
+
+func (uc *MagicLinkLoginUseCase) Execute(ctx context.Context, token, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
+	tokenHash, err := security.HashToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash magic link token: %w", err)
+	}
+
+	// GetDel lo consume de forma atómica, dos clicks simultáneos no crean dos sesiones
+	cachedUserID, err := uc.cacheService.GetDel(ctx, magicLinkKey(tokenHash))
+	if err != nil {
+		uc.logger.Warn().Msg("Invalid or expired magic link used")
+		return nil, ErrInvalidMagicLink
+	}
+
+	userID, err := strconv.Atoi(cachedUserID)
+	if err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+	_ = uc.cacheService.Delete(ctx, magicLinkUserKey(userID))
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrInvalidMagicLink
+	}
+
+	if user.Deleted && !accountRestorable(user) {
+		uc.logger.Error().
+			Int("user_id", user.ID).
+			Msg("User deleted tried to login with magic link")
+		return nil, ErrAccountDeleted
+	}
+	if !user.IsActive {
+		uc.logger.Error().
+			Int("user_id", user.ID).
+			Msg("Deactivated user tried to login with magic link")
+		return nil, errors.New("account deactivated")
+	}
+
+	return uc.login.completeLogin(ctx, user, deviceInfo, clientIP)
+}
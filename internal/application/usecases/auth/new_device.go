@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Solo se comparan los logins recientes, un dispositivo que no se usa hace meses vuelve a avisarse
+	knownDeviceLookback = 90 * 24 * time.Hour
+	// El link de "no fui yo" dura lo mismo que el refresh token de la sesión que denuncia
+	unrecognizedLoginTTL = 7 * 24 * time.Hour
+)
+
+func unrecognizedLoginKey(tokenHash string) string {
+	return "unrecognized_login:" + tokenHash
+}
+
+// deviceFingerprint descarta la versión del navegador de deviceInfo ("Chrome 120.0, Windows 10, Desktop"),
+// así una actualización del navegador no cuenta como un dispositivo nuevo
+func deviceFingerprint(deviceInfo string) string {
+	parts := strings.Split(deviceInfo, ", ")
+	if browser := strings.TrimSpace(parts[0]); strings.Contains(browser, " ") {
+		parts[0] = browser[:strings.LastIndex(browser, " ")]
+	}
+	return strings.ToLower(strings.Join(parts, ", "))
+}
+
+// newDeviceNotifier avisa por email cuando se inicia sesión desde un dispositivo o IP que el usuario
+// no usó antes, con un link para denunciar el acceso
+type newDeviceNotifier struct {
+	sessionRepo  repository.SessionRepository
+	cacheService *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+	appClientURL string
+}
+
+func newNewDeviceNotifier(
+	sessionRepo repository.SessionRepository,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+) *newDeviceNotifier {
+	return &newDeviceNotifier{sessionRepo, cacheService, emailService, logger, appClientURL}
+}
+
+// Notify se llama con la sesión recién creada. Los errores solo se registran: no avisar de un
+// login nunca debe impedirlo.
+func (n *newDeviceNotifier) Notify(ctx context.Context, user *entities.User, session *entities.Session) {
+	history, err := n.sessionRepo.FindHistoryByUserID(ctx, user.ID, time.Now().Add(-knownDeviceLookback))
+	if err != nil {
+		n.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to load session history")
+		return
+	}
+
+	fingerprint := deviceFingerprint(session.DeviceInfo)
+	previous := 0
+	for _, past := range history {
+		// Las sesiones de impersonación las abre soporte, no dicen nada de los dispositivos del usuario
+		if past.ID == session.ID || past.ImpersonatorID != 0 {
+			continue
+		}
+		previous++
+		if past.IPAddress == session.IPAddress && deviceFingerprint(past.DeviceInfo) == fingerprint {
+			return
+		}
+	}
+	// El primer login de la cuenta no tiene con qué compararse
+	if previous == 0 {
+		return
+	}
+
+	token, err := security.GenerateSecureToken(32)
+	if err != nil {
+		n.logger.Error().Err(err).Int("user_id", user.ID).Msg("Failed to generate unrecognized login token")
+		return
+	}
+	tokenHash, err := security.HashToken(token)
+	if err != nil {
+		n.logger.Error().Err(err).Int("user_id", user.ID).Msg("Failed to hash unrecognized login token")
+		return
+	}
+	value := fmt.Sprintf("%d:%d", user.ID, session.ID)
+	if err := n.cacheService.Set(ctx, unrecognizedLoginKey(tokenHash), value, unrecognizedLoginTTL); err != nil {
+		n.logger.Error().Err(err).Int("user_id", user.ID).Msg("Failed to store unrecognized login token")
+		return
+	}
+
+	n.logger.Info().
+		Int("user_id", user.ID).
+		Int("session_id", session.ID).
+		Str("ip", session.IPAddress).
+		Str("device_info", session.DeviceInfo).
+		Msg("Login from new device")
+
+	link := fmt.Sprintf("%s/auth/not-me?token=%s", n.appClientURL, url.QueryEscape(token))
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Nuevo inicio de sesión en tu cuenta",
+		Body: fmt.Sprintf(
+			"Se inició sesión en tu cuenta desde un dispositivo nuevo: %s, IP %s, el %s (UTC). Si fuiste vos, no tenés que hacer nada. Si no fuiste vos, ingresá a <a href=\"%s\">%s</a>: cerramos esa sesión y te pedimos que cambies tu contraseña.",
+			session.DeviceInfo, session.IPAddress, session.CreatedAt.UTC().Format("02/01/2006 15:04"), link, link,
+		),
+	}
+	if err := n.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		n.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("email", user.Email).
+			Msg("Failed to send new device email")
+	}
+}
@@ -0,0 +1,531 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"strings"
+	"time"
+
+	idp "luthierSaas/internal/infrastructure/auth"
+
+	"github.com/rs/zerolog"
+)
+
+type OAuthCallbackUseCase struct {
+	providers             *idp.Registry
+	userRepo              repository.UserRepository
+	subscriptionRepo      repository.SubscriptionRepository
+	emailVerificationRepo repository.EmailVerificationRepository
+	sessionRepo           repository.SessionRepository
+	identityRepo          repository.UserIdentityRepository
+	emailService          *email.EmailService
+	cacheService          *cache.Cache
+	newDevices            *newDeviceNotifier
+	logger                *zerolog.Logger
+}
+
+var (
+	ErrInvalidOAuthState     = errors.New("invalid or expired CSRF state")
+	ErrIdentityNotLinked     = errors.New("an account with this email already exists, sign in and link the provider from your profile")
+	ErrEmailNotVerified      = errors.New("the email must be verified on both the account and the provider")
+	ErrLinkSessionRequired   = errors.New("linking a provider requires the session that started it")
+	ErrIdentityAlreadyLinked = errors.New("this identity is already linked to another account")
+	ErrProviderAlreadyLinked = errors.New("another account from this provider is already linked")
+)
+
+func NewOAuthCallbackUseCase(
+	providers *idp.Registry,
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	emailVerificationRepo repository.EmailVerificationRepository,
+	sessionRepo repository.SessionRepository,
+	identityRepo repository.UserIdentityRepository,
+	emailService *email.EmailService,
+	cacheService *cache.Cache,
+	newDevices *newDeviceNotifier,
+	logger *zerolog.Logger,
+) *OAuthCallbackUseCase {
+	return &OAuthCallbackUseCase{
+		providers:             providers,
+		userRepo:              userRepo,
+		subscriptionRepo:      subscriptionRepo,
+		emailVerificationRepo: emailVerificationRepo,
+		sessionRepo:           sessionRepo,
+		identityRepo:          identityRepo,
+		emailService:          emailService,
+		cacheService:          cacheService,
+		newDevices:            newDevices,
+		logger:                logger,
+	}
+}
+
+// accessToken es la cookie de sesión del navegador que vuelve del proveedor, solo se usa al vincular
+func (uc *OAuthCallbackUseCase) Execute(ctx context.Context, providerName, code, state, accessToken, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
+	provider, err := uc.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	savedState, err := uc.consumeState(ctx, state)
+	if err != nil || savedState.Provider != providerName {
+		uc.logger.Error().
+			Str("provider", providerName).
+			Str("state", state).
+			Msg("Invalid or expired CSRF state")
+		return nil, ErrInvalidOAuthState
+	}
+
+	// El callback es público: sin esto, quien consiga que otro abra su URL de vinculación le agrega
+	// su propia cuenta del proveedor al perfil de la víctima, o al revés
+	if savedState.LinkUserID != 0 && !uc.sessionBelongsTo(ctx, accessToken, savedState.LinkUserID) {
+		uc.logger.Warn().
+			Int("user_id", savedState.LinkUserID).
+			Str("provider", providerName).
+			Msg("Link callback without the session that started it")
+		return nil, ErrLinkSessionRequired
+	}
+
+	external, err := provider.Exchange(ctx, code, idp.AuthRequest{
+		State:        state,
+		Nonce:        savedState.Nonce,
+		CodeVerifier: savedState.CodeVerifier,
+	})
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Str("provider", providerName).
+			Msg("Failed to authenticate with identity provider")
+		return nil, fmt.Errorf("failed to authenticate with %s: %w", providerName, err)
+	}
+
+	if savedState.LinkUserID != 0 {
+		return uc.linkIdentity(ctx, savedState.LinkUserID, external)
+	}
+
+	if external.Email == "" {
+		uc.logger.Error().
+			Str("provider", providerName).
+			Str("subject", external.Subject).
+			Msg("Identity provider did not share an email")
+		return nil, fmt.Errorf("%s did not share an email address", providerName)
+	}
+
+	user, err := uc.resolveUser(ctx, external)
+	if err != nil {
+		return nil, err
+	}
+	userID := user.ID
+
+	if user.Deleted && !accountRestorable(user) {
+		uc.logger.Error().
+			Int("user_id", userID).
+			Str("provider", providerName).
+			Msg("User deleted tried to login")
+		return nil, ErrAccountDeleted
+	}
+	if !user.IsActive {
+		uc.logger.Error().
+			Int("user_id", userID).
+			Str("provider", providerName).
+			Msg("Deactivated user tried to login")
+		return nil, fmt.Errorf("account deactivated")
+	}
+
+	if !user.Verified {
+		verificationCode, expiresAt, err := issueVerificationCode(ctx, uc.userRepo, uc.emailVerificationRepo, userID)
+		if err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Msg("Failed to create email verification")
+			return nil, fmt.Errorf("failed to create email verification: %w", err)
+		}
+
+		verificationToken, err := security.CreateVerificationToken(userID, user.Email, expiresAt)
+		if err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Msg("Failed to create verification token")
+			return nil, fmt.Errorf("failed to create verification token: %w", err)
+		}
+
+		emailJob := email.EmailJob{
+			To:      user.Email,
+			Subject: "Verificá tu cuenta",
+			Body:    fmt.Sprintf("Tu código de verificación es: %s", verificationCode),
+		}
+
+		if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Str("email", user.Email).
+				Msg("Failed to send verification email")
+			return nil, fmt.Errorf("failed to send verification email: %w", err)
+		}
+
+		uc.logger.Info().
+			Int("user_id", userID).
+			Str("email", user.Email).
+			Msg("Verification email sent successfully")
+
+		return &dtos.LoginResponse{
+			VerificationRequired:  true,
+			VerificationExpiresAt: expiresAt,
+			VerificationToken:     verificationToken,
+			Redirect:              "/verify",
+		}, nil
+	}
+
+	// Con cuentas vinculadas un proveedor externo no puede ser un atajo para saltear el segundo factor
+	if user.MFAEnabled {
+		mfaExpiresAt := time.Now().Add(5 * time.Minute)
+		mfaToken, err := security.CreateMFAToken(userID, mfaExpiresAt)
+		if err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Msg("Failed to create mfa token")
+			return nil, err
+		}
+
+		return &dtos.LoginResponse{
+			MFARequired:  true,
+			MFAToken:     mfaToken,
+			MFAExpiresAt: mfaExpiresAt,
+		}, nil
+	}
+
+	if err := restoreDeletedAccount(ctx, uc.userRepo, uc.emailService, uc.logger, user); err != nil {
+		return nil, err
+	}
+
+	// Actualizar LastLogin
+	currentTime := time.Now()
+	err = uc.userRepo.UpdateLastLogin(ctx, userID, currentTime)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to update last login")
+		return nil, fmt.Errorf("failed to update last login: %w", err)
+	}
+	user.LastLogin = currentTime.Format(time.RFC3339)
+
+	// Crear sesión y tokens
+	accessToken, refreshToken, session, err := createSession(ctx, uc.sessionRepo, user, deviceInfo, clientIP, "")
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Str("device_info", deviceInfo).
+			Msg("Failed to create session")
+		return nil, err
+	}
+	uc.newDevices.Notify(ctx, user, session)
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Str("email", user.Email).
+		Str("provider", providerName).
+		Str("device_info", deviceInfo).
+		Msg("User logged in successfully via identity provider")
+
+	return &dtos.LoginResponse{
+		Profile:      newProfileResponse(user),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// consumeState recupera lo guardado por startAuthorization. Se lee y borra en una sola operación,
+// así dos callbacks simultáneos con el mismo estado no pueden canjearlo los dos.
+func (uc *OAuthCallbackUseCase) consumeState(ctx context.Context, state string) (*oauthState, error) {
+	cached, err := uc.cacheService.GetDel(ctx, oauthStateKey(state))
+	if err != nil {
+		return nil, err
+	}
+
+	var saved oauthState
+	if err := json.Unmarshal([]byte(cached), &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// resolveUser busca la cuenta por la identidad externa, y si no existe la vincula o crea según el email
+func (uc *OAuthCallbackUseCase) resolveUser(ctx context.Context, external *idp.ExternalIdentity) (*entities.User, error) {
+	identity, err := uc.identityRepo.FindByProviderSubject(ctx, external.Provider, external.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+	if identity != nil {
+		user, err := uc.userRepo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		if user == nil {
+			return nil, fmt.Errorf("user not found")
+		}
+		return user, nil
+	}
+
+	user, err := uc.userRepo.FindByEmail(external.Email)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Str("email", external.Email).
+			Msg("Failed to find user by email")
+		return nil, err
+	}
+	if user == nil {
+		return uc.createUser(ctx, external)
+	}
+
+	existing, err := uc.identityRepo.FindByUserAndProvider(ctx, user.ID, external.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+	if existing != nil {
+		// Las cuentas migradas desde login_method no tienen subject, se completa en este primer login
+		if existing.Subject != "" {
+			return nil, ErrProviderAlreadyLinked
+		}
+		if !uc.verifiedEmails(user, external) {
+			return nil, ErrIdentityNotLinked
+		}
+		if err := uc.identityRepo.UpdateSubject(ctx, existing.ID, external.Subject, external.Email); err != nil {
+			return nil, fmt.Errorf("failed to update identity: %w", err)
+		}
+		return user, nil
+	}
+
+	if !uc.verifiedEmails(user, external) {
+		return nil, ErrIdentityNotLinked
+	}
+
+	if err := uc.addIdentity(ctx, user, external); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info().
+		Int("user_id", user.ID).
+		Str("provider", external.Provider).
+		Msg("Identity auto-linked to existing account")
+
+	return user, nil
+}
+
+func (uc *OAuthCallbackUseCase) createUser(ctx context.Context, external *idp.ExternalIdentity) (*entities.User, error) {
+	firstName, lastName := external.GivenName, external.FamilyName
+	if firstName == "" {
+		firstName = external.Name
+	}
+
+	user := &entities.User{
+		Email:     external.Email,
+		FirstName: firstName,
+		LastName:  lastName,
+		Role:      entities.RoleUser,
+		IsActive:  true,
+		Deleted:   false,
+		Verified:  external.EmailVerified,
+		CreatedAt: time.Now(),
+	}
+	userID, err := uc.userRepo.Save(user)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Str("email", external.Email).
+			Msg("Failed to save user")
+		return nil, fmt.Errorf("failed to save user: %w", err)
+	}
+	user.ID = userID
+
+	identity := &entities.UserIdentity{
+		UserID:   userID,
+		Provider: external.Provider,
+		Subject:  external.Subject,
+		Email:    external.Email,
+	}
+	if err := uc.identityRepo.Create(ctx, identity); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to create identity")
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	user.Providers = []string{external.Provider}
+
+	plan, err := uc.subscriptionRepo.GetFreeTierPlan()
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to get free tier plan ID")
+		return nil, fmt.Errorf("failed to get Free Tier plan ID: %w", err)
+	}
+
+	now := time.Now()
+	subscription := &entities.Subscription{
+		UserID:    userID,
+		PlanID:    plan.ID,
+		PlanName:  plan.Name,
+		Status:    "active",
+		StartedAt: now,
+		ExpiresAt: now.Add(14 * 24 * time.Hour),
+	}
+
+	_, err = uc.subscriptionRepo.Save(subscription)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to create subscription")
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	user.Subscription = subscription
+
+	return user, nil
+}
+
+// linkIdentity vincula la identidad externa a un usuario ya autenticado que lo pidió desde su perfil
+func (uc *OAuthCallbackUseCase) linkIdentity(ctx context.Context, userID int, external *idp.ExternalIdentity) (*dtos.LoginResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	linked := &dtos.LoginResponse{IdentityLinked: true, Redirect: "/profile"}
+
+	identity, err := uc.identityRepo.FindByProviderSubject(ctx, external.Provider, external.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+	if identity != nil {
+		if identity.UserID != userID {
+			uc.logger.Warn().
+				Int("user_id", userID).
+				Int("owner_id", identity.UserID).
+				Str("provider", external.Provider).
+				Msg("Identity already linked to another account")
+			return nil, ErrIdentityAlreadyLinked
+		}
+		return linked, nil
+	}
+
+	existing, err := uc.identityRepo.FindByUserAndProvider(ctx, userID, external.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+	switch {
+	case existing != nil && existing.Subject != "":
+		return nil, ErrProviderAlreadyLinked
+	case existing != nil:
+		if !uc.verifiedEmails(user, external) {
+			return nil, ErrEmailNotVerified
+		}
+		if err := uc.identityRepo.UpdateSubject(ctx, existing.ID, external.Subject, external.Email); err != nil {
+			return nil, fmt.Errorf("failed to update identity: %w", err)
+		}
+	default:
+		if err := uc.addIdentity(ctx, user, external); err != nil {
+			return nil, err
+		}
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Str("provider", external.Provider).
+		Msg("Identity linked from profile")
+
+	return linked, nil
+}
+
+// sessionBelongsTo indica si la cookie de acceso es de una sesión vigente, propia (no impersonada) de userID
+func (uc *OAuthCallbackUseCase) sessionBelongsTo(ctx context.Context, accessToken string, userID int) bool {
+	if accessToken == "" {
+		return false
+	}
+	claims, err := security.ParseAccessToken(accessToken)
+	if err != nil || claims.UserID != userID || claims.ImpersonatorID != 0 {
+		return false
+	}
+
+	accessTokenHash, err := security.HashToken(accessToken)
+	if err != nil {
+		return false
+	}
+	session, err := uc.sessionRepo.FindByAccessTokenHash(ctx, accessTokenHash)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to find session for link callback")
+		return false
+	}
+	return session != nil && session.IsValid && session.UserID == userID && session.ImpersonatorID == 0
+}
+
+// verifiedEmails es la condición para asociar una identidad por el email, tanto al vincularla como al
+// completar el subject de una migrada: si algún lado no lo confirmó, alguien podría tomar la cuenta
+func (uc *OAuthCallbackUseCase) verifiedEmails(user *entities.User, external *idp.ExternalIdentity) bool {
+	if external.EmailVerified && user.Verified {
+		return true
+	}
+	uc.logger.Warn().
+		Int("user_id", user.ID).
+		Str("provider", external.Provider).
+		Bool("provider_verified", external.EmailVerified).
+		Bool("account_verified", user.Verified).
+		Msg("Refused to auto-link identity")
+	return false
+}
+
+func (uc *OAuthCallbackUseCase) addIdentity(ctx context.Context, user *entities.User, external *idp.ExternalIdentity) error {
+	identity := &entities.UserIdentity{
+		UserID:   user.ID,
+		Provider: external.Provider,
+		Subject:  external.Subject,
+		Email:    external.Email,
+	}
+	if err := uc.identityRepo.Create(ctx, identity); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	user.Providers = append(user.Providers, external.Provider)
+
+	if err := uc.cacheService.Delete(ctx, fmt.Sprintf("profile:user:%d", user.ID)); err != nil {
+		uc.logger.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to clear profile cache")
+	}
+
+	account := external.Email
+	if account == "" {
+		account = external.Name
+	}
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Vinculaste tu cuenta de %s", displayProvider(external.Provider)),
+		Body:    fmt.Sprintf("La cuenta %s de %s ahora puede usarse para iniciar sesión. Si no fuiste vos, desvinculala desde tu perfil y cambiá tu contraseña.", account, displayProvider(external.Provider)),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send identity linked email")
+	}
+
+	return nil
+}
+
+func displayProvider(provider string) string {
+	if provider == "" {
+		return provider
+	}
+	return strings.ToUpper(provider[:1]) + provider[1:]
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"time"
+
+	idp "luthierSaas/internal/infrastructure/auth"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+// oauthState es lo que se guarda entre el redirect al proveedor y el callback
+type oauthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	// LinkUserID distinto de cero indica que el usuario vincula el proveedor desde su perfil
+	LinkUserID int `json:"link_user_id,omitempty"`
+}
+
+type OAuthLoginUseCase struct {
+	providers    *idp.Registry
+	cacheService *cache.Cache
+}
+
+func NewOAuthLoginUseCase(providers *idp.Registry, cacheService *cache.Cache) *OAuthLoginUseCase {
+	return &OAuthLoginUseCase{
+		providers:    providers,
+		cacheService: cacheService,
+	}
+}
+
+func (uc *OAuthLoginUseCase) Execute(ctx context.Context, providerName string) (string, error) {
+	return startAuthorization(ctx, uc.providers, uc.cacheService, providerName, 0)
+}
+
+// LinkProviderUseCase arma la URL del proveedor para vincularlo desde el perfil de un usuario autenticado
+type LinkProviderUseCase struct {
+	providers    *idp.Registry
+	cacheService *cache.Cache
+}
+
+func NewLinkProviderUseCase(providers *idp.Registry, cacheService *cache.Cache) *LinkProviderUseCase {
+	return &LinkProviderUseCase{
+		providers:    providers,
+		cacheService: cacheService,
+	}
+}
+
+func (uc *LinkProviderUseCase) Execute(ctx context.Context, providerName string, userID int) (string, error) {
+	return startAuthorization(ctx, uc.providers, uc.cacheService, providerName, userID)
+}
+
+// startAuthorization guarda state, nonce y code verifier en cache y devuelve la URL del proveedor
+func startAuthorization(ctx context.Context, providers *idp.Registry, cacheService *cache.Cache, providerName string, linkUserID int) (string, error) {
+	provider, err := providers.Get(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := idp.NewAuthRequest()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	state, err := json.Marshal(oauthState{
+		Provider:     providerName,
+		Nonce:        req.Nonce,
+		CodeVerifier: req.CodeVerifier,
+		LinkUserID:   linkUserID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if err := cacheService.Set(ctx, oauthStateKey(req.State), string(state), oauthStateTTL); err != nil {
+		return "", fmt.Errorf("failed to store state in cache: %w", err)
+	}
+
+	url, err := provider.AuthCodeURL(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to build authorization url: %w", err)
+	}
+	return url, nil
+}
+
+func oauthStateKey(state string) string {
+	return "oauth_state:" + state
+}
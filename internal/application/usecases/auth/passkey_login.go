@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	idp "luthierSaas/internal/infrastructure/auth"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/rs/zerolog"
+)
+
+const passkeyLoginTTL = 5 * time.Minute
+
+var ErrInvalidPasskey = errors.New("invalid or expired passkey assertion")
+
+// El login con passkey no conoce al usuario de antemano, el challenge se guarda por ceremonia
+func passkeyLoginKey(ceremonyID string) string {
+	return "webauthn:login:" + ceremonyID
+}
+
+type BeginPasskeyLoginUseCase struct {
+	webAuthn     *webauthn.WebAuthn
+	cacheService *cache.Cache
+}
+
+func NewBeginPasskeyLoginUseCase(webAuthn *webauthn.WebAuthn, cacheService *cache.Cache) *BeginPasskeyLoginUseCase {
+	return &BeginPasskeyLoginUseCase{
+		webAuthn:     webAuthn,
+		cacheService: cacheService,
+	}
+}
+
+// Execute arma un login discoverable: el autenticador ofrece las passkeys del sitio y devuelve el user handle
+func (uc *BeginPasskeyLoginUseCase) Execute(ctx context.Context) (*dtos.PasskeyLoginOptionsResponse, error) {
+	options, session, err := uc.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin passkey login: %w", err)
+	}
+
+	ceremonyID, err := security.GenerateSecureToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ceremony id: %w", err)
+	}
+
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode passkey session: %w", err)
+	}
+	if err := uc.cacheService.Set(ctx, passkeyLoginKey(ceremonyID), string(sessionData), passkeyLoginTTL); err != nil {
+		return nil, fmt.Errorf("failed to store passkey session: %w", err)
+	}
+
+	return &dtos.PasskeyLoginOptionsResponse{
+		CeremonyID: ceremonyID,
+		Options:    options,
+	}, nil
+}
+
+type PasskeyLoginUseCase struct {
+	userRepo     repository.UserRepository
+	passkeyRepo  repository.WebAuthnCredentialRepository
+	webAuthn     *webauthn.WebAuthn
+	cacheService *cache.Cache
+	login        *LoginUseCase
+	logger       *zerolog.Logger
+}
+
+func NewPasskeyLoginUseCase(
+	userRepo repository.UserRepository,
+	passkeyRepo repository.WebAuthnCredentialRepository,
+	webAuthn *webauthn.WebAuthn,
+	cacheService *cache.Cache,
+	login *LoginUseCase,
+	logger *zerolog.Logger,
+) *PasskeyLoginUseCase {
+	return &PasskeyLoginUseCase{
+		userRepo:     userRepo,
+		passkeyRepo:  passkeyRepo,
+		webAuthn:     webAuthn,
+		cacheService: cacheService,
+		login:        login,
+		logger:       logger,
+	}
+}
+
+func (uc *PasskeyLoginUseCase) Execute(ctx context.Context, input dtos.PasskeyLoginInput, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
+	// GetDel consume el challenge, una aserción no se puede reutilizar
+	sessionData, err := uc.cacheService.GetDel(ctx, passkeyLoginKey(input.CeremonyID))
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
+		return nil, ErrInvalidPasskey
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(input.Credential)
+	if err != nil {
+		uc.logger.Warn().
+			Err(err).
+			Msg("Failed to parse passkey assertion")
+		return nil, ErrInvalidPasskey
+	}
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := idp.UserIDFromHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err := uc.userRepo.FindByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrInvalidPasskey
+		}
+		credentials, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return idp.NewPasskeyUser(user, credentials), nil
+	}
+
+	validatedUser, credential, err := uc.webAuthn.ValidatePasskeyLogin(handler, session, parsed)
+	if err != nil {
+		uc.logger.Warn().
+			Err(err).
+			Msg("Passkey assertion verification failed")
+		return nil, ErrInvalidPasskey
+	}
+
+	passkeyUser := validatedUser.(*idp.PasskeyUser)
+	user := passkeyUser.User()
+	passkey := passkeyUser.FindCredential(credential.ID)
+	if passkey == nil {
+		return nil, ErrInvalidPasskey
+	}
+
+	// Un contador que no avanza indica que la clave pudo haber sido clonada
+	if credential.Authenticator.CloneWarning {
+		uc.logger.Error().
+			Int("user_id", user.ID).
+			Int("passkey_id", passkey.ID).
+			Msg("Passkey sign count did not increase, possible cloned authenticator")
+		return nil, ErrInvalidPasskey
+	}
+
+	// Una cuenta que no puede entrar no registra el uso de la passkey
+	if user.Deleted && !accountRestorable(user) {
+		uc.logger.Error().
+			Int("user_id", user.ID).
+			Msg("User deleted tried to login with passkey")
+		return nil, ErrAccountDeleted
+	}
+	if !user.IsActive {
+		uc.logger.Error().
+			Int("user_id", user.ID).
+			Msg("Deactivated user tried to login with passkey")
+		return nil, errors.New("account deactivated")
+	}
+
+	if err := uc.passkeyRepo.UpdateUsage(ctx, passkey.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, time.Now()); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Int("passkey_id", passkey.ID).
+			Msg("Failed to update passkey usage")
+		return nil, err
+	}
+
+	if !user.Verified {
+		return uc.login.completeLogin(ctx, user, deviceInfo, clientIP)
+	}
+	return uc.login.startSession(ctx, user, deviceInfo, clientIP)
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache/cachetest"
+	"luthierSaas/internal/infrastructure/config"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/queue"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+
+	idp "luthierSaas/internal/infrastructure/auth"
+	"luthierSaas/internal/infrastructure/auth/passkeytest"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/rs/zerolog"
+)
+
+const testOrigin = "http://localhost:5173"
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	user *entities.User
+}
+
+func (r *fakeUserRepo) FindByID(id int) (*entities.User, error) {
+	if r.user.ID != id {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+func (r *fakeUserRepo) UpdateLastLogin(ctx context.Context, userID int, lastLogin time.Time) error {
+	return nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionRepository
+
+	mu       sync.Mutex
+	sessions []*entities.Session
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *entities.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = len(r.sessions) + 1
+	r.sessions = append(r.sessions, session)
+	return nil
+}
+
+func (r *fakeSessionRepo) FindHistoryByUserID(ctx context.Context, userID int, since time.Time) ([]*entities.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*entities.Session{}, r.sessions...), nil
+}
+
+// fakePasskeyRepo guarda las passkeys en memoria y cuenta los usos registrados
+type fakePasskeyRepo struct {
+	repository.WebAuthnCredentialRepository
+
+	mu       sync.Mutex
+	passkeys []*entities.WebAuthnCredential
+	usages   int
+}
+
+func (r *fakePasskeyRepo) FindByUserID(ctx context.Context, userID int) ([]*entities.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var passkeys []*entities.WebAuthnCredential
+	for _, passkey := range r.passkeys {
+		if passkey.UserID == userID {
+			copied := *passkey
+			passkeys = append(passkeys, &copied)
+		}
+	}
+	return passkeys, nil
+}
+
+func (r *fakePasskeyRepo) UpdateUsage(ctx context.Context, id int, signCount uint32, backupState bool, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, passkey := range r.passkeys {
+		if passkey.ID == id {
+			passkey.SignCount = signCount
+			passkey.BackupState = backupState
+			passkey.LastUsedAt = usedAt
+		}
+	}
+	r.usages++
+	return nil
+}
+
+type passkeyLoginTest struct {
+	begin         *BeginPasskeyLoginUseCase
+	finish        *PasskeyLoginUseCase
+	user          *entities.User
+	passkeys      *fakePasskeyRepo
+	sessions      *fakeSessionRepo
+	authenticator *passkeytest.Authenticator
+}
+
+// newPasskeyLoginTest registra una passkey del autenticador simulado para el usuario 7
+func newPasskeyLoginTest(t *testing.T) *passkeyLoginTest {
+	t.Helper()
+	t.Setenv("RESEND_API_KEY", "test")
+	logger := zerolog.Nop()
+	if err := security.InitKeyRing("", "", "luthier-saas", true, &logger); err != nil {
+		t.Fatal(err)
+	}
+
+	webAuthn, err := idp.NewWebAuthn(config.WebAuthnConfig{RPID: "localhost", RPDisplayName: "Luthier SaaS", RPOrigins: []string{testOrigin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheService, _ := cachetest.NewCache(t)
+	emailService := email.NewEmailService(queue.NewQueue(cacheService.Client(), "emails"))
+
+	lt := &passkeyLoginTest{
+		user:     &entities.User{ID: 7, Email: "luthier@example.com", Role: entities.RoleUser, Verified: true, IsActive: true},
+		passkeys: &fakePasskeyRepo{},
+		sessions: &fakeSessionRepo{},
+	}
+	users := &fakeUserRepo{user: lt.user}
+
+	lt.authenticator, err = passkeytest.NewAuthenticator(testOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passkeyUser := idp.NewPasskeyUser(lt.user, nil)
+	options, session, err := webAuthn.BeginRegistration(passkeyUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registration, err := lt.authenticator.Register(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(registration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := webAuthn.CreateCredential(passkeyUser, *session, parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passkey := idp.NewWebAuthnCredential(lt.user.ID, "Llave", credential)
+	passkey.ID = 1
+	lt.passkeys.passkeys = append(lt.passkeys.passkeys, passkey)
+
+	newDevices := newNewDeviceNotifier(lt.sessions, cacheService, emailService, &logger, testOrigin)
+	login := NewLoginUseCase(users, nil, lt.sessions, emailService, nil, newDevices, &logger)
+	lt.begin = NewBeginPasskeyLoginUseCase(webAuthn, cacheService)
+	lt.finish = NewPasskeyLoginUseCase(users, lt.passkeys, webAuthn, cacheService, login, &logger)
+	return lt
+}
+
+// assert arma una aserción del autenticador para una ceremonia nueva
+func (lt *passkeyLoginTest) assert(t *testing.T) dtos.PasskeyLoginInput {
+	t.Helper()
+	options, err := lt.begin.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	credential, err := lt.authenticator.Login(options.Options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dtos.PasskeyLoginInput{CeremonyID: options.CeremonyID, Credential: json.RawMessage(credential)}
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	lt := newPasskeyLoginTest(t)
+	input := lt.assert(t)
+
+	result, err := lt.finish.Execute(context.Background(), input, "Chrome 120, Linux, Desktop", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if result.AccessToken == "" || result.RefreshToken == "" || result.Profile == nil || result.Profile.ID != lt.user.ID {
+		t.Fatalf("got %+v, want a session for user %d", result, lt.user.ID)
+	}
+	if len(lt.sessions.sessions) != 1 {
+		t.Errorf("created %d sessions, want 1", len(lt.sessions.sessions))
+	}
+	if lt.passkeys.usages != 1 || lt.passkeys.passkeys[0].SignCount != lt.authenticator.SignCount {
+		t.Errorf("passkey usage %d with sign count %d, want 1 with %d", lt.passkeys.usages, lt.passkeys.passkeys[0].SignCount, lt.authenticator.SignCount)
+	}
+
+	// La ceremonia se consume en el finish, la misma aserción no abre otra sesión
+	if _, err := lt.finish.Execute(context.Background(), input, "Chrome 120, Linux, Desktop", "127.0.0.1"); !errors.Is(err, ErrInvalidPasskey) {
+		t.Fatalf("replayed assertion: got %v, want %v", err, ErrInvalidPasskey)
+	}
+}
+
+func TestPasskeyLoginRejectsClonedAuthenticator(t *testing.T) {
+	lt := newPasskeyLoginTest(t)
+	lt.passkeys.passkeys[0].SignCount = 10
+
+	// El contador del autenticador quedó por detrás del guardado
+	if _, err := lt.finish.Execute(context.Background(), lt.assert(t), "", "127.0.0.1"); !errors.Is(err, ErrInvalidPasskey) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidPasskey)
+	}
+	if lt.passkeys.usages != 0 || len(lt.sessions.sessions) != 0 {
+		t.Errorf("cloned authenticator recorded %d usages and %d sessions", lt.passkeys.usages, len(lt.sessions.sessions))
+	}
+}
+
+func TestPasskeyLoginRejectsUnknownCeremony(t *testing.T) {
+	lt := newPasskeyLoginTest(t)
+	input := lt.assert(t)
+	input.CeremonyID = "unknown"
+
+	if _, err := lt.finish.Execute(context.Background(), input, "", "127.0.0.1"); !errors.Is(err, ErrInvalidPasskey) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidPasskey)
+	}
+}
+
+func TestPasskeyLoginRejectsBlockedAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		block   func(user *entities.User)
+		wantErr error
+	}{
+		{name: "deactivated", block: func(user *entities.User) { user.IsActive = false }},
+		{name: "deleted", block: func(user *entities.User) {
+			user.Deleted = true
+			user.PurgeAfter = time.Now().Add(-time.Hour)
+		}, wantErr: ErrAccountDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lt := newPasskeyLoginTest(t)
+			tt.block(lt.user)
+
+			_, err := lt.finish.Execute(context.Background(), lt.assert(t), "", "127.0.0.1")
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// Una cuenta bloqueada no registra el uso de la passkey ni abre sesión
+			if lt.passkeys.usages != 0 || len(lt.sessions.sessions) != 0 {
+				t.Errorf("blocked account recorded %d usages and %d sessions", lt.passkeys.usages, len(lt.sessions.sessions))
+			}
+		})
+	}
+}
@@ -5,28 +5,43 @@ import (
 	"errors"
 	"fmt"
 	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/email"
 	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/http/dtos"
 	"luthierSaas/internal/interfaces/repository"
 	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+    ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+    // ErrRefreshTokenRotated es el token recién rotado presentado por otra pestaña, no se trata como robo
+    ErrRefreshTokenRotated = errors.New("refresh token already rotated")
 )
 
+// refreshReuseGrace es cuánto después de rotar se tolera el token anterior, para que dos pestañas que
+// renuevan a la vez no disparen la alerta de robo
+const refreshReuseGrace = 10 * time.Second
+
 type RefreshTokenUseCase struct {
 	userRepo repository.UserRepository
 	sessionRepo repository.SessionRepository
+	emailService *email.EmailService
+	logger *zerolog.Logger
 }
 
-func NewRefreshTokenUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository) *RefreshTokenUseCase {
-	return &RefreshTokenUseCase{userRepo, sessionRepo}
+func NewRefreshTokenUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, emailService *email.EmailService, logger *zerolog.Logger) *RefreshTokenUseCase {
+	return &RefreshTokenUseCase{userRepo, sessionRepo, emailService, logger}
 }
 
-func (uc *RefreshTokenUseCase) Execute(ctx context.Context, refreshToken string, deviceInfo string) (*dtos.RefreshResponse, error) {
+func (uc *RefreshTokenUseCase) Execute(ctx context.Context, refreshToken string, deviceInfo, clientIP string) (*dtos.RefreshResponse, error) {
     refreshTokenHash, err := security.HashToken(refreshToken)
     if err != nil {
         return nil, fmt.Errorf("failed to hash refresh token: %w", err)
     }
 
-    session, err := uc.sessionRepo.FindByRefreshTokenHash(ctx, refreshTokenHash)
+    session, err := uc.sessionRepo.FindAnyByRefreshTokenHash(ctx, refreshTokenHash)
     if err != nil {
         return nil, fmt.Errorf("failed to find session: %w", err)
     }
@@ -34,6 +49,21 @@ func (uc *RefreshTokenUseCase) Execute(ctx context.Context, refreshToken string,
         return nil, errors.New("invalid or expired refresh token")
     }
 
+    // Un refresh token ya rotado solo puede presentarse de nuevo si alguien lo robó,
+    // se revoca toda la familia para que ni el atacante ni el usuario sigan usándola.
+    // La excepción es el token inmediatamente anterior dentro del margen de gracia
+    if session.Consumed {
+        if uc.justRotated(ctx, session) {
+            return nil, ErrRefreshTokenRotated
+        }
+        uc.handleReuse(ctx, session.UserID, session.FamilyID, deviceInfo)
+        return nil, ErrRefreshTokenReused
+    }
+
+    if !session.IsValid || !session.RefreshExpiresAt.After(time.Now()) {
+        return nil, errors.New("invalid or expired refresh token")
+    }
+
     user, err := uc.userRepo.FindByID(session.UserID)
     if err != nil {
         return nil, fmt.Errorf("failed to find user: %w", err)
@@ -44,64 +74,106 @@ func (uc *RefreshTokenUseCase) Execute(ctx context.Context, refreshToken string,
     if user.Deleted {
         return nil, errors.New("user deleted")
     }
+    if !user.IsActive {
+        return nil, errors.New("account deactivated")
+    }
     if !user.Verified {
         return nil, errors.New("user not verified")
     }
 
-    accessToken, err := security.CreateAccessToken(user.ID)
+    // Se consume antes de emitir la nueva sesión, así dos pedidos concurrentes con el mismo token no rotan ambos
+    consumed, err := uc.sessionRepo.MarkConsumed(ctx, session.AccessTokenHash)
     if err != nil {
-        return nil, fmt.Errorf("failed to create access token: %w", err)
+        return nil, fmt.Errorf("failed to consume old session: %w", err)
     }
-    newRefreshToken, err := security.CreateRefreshToken(user.ID)
-    if err != nil {
-        return nil, fmt.Errorf("failed to create refresh token: %w", err)
+    if !consumed {
+        // Otro pedido lo rotó entre la lectura y el consumo, es el caso típico de dos pestañas
+        uc.logger.Info().
+            Int("user_id", session.UserID).
+            Str("family_id", session.FamilyID).
+            Msg("Refresh token rotated by a concurrent request")
+        return nil, ErrRefreshTokenRotated
     }
 
-    accessTokenHash, err := security.HashToken(accessToken)
+    accessToken, newRefreshToken, _, err := createSession(ctx, uc.sessionRepo, user, deviceInfo, clientIP, session.FamilyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to hash access token: %w", err)
+        return nil, fmt.Errorf("failed to create new session: %w", err)
     }
-    newRefreshTokenHash, err := security.HashToken(newRefreshToken)
+
+    return &dtos.RefreshResponse{
+        Profile:      newProfileResponse(user),
+        AccessToken:  accessToken,
+        RefreshToken: newRefreshToken,
+    }, nil
+}
+
+// justRotated indica si la sesión se rotó hace menos de refreshReuseGrace y su sucesora es la sesión
+// vigente de la familia, o sea que el token presentado es el inmediatamente anterior
+func (uc *RefreshTokenUseCase) justRotated(ctx context.Context, session *entities.Session) bool {
+    // MarkConsumed actualiza updated_at, así que marca el momento de la rotación
+    if session.FamilyID == "" || time.Since(session.UpdatedAt) > refreshReuseGrace {
+        return false
+    }
+
+    family, err := uc.sessionRepo.FindByFamilyID(ctx, session.FamilyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to hash refresh token: %w", err)
+        uc.logger.Error().
+            Err(err).
+            Str("family_id", session.FamilyID).
+            Msg("Failed to load session family")
+        return false
     }
 
-    newSession := &entities.Session{
-        UserID:           user.ID,
-        AccessTokenHash:  accessTokenHash,
-        RefreshTokenHash: newRefreshTokenHash,
-        ExpiresAt:        time.Now().Add(15 * time.Minute),
-        RefreshExpiresAt: time.Now().Add(7 * 24 * time.Hour),
-        IsValid:          true,
-        DeviceInfo:       deviceInfo, 
+    // La única sesión posterior de la familia tiene que ser la que salió de esta rotación y seguir vigente
+    var newer []*entities.Session
+    for _, s := range family {
+        if s.ID > session.ID {
+            newer = append(newer, s)
+        }
+    }
+    if len(newer) != 1 || newer[0].Consumed || !newer[0].IsValid {
+        return false
     }
 
-    err = uc.sessionRepo.Create(ctx, newSession)
-    if err != nil {
-        return nil, fmt.Errorf("failed to create new session: %w", err)
+    uc.logger.Info().
+        Int("user_id", session.UserID).
+        Str("family_id", session.FamilyID).
+        Msg("Previous refresh token presented within the grace window")
+    return true
+}
+
+func (uc *RefreshTokenUseCase) handleReuse(ctx context.Context, userID int, familyID string, deviceInfo string) {
+    uc.logger.Warn().
+        Int("user_id", userID).
+        Str("family_id", familyID).
+        Str("device_info", deviceInfo).
+        Msg("Refresh token reuse detected, revoking session family")
+
+    if familyID != "" {
+        if err := uc.sessionRepo.InvalidateFamily(ctx, familyID); err != nil {
+            uc.logger.Error().
+                Err(err).
+                Int("user_id", userID).
+                Str("family_id", familyID).
+                Msg("Failed to revoke session family")
+        }
     }
 
-    err = uc.sessionRepo.Delete(ctx, session.AccessTokenHash)
-    if err != nil {
-        return nil, fmt.Errorf("failed to delete old session: %w", err)
+    user, err := uc.userRepo.FindByID(userID)
+    if err != nil || user == nil {
+        return
     }
 
-    profile := &dtos.ProfileResponse{
-        ID:           user.ID,
-        Email:        user.Email,
-        FirstName:    user.FirstName,
-        LastName:     user.LastName,
-        Phone:        user.Phone,
-        Country:      user.Country,
-        WorkshopName: user.WorkshopName,
-        LastLogin:    user.LastLogin,
-        HasPassword:  user.Password != "",
-        Subscription: user.Subscription,
+    emailJob := email.EmailJob{
+        To:      user.Email,
+        Subject: "Alerta de seguridad en tu cuenta",
+        Body:    fmt.Sprintf("Detectamos que una sesión de tu cuenta fue usada desde otro dispositivo (%s) después de haberse renovado. Por seguridad la cerramos. Si no fuiste vos, cambiá tu contraseña.", deviceInfo),
     }
 
-    return &dtos.RefreshResponse{
-        Profile:      profile,
-        AccessToken:  accessToken,
-        RefreshToken: newRefreshToken,
-    }, nil
-}
\ No newline at end of file
+    if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+        uc.logger.Error().
+            Err(err).
+            Int("user_id", userID).
+            Msg("Failed to send security alert email")
+    }
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache/cachetest"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/queue"
+	"luthierSaas/internal/infrastructure/security"
+
+	"github.com/rs/zerolog"
+)
+
+func (r *fakeSessionRepo) FindAnyByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entities.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.RefreshTokenHash == refreshTokenHash {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSessionRepo) FindByFamilyID(ctx context.Context, familyID string) ([]*entities.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var family []*entities.Session
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			copied := *session
+			family = append(family, &copied)
+		}
+	}
+	return family, nil
+}
+
+func (r *fakeSessionRepo) MarkConsumed(ctx context.Context, accessTokenHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.AccessTokenHash == accessTokenHash && !session.Consumed {
+			session.Consumed, session.IsValid, session.UpdatedAt = true, false, time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeSessionRepo) InvalidateFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			session.IsValid = false
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) valid() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	valid := 0
+	for _, session := range r.sessions {
+		if session.IsValid {
+			valid++
+		}
+	}
+	return valid
+}
+
+type refreshTest struct {
+	refresh  *RefreshTokenUseCase
+	sessions *fakeSessionRepo
+}
+
+func newRefreshTest(t *testing.T) *refreshTest {
+	t.Helper()
+	t.Setenv("RESEND_API_KEY", "test")
+	logger := zerolog.Nop()
+	if err := security.InitKeyRing("", "", "luthier-saas", true, &logger); err != nil {
+		t.Fatal(err)
+	}
+	cacheService, _ := cachetest.NewCache(t)
+	emailService := email.NewEmailService(queue.NewQueue(cacheService.Client(), "emails"))
+
+	users := &fakeUserRepo{user: &entities.User{ID: 7, Email: "luthier@example.com", Role: entities.RoleUser, Verified: true, IsActive: true}}
+	sessions := &fakeSessionRepo{}
+	return &refreshTest{refresh: NewRefreshTokenUseCase(users, sessions, emailService, &logger), sessions: sessions}
+}
+
+// add guarda una sesión de la familia "family" cuyo refresh token es token
+func (rt *refreshTest) add(t *testing.T, token string, consumed bool, rotatedAt time.Time) {
+	t.Helper()
+	hash, err := security.HashToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.sessions.Create(context.Background(), &entities.Session{
+		UserID:           7,
+		AccessTokenHash:  "access-" + token,
+		RefreshTokenHash: hash,
+		ExpiresAt:        time.Now().Add(15 * time.Minute),
+		RefreshExpiresAt: time.Now().Add(time.Hour),
+		IsValid:          !consumed,
+		Consumed:         consumed,
+		FamilyID:         "family",
+		UpdatedAt:        rotatedAt,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshTokenToleratesConcurrentTab(t *testing.T) {
+	rt := newRefreshTest(t)
+	rt.add(t, "refresh-1", false, time.Now())
+
+	if _, err := rt.refresh.Execute(context.Background(), "refresh-1", "", "127.0.0.1"); err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	// La otra pestaña llega con el mismo token un instante después
+	if _, err := rt.refresh.Execute(context.Background(), "refresh-1", "", "127.0.0.1"); !errors.Is(err, ErrRefreshTokenRotated) {
+		t.Fatalf("second refresh: got %v, want %v", err, ErrRefreshTokenRotated)
+	}
+	if valid := rt.sessions.valid(); valid != 1 {
+		t.Errorf("%d valid sessions after the second refresh, want the rotated one", valid)
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	tests := []struct {
+		name string
+		seed func(t *testing.T, rt *refreshTest)
+	}{
+		{name: "after the grace window", seed: func(t *testing.T, rt *refreshTest) {
+			rt.add(t, "refresh-1", true, time.Now().Add(-time.Minute))
+			rt.add(t, "refresh-2", false, time.Now())
+		}},
+		{name: "older than the previous token", seed: func(t *testing.T, rt *refreshTest) {
+			rt.add(t, "refresh-1", true, time.Now())
+			rt.add(t, "refresh-2", true, time.Now())
+			rt.add(t, "refresh-3", false, time.Now())
+		}},
+		{name: "successor already revoked", seed: func(t *testing.T, rt *refreshTest) {
+			rt.add(t, "refresh-1", true, time.Now())
+			rt.add(t, "refresh-2", true, time.Now())
+			rt.sessions.sessions[1].Consumed = false
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newRefreshTest(t)
+			tt.seed(t, rt)
+
+			if _, err := rt.refresh.Execute(context.Background(), "refresh-1", "", "127.0.0.1"); !errors.Is(err, ErrRefreshTokenReused) {
+				t.Fatalf("got %v, want %v", err, ErrRefreshTokenReused)
+			}
+			if valid := rt.sessions.valid(); valid != 0 {
+				t.Errorf("%d valid sessions after reuse, want the family revoked", valid)
+			}
+		})
+	}
+}
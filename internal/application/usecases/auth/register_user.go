@@ -17,16 +17,18 @@ import (
 type RegisterUserUseCase struct {
 	userRepo     repository.UserRepository
 	subscriptionRepo     repository.SubscriptionRepository
+	emailVerificationRepo repository.EmailVerificationRepository
 	emailService *email.EmailService
 	cacheService *cache.Cache
 	logger      *zerolog.Logger
 }
 
 
-func NewRegisterUserUseCase(userRepo repository.UserRepository, subscriptionRepo repository.SubscriptionRepository, emailService *email.EmailService, cacheService *cache.Cache, logger *zerolog.Logger) *RegisterUserUseCase {
+func NewRegisterUserUseCase(userRepo repository.UserRepository, subscriptionRepo repository.SubscriptionRepository, emailVerificationRepo repository.EmailVerificationRepository, emailService *email.EmailService, cacheService *cache.Cache, logger *zerolog.Logger) *RegisterUserUseCase {
 	return &RegisterUserUseCase{
 		userRepo:     userRepo,
 		subscriptionRepo: subscriptionRepo,
+		emailVerificationRepo: emailVerificationRepo,
 		emailService: emailService,
 		cacheService: cacheService,
 		logger: logger,
@@ -99,18 +101,7 @@ func (uc *RegisterUserUseCase) Execute(ctx context.Context, input dtos.RegisterI
         return nil, fmt.Errorf("failed to create subscription for user %d: %w", userID, err)
     }
 	
-	code, err := security.GenerateVerificationCode(6)
-	if err != nil {
-		uc.logger.Error().
-            Err(err).
-            Int("user_id", userID).
-            Msg("Failed to generate verification code")
-		return nil, err
-	}
-
-	expiresAt := time.Now().Add(15 * time.Minute)
-
-	err = uc.userRepo.CreateEmailVerification(userID, code, expiresAt)
+	code, expiresAt, err := issueVerificationCode(ctx, uc.userRepo, uc.emailVerificationRepo, userID)
 	if err != nil {
 		uc.logger.Error().
             Err(err).
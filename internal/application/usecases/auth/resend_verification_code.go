@@ -7,7 +7,6 @@ import (
 	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/http/dtos"
 	"luthierSaas/internal/interfaces/repository"
-	"time"
 )
 
 type ResendVerificationCodeUseCase struct {
@@ -26,31 +25,29 @@ func NewResendVerificationCodeUseCase(userRepo repository.UserRepository, emailV
 func (uc *ResendVerificationCodeUseCase) Execute(verificationToken string) (*dtos.ResendCode, error) {
 	userId, userEmail, _, err := security.ValidateVerificationToken(verificationToken)
 	if err != nil {
-		return nil, err
+		return nil, &VerificationError{Code: VerificationInvalidToken}
 	}
 
-	emailVerificationCode, err := uc.emailVerificationRepo.GetByUserID(context.Background(), userId)
+	emailVerification, err := uc.emailVerificationRepo.GetByUserID(context.Background(), userId)
 	if err != nil {
 		return nil, err
 	}
 
-	if emailVerificationCode == nil {
-		return nil, err
+	if emailVerification == nil {
+		return nil, &VerificationError{Code: VerificationNotFound}
 	}
-	
-	newCode, err := security.GenerateVerificationCode(6)
-	if err != nil {
-		return nil, err
+
+	if emailVerification.Verified {
+		return nil, &VerificationError{Code: VerificationAlreadyVerified}
 	}
-	
-	newExpiresAt := time.Now().Add(15 * time.Minute)
 
-	newVerificationToken, err := security.CreateVerificationToken(userId, userEmail, newExpiresAt)
+	// El código nuevo reinicia los intentos, es la única forma de salir de too_many_attempts
+	newCode, newExpiresAt, err := issueVerificationCode(context.Background(), uc.userRepo, uc.emailVerificationRepo, userId)
 	if err != nil {
 		return nil, err
 	}
-	
-	err = uc.emailVerificationRepo.UpdateCode(context.Background(), emailVerificationCode.ID, newCode, newExpiresAt)
+
+	newVerificationToken, err := security.CreateVerificationToken(userId, userEmail, newExpiresAt)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type ResetPasswordUseCase struct {
+	userRepo          repository.UserRepository
+	passwordResetRepo repository.PasswordResetRepository
+	sessionRepo       repository.SessionRepository
+	tokenRepo         repository.PersonalAccessTokenRepository
+	emailService      *email.EmailService
+	cacheService      *cache.Cache
+	lockout           *security.LoginLockout
+	logger            *zerolog.Logger
+}
+
+func NewResetPasswordUseCase(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	sessionRepo repository.SessionRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	emailService *email.EmailService,
+	cacheService *cache.Cache,
+	lockout *security.LoginLockout,
+	logger *zerolog.Logger,
+) *ResetPasswordUseCase {
+	return &ResetPasswordUseCase{
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		sessionRepo:       sessionRepo,
+		tokenRepo:         tokenRepo,
+		emailService:      emailService,
+		cacheService:      cacheService,
+		lockout:           lockout,
+		logger:            logger,
+	}
+}
+
+func (uc *ResetPasswordUseCase) Execute(ctx context.Context, input dtos.ResetPasswordInput) error {
+	tokenHash, err := security.HashToken(input.Token)
+	if err != nil {
+		return fmt.Errorf("failed to hash reset token: %w", err)
+	}
+
+	reset, err := uc.passwordResetRepo.FindByTokenHash(ctx, tokenHash)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Msg("Failed to find password reset")
+		return fmt.Errorf("failed to find password reset: %w", err)
+	}
+
+	if reset == nil || reset.Used || time.Now().After(reset.ExpiresAt) {
+		uc.logger.Warn().
+			Msg("Invalid or expired password reset token")
+		return ErrInvalidResetToken
+	}
+
+	user, err := uc.userRepo.FindByID(reset.UserID)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", reset.UserID).
+			Msg("Failed to find user")
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil || user.Deleted {
+		return ErrInvalidResetToken
+	}
+
+	// El token se consume antes de cambiar la contraseña para que no pueda reutilizarse
+	marked, err := uc.passwordResetRepo.MarkAsUsed(ctx, reset.ID)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to mark password reset as used")
+		return fmt.Errorf("failed to mark password reset as used: %w", err)
+	}
+	if !marked {
+		// Otro request con el mismo token lo consumió entre la búsqueda y este punto
+		uc.logger.Warn().
+			Int("user_id", user.ID).
+			Msg("Password reset token already used")
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := security.HashPassword(input.NewPassword)
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to hash new password")
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	if err := uc.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to update password")
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := uc.sessionRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to invalidate sessions")
+		return fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+	// Un token personal creado por quien conocía la contraseña anterior tampoco tiene que seguir sirviendo
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to revoke access tokens")
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	_ = uc.cacheService.Delete(ctx, fmt.Sprintf("profile:user:%d", user.ID))
+
+	// Restablecer la contraseña prueba que el dueño tiene el email, se levanta el bloqueo por intentos fallidos
+	if err := uc.lockout.Unlock(ctx, user.ID); err != nil {
+		uc.logger.Warn().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to unlock login after password reset")
+	}
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Se restableció tu contraseña",
+		Body:    "Tu contraseña fue restablecida y se cerraron todas tus sesiones. Si no fuiste vos, contactate con soporte.",
+	}
+
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("email", user.Email).
+			Msg("Failed to send password reset confirmation email")
+	}
+
+	uc.logger.Info().
+		Int("user_id", user.ID).
+		Msg("Password reset successfully")
+
+	return nil
+}
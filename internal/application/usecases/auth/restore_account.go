@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var ErrAccountDeleted = errors.New("account deleted")
+
+// accountRestorable indica si la cuenta la borró su dueño y todavía está dentro del plazo para
+// recuperarla. Las cuentas borradas desde el panel de administración no tienen PurgeAfter.
+func accountRestorable(user *entities.User) bool {
+	return user.Deleted && !user.PurgeAfter.IsZero() && time.Now().Before(user.PurgeAfter)
+}
+
+// restoreDeletedAccount cancela el borrado programado. Se llama justo antes de crear la sesión, así
+// la cuenta solo se recupera cuando el login se completó, incluido el segundo factor.
+func restoreDeletedAccount(
+	ctx context.Context,
+	userRepo repository.UserRepository,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	user *entities.User,
+) error {
+	if !user.Deleted {
+		return nil
+	}
+	if !accountRestorable(user) {
+		return ErrAccountDeleted
+	}
+
+	if err := userRepo.RestoreDeleted(user.ID); err != nil {
+		logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to restore deleted account")
+		return fmt.Errorf("failed to restore account: %w", err)
+	}
+	user.Deleted = false
+	user.DeletedAt = time.Time{}
+	user.PurgeAfter = time.Time{}
+
+	logger.Info().
+		Int("user_id", user.ID).
+		Str("email", user.Email).
+		Msg("Deleted account restored by login")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Recuperaste tu cuenta",
+		Body:    "Iniciaste sesión durante el plazo de recuperación, así que cancelamos la eliminación de tu cuenta. Si no fuiste vos, cambiá tu contraseña y revisá tus sesiones activas.",
+	}
+	if err := emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("email", user.Email).
+			Msg("Failed to send account restored email")
+	}
+
+	return nil
+}
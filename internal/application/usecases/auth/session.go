@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+)
+
+// createSession emite el par access/refresh y guarda la sesión. Un familyID vacío
+// indica un login nuevo; en una rotación se pasa el de la sesión anterior.
+func createSession(ctx context.Context, sessionRepo repository.SessionRepository, user *entities.User, deviceInfo, clientIP string, familyID string) (string, string, *entities.Session, error) {
+	userID := user.ID
+
+	if familyID == "" {
+		var err error
+		familyID, err = security.GenerateSecureToken(16)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to generate session family: %w", err)
+		}
+	}
+
+	accessToken, err := security.CreateAccessToken(userID, user.Role)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	refreshToken, err := security.CreateRefreshToken(userID)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	accessTokenHash, err := security.HashToken(accessToken)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to hash access token: %w", err)
+	}
+
+	refreshTokenHash, err := security.HashToken(refreshToken)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to hash refresh token: %w", err)
+	}
+
+	session := &entities.Session{
+		UserID:           userID,
+		AccessTokenHash:  accessTokenHash,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        time.Now().Add(15 * time.Minute),
+		RefreshExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+		IsValid:          true,
+		DeviceInfo:       deviceInfo,
+		IPAddress:        clientIP,
+		CreatedAt:        time.Now(),
+		FamilyID:         familyID,
+	}
+
+	if err := sessionRepo.Create(ctx, session); err != nil {
+		return "", "", nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return accessToken, refreshToken, session, nil
+}
+
+func newProfileResponse(user *entities.User) *dtos.ProfileResponse {
+	return &dtos.ProfileResponse{
+		ID:           user.ID,
+		Email:        user.Email,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Phone:        user.Phone,
+		Address:      user.Address,
+		Country:      user.Country,
+		WorkshopName: user.WorkshopName,
+		LastLogin:    user.LastLogin,
+		Providers:    user.Providers,
+		HasPassword:  user.Password != "",
+		MFAEnabled:   user.MFAEnabled,
+		Role:         user.Role,
+		Subscription: user.Subscription,
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrInvalidUnrecognizedLoginLink = errors.New("invalid or expired link")
+	ErrPasswordResetRequired        = errors.New("password reset required, check your email")
+)
+
+// ReportUnrecognizedLoginUseCase atiende el link "no fui yo" del aviso de dispositivo nuevo. Si alguien
+// entró con la contraseña no alcanza con cerrar esa sesión: se cierran todas y la contraseña actual
+// deja de servir hasta que el dueño la restablezca.
+type ReportUnrecognizedLoginUseCase struct {
+	userRepo      repository.UserRepository
+	sessionRepo   repository.SessionRepository
+	tokenRepo     repository.PersonalAccessTokenRepository
+	cacheService  *cache.Cache
+	passwordReset *ForgotPasswordUseCase
+	logger        *zerolog.Logger
+}
+
+func NewReportUnrecognizedLoginUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	cacheService *cache.Cache,
+	passwordReset *ForgotPasswordUseCase,
+	logger *zerolog.Logger,
+) *ReportUnrecognizedLoginUseCase {
+	return &ReportUnrecognizedLoginUseCase{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		tokenRepo:     tokenRepo,
+		cacheService:  cacheService,
+		passwordReset: passwordReset,
+		logger:        logger,
+	}
+}
+
+func (uc *ReportUnrecognizedLoginUseCase) Execute(ctx context.Context, token string) error {
+	tokenHash, err := security.HashToken(token)
+	if err != nil {
+		return fmt.Errorf("failed to hash token: %w", err)
+	}
+
+	cached, err := uc.cacheService.GetDel(ctx, unrecognizedLoginKey(tokenHash))
+	if err != nil {
+		return ErrInvalidUnrecognizedLoginLink
+	}
+	rawUserID, rawSessionID, found := strings.Cut(cached, ":")
+	if !found {
+		return ErrInvalidUnrecognizedLoginLink
+	}
+	userID, err := strconv.Atoi(rawUserID)
+	if err != nil {
+		return ErrInvalidUnrecognizedLoginLink
+	}
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return ErrInvalidUnrecognizedLoginLink
+	}
+
+	if err := uc.sessionRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to invalidate sessions")
+		return fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+	// Quien entró pudo haberse creado un token personal para volver
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to revoke access tokens")
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	_ = uc.cacheService.Delete(ctx, fmt.Sprintf("profile:user:%d", user.ID))
+
+	uc.logger.Warn().
+		Int("user_id", user.ID).
+		Str("session_id", rawSessionID).
+		Msg("User reported an unrecognized login")
+
+	// Las cuentas sin contraseña entran por un proveedor o un link al email, no hay nada que restablecer
+	if user.Password == "" {
+		return nil
+	}
+
+	if err := uc.userRepo.RequirePasswordReset(ctx, user.ID); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to require password reset")
+		return fmt.Errorf("failed to require password reset: %w", err)
+	}
+
+	return uc.passwordReset.sendResetLink(ctx, user,
+		"Cerramos tus sesiones, elegí una nueva contraseña",
+		"Nos avisaste que un inicio de sesión no fue tuyo, así que cerramos todas tus sesiones y desactivamos tu contraseña actual.",
+		"Hasta que la cambies no vas a poder entrar con la contraseña anterior. Te recomendamos activar la verificación en dos pasos.",
+	)
+}
@@ -1,12 +1,14 @@
 package auth
 
 import (
+	idp "luthierSaas/internal/infrastructure/auth"
 	"luthierSaas/internal/infrastructure/cache"
 	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/repository"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/rs/zerolog"
-	"golang.org/x/oauth2"
 )
 
 type AuthUseCases struct {
@@ -16,9 +18,18 @@ type AuthUseCases struct {
     VerifyEmail *VerifyEmailUseCase
     ResendVerificationCode *ResendVerificationCodeUseCase
     RefreshToken *RefreshTokenUseCase
-    GoogleLogin *LoginGoogleUseCase
-    GoogleCallback *GoogleCallbackUseCase
+    OAuthLogin *OAuthLoginUseCase
+    OAuthCallback *OAuthCallbackUseCase
+    LinkProvider *LinkProviderUseCase
     Logout *LogoutUseCase
+    ForgotPassword *ForgotPasswordUseCase
+    ResetPassword *ResetPasswordUseCase
+    VerifyMFA *VerifyMFAUseCase
+    RequestMagicLink *RequestMagicLinkUseCase
+    MagicLinkLogin *MagicLinkLoginUseCase
+    BeginPasskeyLogin *BeginPasskeyLoginUseCase
+    PasskeyLogin *PasskeyLoginUseCase
+    ReportUnrecognizedLogin *ReportUnrecognizedLoginUseCase
 }
 
 func NewAuthUseCases(
@@ -26,21 +37,42 @@ func NewAuthUseCases(
     suscriptionRepo repository.SubscriptionRepository, 
     emailVerificationRepo repository.EmailVerificationRepository, 
     sessionRepo repository.SessionRepository, 
+    passwordResetRepo repository.PasswordResetRepository,
+    recoveryCodeRepo repository.MFARecoveryCodeRepository,
+    identityRepo repository.UserIdentityRepository,
+    passkeyRepo repository.WebAuthnCredentialRepository,
+    tokenRepo repository.PersonalAccessTokenRepository,
     emailService *email.EmailService, 
     cacheService *cache.Cache,
     logger      *zerolog.Logger,
-    googleOAuthConfig *oauth2.Config,
+    identityProviders *idp.Registry,
+    webAuthn *webauthn.WebAuthn,
+    loginLockout *security.LoginLockout,
+    appClientURL string,
     ) *AuthUseCases{
-        
+
+    newDevices := newNewDeviceNotifier(sessionRepo, cacheService, emailService, logger, appClientURL)
+    login := NewLoginUseCase(userRepo, emailVerificationRepo, sessionRepo, emailService, loginLockout, newDevices, logger)
+    forgotPassword := NewForgotPasswordUseCase(userRepo, passwordResetRepo, emailService, logger, appClientURL)
+
     return &AuthUseCases{
-        Login:      NewLoginUseCase(userRepo, emailVerificationRepo, sessionRepo, emailService, logger),
-        Register:   NewRegisterUserUseCase(userRepo, suscriptionRepo, emailService, cacheService, logger),
+        Login:      login,
+        Register:   NewRegisterUserUseCase(userRepo, suscriptionRepo, emailVerificationRepo, emailService, cacheService, logger),
         CheckEmail: NewCheckEmailUseCase(userRepo, cacheService),
         VerifyEmail: NewVerifyEmailUseCase(userRepo, emailVerificationRepo),
         ResendVerificationCode: NewResendVerificationCodeUseCase(userRepo, emailVerificationRepo, emailService),
-        RefreshToken: NewRefreshTokenUseCase(userRepo, sessionRepo),
-        GoogleLogin: NewLoginGoogleUseCase(googleOAuthConfig, cacheService),
-        GoogleCallback: NewGoogleCallbackUseCase(googleOAuthConfig, userRepo, suscriptionRepo, emailVerificationRepo, sessionRepo, emailService, cacheService, logger),
+        RefreshToken: NewRefreshTokenUseCase(userRepo, sessionRepo, emailService, logger),
+        OAuthLogin: NewOAuthLoginUseCase(identityProviders, cacheService),
+        OAuthCallback: NewOAuthCallbackUseCase(identityProviders, userRepo, suscriptionRepo, emailVerificationRepo, sessionRepo, identityRepo, emailService, cacheService, newDevices, logger),
+        LinkProvider: NewLinkProviderUseCase(identityProviders, cacheService),
         Logout: NewLogoutUseCase(sessionRepo, logger),
+        ForgotPassword: forgotPassword,
+        ResetPassword: NewResetPasswordUseCase(userRepo, passwordResetRepo, sessionRepo, tokenRepo, emailService, cacheService, loginLockout, logger),
+        VerifyMFA: NewVerifyMFAUseCase(userRepo, sessionRepo, recoveryCodeRepo, cacheService, emailService, newDevices, logger),
+        RequestMagicLink: NewRequestMagicLinkUseCase(userRepo, cacheService, emailService, logger, appClientURL),
+        MagicLinkLogin: NewMagicLinkLoginUseCase(userRepo, cacheService, login, logger),
+        BeginPasskeyLogin: NewBeginPasskeyLoginUseCase(webAuthn, cacheService),
+        PasskeyLogin: NewPasskeyLoginUseCase(userRepo, passkeyRepo, webAuthn, cacheService, login, logger),
+        ReportUnrecognizedLogin: NewReportUnrecognizedLoginUseCase(userRepo, sessionRepo, tokenRepo, cacheService, forgotPassword, logger),
     }
 }
\ No newline at end of file
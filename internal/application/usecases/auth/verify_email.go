@@ -2,12 +2,79 @@ package auth
 
 import (
 	"context"
-	"errors"
+	"crypto/subtle"
 	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/repository"
 	"time"
 )
 
+const (
+	verificationCodeLength = 6
+	verificationCodeTTL    = 15 * time.Minute
+	// Después de esta cantidad de códigos incorrectos hay que pedir un reenvío
+	maxVerificationAttempts = 5
+)
+
+// Códigos estables de error de verificación, el frontend decide con ellos qué mostrar
+const (
+	VerificationInvalidToken    = "invalid_token"
+	VerificationNotFound        = "verification_not_found"
+	VerificationAlreadyVerified = "already_verified"
+	VerificationCodeExpired     = "code_expired"
+	VerificationCodeIncorrect   = "code_incorrect"
+	VerificationTooManyAttempts = "too_many_attempts"
+)
+
+var verificationErrorMessages = map[string]string{
+	VerificationInvalidToken:    "verification token is invalid",
+	VerificationNotFound:        "no pending email verification",
+	VerificationAlreadyVerified: "email already verified",
+	VerificationCodeExpired:     "verification code has expired",
+	VerificationCodeIncorrect:   "verification code is incorrect",
+	VerificationTooManyAttempts: "too many incorrect codes, request a new one",
+}
+
+type VerificationError struct {
+	Code              string
+	AttemptsRemaining int
+}
+
+func (e *VerificationError) Error() string {
+	return verificationErrorMessages[e.Code]
+}
+
+// issueVerificationCode genera un código nuevo, guarda solo su hash y reinicia los intentos.
+// Devuelve el código en claro para mandarlo por email.
+func issueVerificationCode(
+	ctx context.Context,
+	userRepo repository.UserRepository,
+	emailVerificationRepo repository.EmailVerificationRepository,
+	userID int,
+) (string, time.Time, error) {
+	code, err := security.GenerateVerificationCode(verificationCodeLength)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	codeHash := security.HashVerificationCode(code)
+	expiresAt := time.Now().Add(verificationCodeTTL)
+
+	emailVerification, err := emailVerificationRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if emailVerification == nil {
+		err = userRepo.CreateEmailVerification(userID, codeHash, expiresAt)
+	} else {
+		err = emailVerificationRepo.UpdateCode(ctx, emailVerification.ID, codeHash, expiresAt)
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return code, expiresAt, nil
+}
+
 type VerifyEmailUseCase struct {
 	userRepo              repository.UserRepository
 	emailVerificationRepo  repository.EmailVerificationRepository
@@ -25,11 +92,11 @@ func (uc *VerifyEmailUseCase) Execute(verificationToken string, verificationCode
 
 	userId, _, verificationExpiresAt, err := security.ValidateVerificationToken(verificationToken)
 	if err != nil {
-		return false, err
+		return false, &VerificationError{Code: VerificationInvalidToken}
 	}
 
 	if time.Now().After(verificationExpiresAt) {
-		return false, errors.New("verification token has expired")
+		return false, &VerificationError{Code: VerificationCodeExpired}
 	}
 
 	emailVerification, err := uc.emailVerificationRepo.GetByUserID(ctx, userId)
@@ -37,19 +104,42 @@ func (uc *VerifyEmailUseCase) Execute(verificationToken string, verificationCode
 		return false, err
 	}
 
+	if emailVerification == nil {
+		return false, &VerificationError{Code: VerificationNotFound}
+	}
+
 	if emailVerification.Verified {
-		return false, errors.New("email already verified")
+		return false, &VerificationError{Code: VerificationAlreadyVerified}
+	}
+
+	// Un código que agotó los intentos no vuelve a servir aunque sea el correcto
+	if emailVerification.Attempts >= maxVerificationAttempts {
+		return false, &VerificationError{Code: VerificationTooManyAttempts}
 	}
 
-	if emailVerification.Code != verificationCode {
-		return false, errors.New("verification code is incorrect")
+	if time.Now().After(emailVerification.ExpiresAt) {
+		return false, &VerificationError{Code: VerificationCodeExpired}
+	}
+
+	codeHash := security.HashVerificationCode(verificationCode)
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(emailVerification.CodeHash)) != 1 {
+		attempts, err := uc.emailVerificationRepo.IncrementAttempts(ctx, emailVerification.ID)
+		if err != nil {
+			return false, err
+		}
+
+		remaining := maxVerificationAttempts - attempts
+		if remaining <= 0 {
+			return false, &VerificationError{Code: VerificationTooManyAttempts}
+		}
+		return false, &VerificationError{Code: VerificationCodeIncorrect, AttemptsRemaining: remaining}
 	}
 
 	err = uc.emailVerificationRepo.MarkAsVerified(ctx, userId)
 	if err != nil {
 		return false, err
 	}
-	
+
 	err = uc.userRepo.UpdateEmailVerified(userId, true)
 	if err != nil {
 		return false, err
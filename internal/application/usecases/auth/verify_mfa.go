@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const maxMFAAttempts = 5
+
+var ErrInvalidMFACode = errors.New("invalid mfa code")
+
+type VerifyMFAUseCase struct {
+	userRepo         repository.UserRepository
+	sessionRepo      repository.SessionRepository
+	recoveryCodeRepo repository.MFARecoveryCodeRepository
+	cacheService     *cache.Cache
+	emailService     *email.EmailService
+	newDevices       *newDeviceNotifier
+	logger           *zerolog.Logger
+}
+
+func NewVerifyMFAUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	recoveryCodeRepo repository.MFARecoveryCodeRepository,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	newDevices *newDeviceNotifier,
+	logger *zerolog.Logger,
+) *VerifyMFAUseCase {
+	return &VerifyMFAUseCase{
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		cacheService:     cacheService,
+		emailService:     emailService,
+		newDevices:       newDevices,
+		logger:           logger,
+	}
+}
+
+func (uc *VerifyMFAUseCase) Execute(ctx context.Context, input dtos.VerifyMFAInput, deviceInfo, clientIP string) (*dtos.LoginResponse, error) {
+	userID, err := security.ValidateMFAToken(input.MFAToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	// Limita los intentos por token para que no se pueda adivinar el código dentro de los 5 minutos
+	tokenHash, _ := security.HashToken(input.MFAToken)
+	attemptsKey := "mfa:attempts:" + tokenHash
+	attempts, err := uc.cacheService.Incr(ctx, attemptsKey, 5*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to track mfa attempts: %w", err)
+	}
+	if attempts > maxMFAAttempts {
+		uc.logger.Warn().
+			Int("user_id", userID).
+			Msg("Too many mfa attempts")
+		return nil, errors.New("too many attempts, sign in again")
+	}
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil || (user.Deleted && !accountRestorable(user)) || !user.IsActive || !user.MFAEnabled {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	switch {
+	case input.Code != "":
+		secret, err := security.DecryptSecret(user.MFASecret)
+		if err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", user.ID).
+				Msg("Failed to decrypt mfa secret")
+			return nil, fmt.Errorf("failed to decrypt mfa secret: %w", err)
+		}
+
+		step, ok := security.ValidateTOTP(secret, input.Code, time.Now())
+		if !ok {
+			uc.logger.Warn().
+				Int("user_id", user.ID).
+				Msg("Invalid mfa code")
+			return nil, ErrInvalidMFACode
+		}
+
+		// Un mismo código no puede usarse dos veces
+		usedKey := fmt.Sprintf("mfa:used:%d:%d", user.ID, step)
+		fresh, err := uc.cacheService.Client().SetNX(ctx, usedKey, "1", time.Duration(2*security.TOTPSkew+1)*security.TOTPPeriod*time.Second).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to store mfa step: %w", err)
+		}
+		if !fresh {
+			return nil, ErrInvalidMFACode
+		}
+	case input.RecoveryCode != "":
+		codeHash, err := security.HashToken(security.NormalizeRecoveryCode(input.RecoveryCode))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+
+		consumed, err := uc.recoveryCodeRepo.Consume(ctx, user.ID, codeHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+		if !consumed {
+			uc.logger.Warn().
+				Int("user_id", user.ID).
+				Msg("Invalid recovery code")
+			return nil, ErrInvalidMFACode
+		}
+
+		uc.logger.Info().
+			Int("user_id", user.ID).
+			Msg("Recovery code used to sign in")
+	default:
+		return nil, errors.New("code or recovery_code is required")
+	}
+
+	_ = uc.cacheService.Delete(ctx, attemptsKey)
+
+	if err := restoreDeletedAccount(ctx, uc.userRepo, uc.emailService, uc.logger, user); err != nil {
+		return nil, err
+	}
+
+	currentTime := time.Now()
+	if err := uc.userRepo.UpdateLastLogin(ctx, user.ID, currentTime); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Msg("Failed to update last login")
+		return nil, err
+	}
+	user.LastLogin = currentTime.Format(time.RFC3339)
+
+	accessToken, refreshToken, session, err := createSession(ctx, uc.sessionRepo, user, deviceInfo, clientIP, "")
+	if err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("device_info", deviceInfo).
+			Msg("Failed to create session")
+		return nil, err
+	}
+	uc.newDevices.Notify(ctx, user, session)
+
+	uc.logger.Info().
+		Int("user_id", user.ID).
+		Str("device_info", deviceInfo).
+		Msg("User logged in successfully with mfa")
+
+	return &dtos.LoginResponse{
+		Profile:      newProfileResponse(user),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -0,0 +1,318 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrPlanNotFound         = errors.New("plan not found")
+	ErrPlanNotSelectable    = errors.New("the free trial is only available when signing up")
+	ErrSamePlan             = errors.New("already subscribed to this plan")
+	ErrNoActiveSubscription = errors.New("no active subscription")
+	ErrSubscriptionChanged  = errors.New("subscription changed meanwhile, try again")
+	ErrAlreadyCanceled      = errors.New("subscription is already set to cancel at period end")
+	ErrCheckoutUnavailable  = errors.New("payment provider unavailable, try again later")
+)
+
+// subscriptionManager agrupa las dependencias de los casos de uso que modifican la suscripción
+type subscriptionManager struct {
+	userRepo         repository.UserRepository
+	subscriptionRepo repository.SubscriptionRepository
+	cache            *cache.Cache
+	emailService     *email.EmailService
+	logger           *zerolog.Logger
+}
+
+func (m *subscriptionManager) activeSubscription(ctx context.Context, userID int) (*entities.Subscription, error) {
+	current, err := m.subscriptionRepo.FindActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscription: %w", err)
+	}
+	if current == nil {
+		return nil, ErrNoActiveSubscription
+	}
+	return current, nil
+}
+
+// finish descarta el perfil cacheado, que incluye la suscripción, y avisa por email salvo que la
+// cuenta esté borrada
+func (m *subscriptionManager) finish(ctx context.Context, userID int, subject, body string) (*dtos.SubscriptionResponse, error) {
+	_ = m.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+	_ = m.cache.Delete(ctx, planTierCacheKey(userID))
+
+	user, err := m.userRepo.FindByID(userID)
+	if err == nil && user != nil && !user.Deleted {
+		emailJob := email.EmailJob{To: user.Email, Subject: subject, Body: body}
+		if err := m.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+			m.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Msg("Failed to send subscription email")
+		}
+	}
+
+	return subscriptionOverview(ctx, m.subscriptionRepo, userID)
+}
+
+// ChangePlanUseCase cambia de plan. Subir a un plan pago abre el checkout de la pasarela y el plan se
+// activa, con un período nuevo, cuando llega el webhook del pago; bajar de plan queda programado para
+// cuando vence el período actual, que ya está pago.
+type ChangePlanUseCase struct {
+	subscriptionManager
+	checkouts *checkoutOpener
+}
+
+func NewChangePlanUseCase(
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	gateway payment.PaymentGateway,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+) *ChangePlanUseCase {
+	return &ChangePlanUseCase{
+		subscriptionManager: subscriptionManager{userRepo, subscriptionRepo, cacheService, emailService, logger},
+		checkouts:           &checkoutOpener{subscriptionRepo, gateway, logger, appClientURL},
+	}
+}
+
+func (uc *ChangePlanUseCase) Execute(ctx context.Context, userID int, input dtos.ChangePlanInput) (*dtos.SubscriptionResponse, error) {
+	plan, err := uc.subscriptionRepo.FindPlanByID(ctx, input.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, ErrPlanNotFound
+	}
+	if plan.Name == entities.FreeTierPlanName {
+		return nil, ErrPlanNotSelectable
+	}
+
+	current, err := uc.subscriptionRepo.FindActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscription: %w", err)
+	}
+	if current != nil && current.PlanID == plan.ID {
+		return nil, ErrSamePlan
+	}
+
+	now := time.Now()
+	downgrade := false
+	if current != nil && now.Before(current.ExpiresAt) {
+		currentPlan, err := uc.subscriptionRepo.FindPlanByID(ctx, current.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		downgrade = currentPlan != nil && plan.Price < currentPlan.Price
+	}
+
+	if downgrade {
+		return uc.downgrade(ctx, userID, current, plan)
+	}
+	if plan.Price > 0 {
+		return uc.checkout(ctx, userID, plan, now)
+	}
+	return uc.upgrade(ctx, userID, current, plan, now)
+}
+
+// checkout deja una suscripción pendiente y devuelve la página de pago. La suscripción activa no se
+// toca hasta que la pasarela confirma el cobro.
+func (uc *ChangePlanUseCase) checkout(ctx context.Context, userID int, plan *entities.SubscriptionPlan, now time.Time) (*dtos.SubscriptionResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+
+	_, session, err := uc.checkouts.open(ctx, user, plan, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := subscriptionOverview(ctx, uc.subscriptionRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.Checkout = &dtos.CheckoutResponse{ID: session.ID, URL: session.URL}
+	return result, nil
+}
+
+func (uc *ChangePlanUseCase) upgrade(ctx context.Context, userID int, current *entities.Subscription, plan *entities.SubscriptionPlan, now time.Time) (*dtos.SubscriptionResponse, error) {
+	next := &entities.Subscription{
+		UserID:    userID,
+		PlanID:    plan.ID,
+		Status:    entities.SubscriptionStatusActive,
+		StartedAt: now,
+		ExpiresAt: now.AddDate(0, 0, plan.DurationDays),
+	}
+	ok, err := uc.subscriptionRepo.Replace(ctx, current, next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change plan: %w", err)
+	}
+	if !ok {
+		return nil, ErrSubscriptionChanged
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("plan_id", plan.ID).
+		Time("expires_at", next.ExpiresAt).
+		Msg("Subscription plan upgraded")
+
+	return uc.finish(ctx, userID,
+		"Cambiaste de plan",
+		fmt.Sprintf("Tu cuenta ya tiene el plan %s, vigente hasta el %s.", plan.Name, next.ExpiresAt.Format("02/01/2006")),
+	)
+}
+
+func (uc *ChangePlanUseCase) downgrade(ctx context.Context, userID int, current *entities.Subscription, plan *entities.SubscriptionPlan) (*dtos.SubscriptionResponse, error) {
+	next := &entities.Subscription{
+		UserID:    userID,
+		PlanID:    plan.ID,
+		Status:    entities.SubscriptionStatusScheduled,
+		StartedAt: current.ExpiresAt,
+		ExpiresAt: current.ExpiresAt.AddDate(0, 0, plan.DurationDays),
+	}
+	ok, err := uc.subscriptionRepo.ScheduleChange(ctx, current, next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule plan change: %w", err)
+	}
+	if !ok {
+		return nil, ErrSubscriptionChanged
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("plan_id", plan.ID).
+		Time("starts_at", next.StartedAt).
+		Msg("Subscription plan downgrade scheduled")
+
+	return uc.finish(ctx, userID,
+		"Programaste un cambio de plan",
+		fmt.Sprintf("Seguís con el plan %s hasta el %s. Desde ese día tu cuenta pasa al plan %s.", current.PlanName, current.ExpiresAt.Format("02/01/2006"), plan.Name),
+	)
+}
+
+// CancelSubscriptionUseCase cancela la suscripción. Por defecto sigue vigente hasta que vence y no se
+// renueva; con AtPeriodEnd en false se cierra en el momento.
+type CancelSubscriptionUseCase struct {
+	subscriptionManager
+}
+
+func NewCancelSubscriptionUseCase(
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *CancelSubscriptionUseCase {
+	return &CancelSubscriptionUseCase{subscriptionManager{userRepo, subscriptionRepo, cacheService, emailService, logger}}
+}
+
+func (uc *CancelSubscriptionUseCase) Execute(ctx context.Context, userID int, input dtos.CancelSubscriptionInput) (*dtos.SubscriptionResponse, error) {
+	current, err := uc.activeSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	atPeriodEnd := input.AtPeriodEnd == nil || *input.AtPeriodEnd
+	if atPeriodEnd {
+		if current.CancelAtPeriodEnd {
+			return nil, ErrAlreadyCanceled
+		}
+		ok, err := uc.subscriptionRepo.SetCancelAtPeriodEnd(ctx, current, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel subscription: %w", err)
+		}
+		if !ok {
+			return nil, ErrSubscriptionChanged
+		}
+
+		uc.logger.Info().
+			Int("user_id", userID).
+			Int("subscription_id", current.ID).
+			Msg("Subscription set to cancel at period end")
+
+		return uc.finish(ctx, userID,
+			"Cancelaste tu suscripción",
+			fmt.Sprintf("Tu plan %s sigue activo hasta el %s y después no se renueva. Si cambiás de idea podés reactivarlo antes de esa fecha desde tu perfil.", current.PlanName, current.ExpiresAt.Format("02/01/2006")),
+		)
+	}
+
+	ok, err := uc.subscriptionRepo.Cancel(ctx, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+	if !ok {
+		return nil, ErrSubscriptionChanged
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("subscription_id", current.ID).
+		Msg("Subscription canceled immediately")
+
+	return uc.finish(ctx, userID,
+		"Cancelaste tu suscripción",
+		fmt.Sprintf("Tu plan %s quedó cancelado. Podés elegir un plan nuevo cuando quieras desde tu perfil.", current.PlanName),
+	)
+}
+
+// ResumeSubscriptionUseCase deshace una cancelación o un cambio de plan programados, la
+// suscripción actual vuelve a renovarse al vencer
+type ResumeSubscriptionUseCase struct {
+	subscriptionManager
+}
+
+func NewResumeSubscriptionUseCase(
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *ResumeSubscriptionUseCase {
+	return &ResumeSubscriptionUseCase{subscriptionManager{userRepo, subscriptionRepo, cacheService, emailService, logger}}
+}
+
+func (uc *ResumeSubscriptionUseCase) Execute(ctx context.Context, userID int) (*dtos.SubscriptionResponse, error) {
+	current, err := uc.activeSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Sin nada programado no hay qué deshacer
+	if !current.CancelAtPeriodEnd {
+		return subscriptionOverview(ctx, uc.subscriptionRepo, userID)
+	}
+
+	ok, err := uc.subscriptionRepo.SetCancelAtPeriodEnd(ctx, current, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume subscription: %w", err)
+	}
+	if !ok {
+		return nil, ErrSubscriptionChanged
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("subscription_id", current.ID).
+		Msg("Subscription resumed")
+
+	return uc.finish(ctx, userID,
+		"Reactivaste tu suscripción",
+		fmt.Sprintf("Tu plan %s se va a renovar normalmente el %s.", current.PlanName, current.ExpiresAt.Format("02/01/2006")),
+	)
+}
@@ -0,0 +1,98 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/interfaces/repository"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// checkoutOpener deja una suscripción pendiente y abre su pago en la pasarela. La usan el cambio
+// de plan y la renovación; la suscripción se activa cuando llega el webhook del pago aprobado.
+type checkoutOpener struct {
+	subscriptionRepo repository.SubscriptionRepository
+	gateway          payment.PaymentGateway
+	logger           *zerolog.Logger
+	appClientURL     string
+}
+
+func (o *checkoutOpener) open(ctx context.Context, user *entities.User, plan *entities.SubscriptionPlan, now time.Time) (*entities.Subscription, *payment.CheckoutSession, error) {
+	if err := o.cancelPending(ctx, user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	// Las fechas definitivas se fijan al confirmarse el pago
+	pending := &entities.Subscription{
+		UserID:    user.ID,
+		PlanID:    plan.ID,
+		PlanName:  plan.Name,
+		Status:    entities.SubscriptionStatusPending,
+		StartedAt: now,
+		ExpiresAt: now.AddDate(0, 0, plan.DurationDays),
+	}
+	if _, err := o.subscriptionRepo.Save(pending); err != nil {
+		return nil, nil, err
+	}
+
+	session, err := o.gateway.CreateCheckout(ctx, payment.CheckoutRequest{
+		Reference:     strconv.Itoa(pending.ID),
+		UserID:        user.ID,
+		CustomerEmail: user.Email,
+		Description:   fmt.Sprintf("Plan %s - %d días", plan.Name, plan.DurationDays),
+		Amount:        plan.Price,
+		SuccessURL:    o.appClientURL + "/billing?checkout=success",
+		CancelURL:     o.appClientURL + "/billing?checkout=canceled",
+	})
+	if err != nil {
+		o.logger.Error().
+			Err(err).
+			Int("user_id", user.ID).
+			Str("provider", o.gateway.Name()).
+			Msg("Failed to create checkout session")
+		_, _ = o.subscriptionRepo.CancelPending(ctx, pending)
+		return nil, nil, ErrCheckoutUnavailable
+	}
+	if err := o.subscriptionRepo.SetCheckout(ctx, pending.ID, o.gateway.Name(), session.ID); err != nil {
+		return nil, nil, err
+	}
+	pending.PaymentProvider = o.gateway.Name()
+	pending.CheckoutID = session.ID
+
+	o.logger.Info().
+		Int("user_id", user.ID).
+		Int("plan_id", plan.ID).
+		Int("subscription_id", pending.ID).
+		Str("checkout_id", session.ID).
+		Msg("Checkout session created")
+
+	return pending, session, nil
+}
+
+// cancelPending descarta los checkouts que el usuario dejó abiertos, así cada cambio de plan no suma
+// una suscripción pendiente más. Si después llega el pago de uno descartado, el webhook lo registra
+// para devolverlo en lugar de activarlo.
+func (o *checkoutOpener) cancelPending(ctx context.Context, userID int) error {
+	for {
+		pending, err := o.subscriptionRepo.FindPending(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to find pending subscription: %w", err)
+		}
+		if pending == nil {
+			return nil
+		}
+		if _, err := o.subscriptionRepo.CancelPending(ctx, pending); err != nil {
+			return err
+		}
+
+		o.logger.Info().
+			Int("user_id", userID).
+			Int("subscription_id", pending.ID).
+			Str("checkout_id", pending.CheckoutID).
+			Msg("Previous checkout replaced")
+	}
+}
@@ -0,0 +1,42 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/payment"
+)
+
+func TestCheckoutReplacesPendingCheckout(t *testing.T) {
+	wt := newWebhookTest(t)
+	opener := &checkoutOpener{subscriptionRepo: wt.subscriptions, gateway: wt.gateway, logger: wt.logger, appClientURL: "http://localhost:5173"}
+	user := &entities.User{ID: 7, Email: "luthier@example.com"}
+	plan := wt.subscriptions.plans[2]
+
+	// newWebhookTest ya dejó un checkout abierto, el cambio de plan siguiente lo reemplaza
+	latest, _, err := opener.open(context.Background(), user, plan, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := wt.subscriptions.status(testPendingID); got != entities.SubscriptionStatusCanceled {
+		t.Errorf("previous checkout status %q, want %q", got, entities.SubscriptionStatusCanceled)
+	}
+
+	pending, err := wt.subscriptions.FindPending(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending == nil || pending.ID != latest.ID || pending.CheckoutID != latest.CheckoutID {
+		t.Fatalf("pending subscription %+v, want the latest checkout %+v", pending, latest)
+	}
+
+	// Pagar el checkout descartado no lo activa
+	if _, err := wt.gateway.Complete(context.Background(), wt.checkoutID, payment.FakeOutcomePaid, ""); err != nil {
+		t.Fatal(err)
+	}
+	if wt.subscriptions.activations != 0 {
+		t.Errorf("replaced checkout activated %d subscriptions", wt.subscriptions.activations)
+	}
+}
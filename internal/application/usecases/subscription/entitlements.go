@@ -0,0 +1,145 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+)
+
+const planFeaturesCacheTTL = 5 * time.Minute
+
+// El nivel se consulta en cada request por el rate limiter. finish lo borra al cambiar de plan, el TTL
+// cubre los vencimientos.
+const planTierCacheTTL = time.Minute
+
+var ErrUpgradeRequired = errors.New("upgrade required")
+
+// UpgradeRequiredError dice qué límite del plan se alcanzó, el frontend lo usa para ofrecer el plan siguiente
+type UpgradeRequiredError struct {
+	Feature string
+	// Limit es -1 en las funciones sin cupo, como pdf_branding
+	Limit int
+	Usage int
+	Plan  string
+}
+
+func (e *UpgradeRequiredError) Error() string {
+	return fmt.Sprintf("plan %s does not allow %s", e.Plan, e.Feature)
+}
+
+func (e *UpgradeRequiredError) Is(target error) bool {
+	return target == ErrUpgradeRequired
+}
+
+// EntitlementsUseCase resuelve qué permite el plan vigente del usuario. Execute alimenta al frontend;
+// Check es lo que usan el middleware y los casos de uso antes de crear algo que ocupa cupo.
+type EntitlementsUseCase struct {
+	subscriptionRepo repository.SubscriptionRepository
+	cache            *cache.Cache
+}
+
+func NewEntitlementsUseCase(subscriptionRepo repository.SubscriptionRepository, cacheService *cache.Cache) *EntitlementsUseCase {
+	return &EntitlementsUseCase{subscriptionRepo, cacheService}
+}
+
+func (uc *EntitlementsUseCase) Execute(ctx context.Context, userID int) (*dtos.EntitlementsResponse, error) {
+	current, features, err := uc.resolve(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.EntitlementsResponse{Subscription: current, Features: features}, nil
+}
+
+// Check devuelve un *UpgradeRequiredError si usage, lo que quedaría usado después de la operación,
+// no entra en el plan del usuario
+func (uc *EntitlementsUseCase) Check(ctx context.Context, userID int, feature string, usage int) error {
+	current, features, err := uc.resolve(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	limit, ok := features.Allows(feature, usage)
+	if ok {
+		return nil
+	}
+
+	plan := entities.PlanTierFree
+	if current != nil {
+		plan = current.PlanName
+	}
+	return &UpgradeRequiredError{Feature: feature, Limit: limit, Usage: usage, Plan: plan}
+}
+
+// PlanTier devuelve el nivel (free, basic o premium) del plan vigente del usuario
+func (uc *EntitlementsUseCase) PlanTier(ctx context.Context, userID int) (string, error) {
+	cacheKey := planTierCacheKey(userID)
+	if cached, err := uc.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		return cached, nil
+	}
+
+	current, err := uc.subscriptionRepo.FindActive(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to find subscription: %w", err)
+	}
+	tier := entities.PlanTierFree
+	if current != nil {
+		tier = entities.PlanTier(current.PlanName)
+	}
+
+	_ = uc.cache.Set(ctx, cacheKey, tier, planTierCacheTTL)
+	return tier, nil
+}
+
+func planTierCacheKey(userID int) string {
+	return fmt.Sprintf("plan_tier:user:%d", userID)
+}
+
+// resolve devuelve la suscripción activa (nil si no hay) y sus límites
+func (uc *EntitlementsUseCase) resolve(ctx context.Context, userID int) (*entities.Subscription, *entities.PlanFeatures, error) {
+	current, err := uc.subscriptionRepo.FindActive(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find subscription: %w", err)
+	}
+	if current == nil {
+		return nil, entities.FreeFeatures(), nil
+	}
+
+	features, err := uc.planFeatures(ctx, current.PlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, features, nil
+}
+
+// planFeatures cachea los límites de cada plan en Redis, cambian solo por migración
+func (uc *EntitlementsUseCase) planFeatures(ctx context.Context, planID int) (*entities.PlanFeatures, error) {
+	cacheKey := fmt.Sprintf("plan_features:plan:%d", planID)
+
+	cached, err := uc.cache.Get(ctx, cacheKey)
+	if err == nil && cached != "" {
+		var features entities.PlanFeatures
+		if err := json.Unmarshal([]byte(cached), &features); err == nil {
+			return &features, nil
+		}
+	}
+
+	features, err := uc.subscriptionRepo.FindPlanFeatures(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	// Un plan sin límites cargados no puede dar más que la versión gratuita
+	if features == nil {
+		features = entities.FreeFeatures()
+	}
+
+	if data, err := json.Marshal(features); err == nil {
+		_ = uc.cache.Set(ctx, cacheKey, string(data), planFeaturesCacheTTL)
+	}
+	return features, nil
+}
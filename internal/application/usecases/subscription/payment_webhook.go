@@ -0,0 +1,160 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/interfaces/repository"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// HandlePaymentWebhookUseCase aplica los webhooks de la pasarela: el pago aprobado activa la
+// suscripción pendiente y el fallido o vencido la cancela. La pasarela reintenta hasta recibir un 2xx,
+// así que un evento ya registrado se ignora y un error devuelto hace que vuelva a llegar.
+type HandlePaymentWebhookUseCase struct {
+	subscriptionManager
+	paymentEventRepo repository.PaymentEventRepository
+	gateway          payment.PaymentGateway
+}
+
+func NewHandlePaymentWebhookUseCase(
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	paymentEventRepo repository.PaymentEventRepository,
+	gateway payment.PaymentGateway,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *HandlePaymentWebhookUseCase {
+	return &HandlePaymentWebhookUseCase{
+		subscriptionManager: subscriptionManager{userRepo, subscriptionRepo, cacheService, emailService, logger},
+		paymentEventRepo:    paymentEventRepo,
+		gateway:             gateway,
+	}
+}
+
+func (uc *HandlePaymentWebhookUseCase) Execute(ctx context.Context, header http.Header, payload []byte) error {
+	event, err := uc.gateway.ParseWebhook(header, payload)
+	if err != nil {
+		return err
+	}
+
+	provider := uc.gateway.Name()
+	processed, err := uc.paymentEventRepo.Exists(ctx, provider, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check payment event: %w", err)
+	}
+	if processed {
+		uc.logger.Info().
+			Str("provider", provider).
+			Str("event_id", event.ID).
+			Msg("Payment event already processed")
+		return nil
+	}
+
+	record := &entities.PaymentEvent{Provider: provider, EventID: event.ID, Type: event.RawType}
+	if event.Type != payment.EventIgnored {
+		sub, err := uc.subscriptionRepo.FindByCheckout(ctx, provider, event.CheckoutID)
+		if err != nil {
+			return err
+		}
+		if sub == nil {
+			uc.logger.Warn().
+				Str("provider", provider).
+				Str("event_id", event.ID).
+				Str("checkout_id", event.CheckoutID).
+				Str("reference", event.Reference).
+				Msg("Payment event for unknown checkout")
+		} else {
+			record.SubscriptionID = sub.ID
+			if err := uc.apply(ctx, event, sub); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Se registra después de aplicarlo: si algo falla, el reintento de la pasarela lo vuelve a procesar.
+	// Los cambios de estado solo valen desde pending, así que repetirlos no tiene efecto.
+	if err := uc.paymentEventRepo.Create(ctx, record); err != nil {
+		return fmt.Errorf("failed to save payment event: %w", err)
+	}
+	return nil
+}
+
+func (uc *HandlePaymentWebhookUseCase) apply(ctx context.Context, event *payment.Event, sub *entities.Subscription) error {
+	switch event.Type {
+	case payment.EventCheckoutCompleted:
+		plan, err := uc.subscriptionRepo.FindPlanByID(ctx, sub.PlanID)
+		if err != nil {
+			return err
+		}
+		if plan == nil {
+			return ErrPlanNotFound
+		}
+
+		// El período pago arranca cuando se confirma el cobro, no cuando se abrió el checkout
+		now := time.Now()
+		sub.StartedAt = now
+		sub.ExpiresAt = now.AddDate(0, 0, plan.DurationDays)
+		ok, err := uc.subscriptionRepo.Activate(ctx, sub)
+		if err != nil {
+			return fmt.Errorf("failed to activate subscription: %w", err)
+		}
+		if !ok {
+			// Un pago aprobado después de que el checkout venció hay que devolverlo a mano
+			uc.logger.Error().
+				Int("user_id", sub.UserID).
+				Int("subscription_id", sub.ID).
+				Str("event_id", event.ID).
+				Str("status", sub.Status).
+				Msg("Payment received for a subscription that is no longer pending")
+			return nil
+		}
+
+		uc.logger.Info().
+			Int("user_id", sub.UserID).
+			Int("subscription_id", sub.ID).
+			Int("plan_id", plan.ID).
+			Time("expires_at", sub.ExpiresAt).
+			Msg("Subscription activated by payment")
+
+		_, err = uc.finish(ctx, sub.UserID,
+			"Recibimos tu pago",
+			fmt.Sprintf("Tu cuenta ya tiene el plan %s, vigente hasta el %s. ¡Gracias!", plan.Name, sub.ExpiresAt.Format("02/01/2006")),
+		)
+		return err
+
+	case payment.EventCheckoutFailed, payment.EventCheckoutExpired:
+		ok, err := uc.subscriptionRepo.CancelPending(ctx, sub)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		uc.logger.Info().
+			Int("user_id", sub.UserID).
+			Int("subscription_id", sub.ID).
+			Str("event", event.Type).
+			Msg("Pending subscription canceled")
+
+		// Un checkout abandonado no merece un email, un pago rechazado sí
+		if event.Type == payment.EventCheckoutExpired {
+			_ = uc.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", sub.UserID))
+			return nil
+		}
+		_, err = uc.finish(ctx, sub.UserID,
+			"No pudimos cobrar tu plan",
+			fmt.Sprintf("El pago del plan %s fue rechazado y tu plan actual no cambió. Podés intentarlo de nuevo desde tu perfil con otro medio de pago.", sub.PlanName),
+		)
+		return err
+	}
+	return nil
+}
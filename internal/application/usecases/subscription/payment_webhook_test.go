@@ -0,0 +1,343 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/config"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/infrastructure/queue"
+	"luthierSaas/internal/interfaces/repository"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// fakeSubscriptionRepo guarda las suscripciones en memoria, solo implementa lo que usan el webhook y el checkout
+type fakeSubscriptionRepo struct {
+	repository.SubscriptionRepository
+
+	mu            sync.Mutex
+	subscriptions map[int]*entities.Subscription
+	plans         map[int]*entities.SubscriptionPlan
+	activations   int
+}
+
+func (r *fakeSubscriptionRepo) Save(subscription *entities.Subscription) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription.ID = len(r.subscriptions) + 1
+	r.subscriptions[subscription.ID] = subscription
+	return subscription.ID, nil
+}
+
+func (r *fakeSubscriptionRepo) SetCheckout(ctx context.Context, subscriptionID int, provider, checkoutID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[subscriptionID].PaymentProvider = provider
+	r.subscriptions[subscriptionID].CheckoutID = checkoutID
+	return nil
+}
+
+func (r *fakeSubscriptionRepo) FindPending(ctx context.Context, userID int) (*entities.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *entities.Subscription
+	for _, sub := range r.subscriptions {
+		if sub.UserID == userID && sub.Status == entities.SubscriptionStatusPending && (latest == nil || sub.ID > latest.ID) {
+			latest = sub
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *fakeSubscriptionRepo) FindPlanByID(ctx context.Context, planID int) (*entities.SubscriptionPlan, error) {
+	return r.plans[planID], nil
+}
+
+func (r *fakeSubscriptionRepo) FindByCheckout(ctx context.Context, provider, checkoutID string) (*entities.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sub := range r.subscriptions {
+		if sub.PaymentProvider == provider && sub.CheckoutID == checkoutID {
+			copied := *sub
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSubscriptionRepo) FindByUserID(ctx context.Context, userID int) ([]*entities.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var history []*entities.Subscription
+	for _, sub := range r.subscriptions {
+		if sub.UserID == userID {
+			history = append(history, sub)
+		}
+	}
+	return history, nil
+}
+
+func (r *fakeSubscriptionRepo) Activate(ctx context.Context, pending *entities.Subscription) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.subscriptions[pending.ID]
+	if stored.Status != entities.SubscriptionStatusPending {
+		return false, nil
+	}
+	stored.Status = entities.SubscriptionStatusActive
+	stored.StartedAt, stored.ExpiresAt = pending.StartedAt, pending.ExpiresAt
+	r.activations++
+	return true, nil
+}
+
+func (r *fakeSubscriptionRepo) CancelPending(ctx context.Context, pending *entities.Subscription) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.subscriptions[pending.ID]
+	if stored.Status != entities.SubscriptionStatusPending {
+		return false, nil
+	}
+	stored.Status = entities.SubscriptionStatusCanceled
+	return true, nil
+}
+
+func (r *fakeSubscriptionRepo) status(id int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.subscriptions[id].Status
+}
+
+type fakePaymentEventRepo struct {
+	mu     sync.Mutex
+	events []*entities.PaymentEvent
+}
+
+func (r *fakePaymentEventRepo) Exists(ctx context.Context, provider, eventID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.events {
+		if event.Provider == provider && event.EventID == eventID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakePaymentEventRepo) Create(ctx context.Context, event *entities.PaymentEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	user *entities.User
+}
+
+func (r *fakeUserRepo) FindByID(id int) (*entities.User, error) {
+	if r.user.ID != id {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+// webhookTest arma el caso de uso detrás de un endpoint httptest al que la pasarela simulada le
+// entrega los webhooks firmados, como en desarrollo
+type webhookTest struct {
+	gateway       *payment.FakeGateway
+	subscriptions *fakeSubscriptionRepo
+	events        *fakePaymentEventRepo
+	logger        *zerolog.Logger
+	checkoutID    string
+}
+
+const testPendingID = 10
+
+func newWebhookTest(t *testing.T) *webhookTest {
+	t.Helper()
+	t.Setenv("RESEND_API_KEY", "test")
+
+	// Sin Redis: el caché y la cola de emails fallan en el acto y el caso de uso lo tolera
+	offline := redis.NewClient(&redis.Options{
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("redis is offline")
+		},
+	})
+	t.Cleanup(func() { _ = offline.Close() })
+	logger := zerolog.Nop()
+
+	wt := &webhookTest{
+		subscriptions: &fakeSubscriptionRepo{
+			subscriptions: map[int]*entities.Subscription{},
+			plans:         map[int]*entities.SubscriptionPlan{2: {ID: 2, Name: "Basic", Price: 10, DurationDays: 30}},
+		},
+		events: &fakePaymentEventRepo{},
+		logger: &logger,
+	}
+
+	var uc *HandlePaymentWebhookUseCase
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		if err := uc.Execute(r.Context(), r.Header, payload); err != nil {
+			t.Errorf("Execute: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	gateway, err := payment.NewFakeGateway(config.PaymentConfig{
+		Provider:      "fake",
+		AllowFake:     true,
+		WebhookSecret: "whsec_test",
+		WebhookURL:    server.URL + "/v1/payments/webhook",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.gateway = gateway
+
+	uc = NewHandlePaymentWebhookUseCase(
+		&fakeUserRepo{user: &entities.User{ID: 7, Email: "luthier@example.com"}},
+		wt.subscriptions,
+		wt.events,
+		gateway,
+		cache.NewCache(offline),
+		email.NewEmailService(queue.NewQueue(offline, "emails")),
+		&logger,
+	)
+
+	session, err := gateway.CreateCheckout(context.Background(), payment.CheckoutRequest{Reference: strconv.Itoa(testPendingID), UserID: 7, Amount: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.checkoutID = session.ID
+	wt.subscriptions.subscriptions[testPendingID] = &entities.Subscription{
+		ID:              testPendingID,
+		UserID:          7,
+		PlanID:          2,
+		PlanName:        "Basic",
+		Status:          entities.SubscriptionStatusPending,
+		PaymentProvider: gateway.Name(),
+		CheckoutID:      session.ID,
+	}
+	return wt
+}
+
+func TestHandlePaymentWebhook(t *testing.T) {
+	tests := []struct {
+		outcome    string
+		wantStatus string
+	}{
+		{outcome: payment.FakeOutcomePaid, wantStatus: entities.SubscriptionStatusActive},
+		{outcome: payment.FakeOutcomeFailed, wantStatus: entities.SubscriptionStatusCanceled},
+		{outcome: payment.FakeOutcomeExpired, wantStatus: entities.SubscriptionStatusCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			wt := newWebhookTest(t)
+
+			eventID, err := wt.gateway.Complete(context.Background(), wt.checkoutID, tt.outcome, "")
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+
+			if got := wt.subscriptions.status(testPendingID); got != tt.wantStatus {
+				t.Errorf("subscription status %q, want %q", got, tt.wantStatus)
+			}
+			if len(wt.events.events) != 1 || wt.events.events[0].EventID != eventID || wt.events.events[0].SubscriptionID != testPendingID {
+				t.Errorf("recorded events %+v, want %q for subscription %d", wt.events.events, eventID, testPendingID)
+			}
+		})
+	}
+}
+
+func TestHandlePaymentWebhookPaidSetsPeriod(t *testing.T) {
+	wt := newWebhookTest(t)
+	before := time.Now()
+
+	if _, err := wt.gateway.Complete(context.Background(), wt.checkoutID, payment.FakeOutcomePaid, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := wt.subscriptions.subscriptions[testPendingID]
+	if sub.StartedAt.Before(before) || !sub.ExpiresAt.Equal(sub.StartedAt.AddDate(0, 0, 30)) {
+		t.Errorf("period %v - %v, want 30 days from the payment", sub.StartedAt, sub.ExpiresAt)
+	}
+}
+
+func TestHandlePaymentWebhookDuplicate(t *testing.T) {
+	wt := newWebhookTest(t)
+
+	eventID, err := wt.gateway.Complete(context.Background(), wt.checkoutID, payment.FakeOutcomePaid, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La pasarela reintenta el mismo evento, por ejemplo porque no recibió la respuesta
+	if _, err := wt.gateway.Complete(context.Background(), wt.checkoutID, payment.FakeOutcomePaid, eventID); err != nil {
+		t.Fatal(err)
+	}
+
+	if wt.subscriptions.activations != 1 {
+		t.Errorf("subscription activated %d times, want 1", wt.subscriptions.activations)
+	}
+	if len(wt.events.events) != 1 {
+		t.Errorf("recorded %d events, want 1", len(wt.events.events))
+	}
+}
+
+func TestHandlePaymentWebhookAfterCancel(t *testing.T) {
+	wt := newWebhookTest(t)
+
+	if _, err := wt.gateway.Complete(context.Background(), wt.checkoutID, payment.FakeOutcomeExpired, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Un pago que llega con el checkout ya vencido no reactiva la suscripción
+	if _, err := wt.gateway.Complete(context.Background(), wt.checkoutID, payment.FakeOutcomePaid, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := wt.subscriptions.status(testPendingID); got != entities.SubscriptionStatusCanceled {
+		t.Errorf("subscription status %q, want %q", got, entities.SubscriptionStatusCanceled)
+	}
+	if len(wt.events.events) != 2 {
+		t.Errorf("recorded %d events, want 2", len(wt.events.events))
+	}
+}
+
+func TestHandlePaymentWebhookRejectsBadSignature(t *testing.T) {
+	wt := newWebhookTest(t)
+	logger := zerolog.Nop()
+	uc := NewHandlePaymentWebhookUseCase(nil, wt.subscriptions, wt.events, wt.gateway, nil, nil, &logger)
+
+	header := http.Header{}
+	header.Set("Stripe-Signature", "t="+strconv.FormatInt(time.Now().Unix(), 10)+",v1=deadbeef")
+	err := uc.Execute(context.Background(), header, []byte(`{"id":"evt_forged","type":"checkout.session.completed"}`))
+	if !errors.Is(err, payment.ErrInvalidSignature) {
+		t.Fatalf("got %v, want %v", err, payment.ErrInvalidSignature)
+	}
+	if len(wt.events.events) != 0 {
+		t.Errorf("forged webhook recorded %d events", len(wt.events.events))
+	}
+}
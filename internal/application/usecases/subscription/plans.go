@@ -0,0 +1,79 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type ListPlansUseCase struct {
+	subscriptionRepo repository.SubscriptionRepository
+}
+
+func NewListPlansUseCase(subscriptionRepo repository.SubscriptionRepository) *ListPlansUseCase {
+	return &ListPlansUseCase{subscriptionRepo}
+}
+
+func (uc *ListPlansUseCase) Execute(ctx context.Context) ([]dtos.PlanResponse, error) {
+	plans, err := uc.subscriptionRepo.FindPlans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find plans: %w", err)
+	}
+
+	result := make([]dtos.PlanResponse, 0, len(plans))
+	for _, plan := range plans {
+		features, err := uc.subscriptionRepo.FindPlanFeatures(ctx, plan.ID)
+		if err != nil {
+			return nil, err
+		}
+		if features == nil {
+			features = entities.FreeFeatures()
+		}
+
+		result = append(result, dtos.PlanResponse{
+			ID:           plan.ID,
+			Name:         plan.Name,
+			Description:  plan.Description,
+			Price:        plan.Price,
+			DurationDays: plan.DurationDays,
+			Trial:        plan.Name == entities.FreeTierPlanName,
+			Features:     features,
+		})
+	}
+	return result, nil
+}
+
+type GetSubscriptionUseCase struct {
+	subscriptionRepo repository.SubscriptionRepository
+}
+
+func NewGetSubscriptionUseCase(subscriptionRepo repository.SubscriptionRepository) *GetSubscriptionUseCase {
+	return &GetSubscriptionUseCase{subscriptionRepo}
+}
+
+func (uc *GetSubscriptionUseCase) Execute(ctx context.Context, userID int) (*dtos.SubscriptionResponse, error) {
+	return subscriptionOverview(ctx, uc.subscriptionRepo, userID)
+}
+
+// subscriptionOverview arma la respuesta que devuelven la consulta y todos los cambios de plan
+func subscriptionOverview(ctx context.Context, subscriptionRepo repository.SubscriptionRepository, userID int) (*dtos.SubscriptionResponse, error) {
+	history, err := subscriptionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscriptions: %w", err)
+	}
+
+	result := &dtos.SubscriptionResponse{History: history}
+	for _, sub := range history {
+		switch {
+		case sub.Status == entities.SubscriptionStatusActive && result.Current == nil:
+			result.Current = sub
+		case sub.Status == entities.SubscriptionStatusScheduled && result.Scheduled == nil:
+			result.Scheduled = sub
+		case sub.Status == entities.SubscriptionStatusPending && result.Pending == nil:
+			result.Pending = sub
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,228 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Cuánto antes del vencimiento de la prueba gratuita se avisa
+const trialReminderLead = 3 * 24 * time.Hour
+
+// ProcessSubscriptionsUseCase es el ciclo de vida de las suscripciones, lo corre el scheduler:
+//   - la prueba gratuita avisa 3 días antes de vencer y se cierra al vencer;
+//   - un plan pago marcado para cancelar se cierra al vencer;
+//   - un plan pago que se renueva abre al vencer el checkout del período siguiente (del plan
+//     programado, si había un cambio) y sigue activo durante el período de gracia; si no se paga
+//     en ese plazo se cierra. El pago tardío igual activa el plan, por el webhook.
+//
+// Sin suscripción activa la cuenta queda con los límites del plan gratuito.
+type ProcessSubscriptionsUseCase struct {
+	subscriptionManager
+	checkouts    *checkoutOpener
+	gracePeriod  time.Duration
+	appClientURL string
+}
+
+func NewProcessSubscriptionsUseCase(
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	gateway payment.PaymentGateway,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+	gracePeriod time.Duration,
+) *ProcessSubscriptionsUseCase {
+	return &ProcessSubscriptionsUseCase{
+		subscriptionManager: subscriptionManager{userRepo, subscriptionRepo, cacheService, emailService, logger},
+		checkouts:           &checkoutOpener{subscriptionRepo, gateway, logger, appClientURL},
+		gracePeriod:         gracePeriod,
+		appClientURL:        appClientURL,
+	}
+}
+
+func (uc *ProcessSubscriptionsUseCase) Execute(ctx context.Context) error {
+	now := time.Now()
+	subscriptions, err := uc.subscriptionRepo.FindActiveExpiringBefore(ctx, now.Add(trialReminderLead))
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscriptions {
+		var err error
+		if sub.PlanName == entities.FreeTierPlanName {
+			err = uc.processTrial(ctx, sub, now)
+		} else {
+			err = uc.processPaid(ctx, sub, now)
+		}
+		// Una suscripción con problemas no frena al resto, se reintenta en la próxima ejecución
+		if err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", sub.UserID).
+				Int("subscription_id", sub.ID).
+				Msg("Failed to process subscription")
+		}
+	}
+	return nil
+}
+
+func (uc *ProcessSubscriptionsUseCase) processTrial(ctx context.Context, sub *entities.Subscription, now time.Time) error {
+	if !now.Before(sub.ExpiresAt) {
+		return uc.expire(ctx, sub,
+			"Tu prueba gratuita terminó",
+			fmt.Sprintf("Terminó tu período de prueba y tu cuenta quedó con las funciones limitadas de la versión gratuita. Tus datos siguen guardados: elegí un plan en %s/billing para volver a usar todas las funciones.", uc.appClientURL),
+		)
+	}
+
+	if !sub.ReminderSentAt.IsZero() {
+		return nil
+	}
+	ok, err := uc.subscriptionRepo.MarkReminderSent(ctx, sub.ID)
+	if err != nil || !ok {
+		return err
+	}
+
+	user, err := uc.userRepo.FindByID(sub.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil || user.Deleted {
+		return nil
+	}
+
+	uc.logger.Info().
+		Int("user_id", sub.UserID).
+		Int("subscription_id", sub.ID).
+		Time("expires_at", sub.ExpiresAt).
+		Msg("Trial ending reminder sent")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Tu prueba gratuita termina en 3 días",
+		Body: fmt.Sprintf(
+			"Tu prueba gratuita vence el %s. Para no perder ninguna función elegí un plan en <a href=\"%s/billing\">%s/billing</a>.",
+			sub.ExpiresAt.Format("02/01/2006"), uc.appClientURL, uc.appClientURL,
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", sub.UserID).
+			Msg("Failed to send trial reminder email")
+	}
+	return nil
+}
+
+func (uc *ProcessSubscriptionsUseCase) processPaid(ctx context.Context, sub *entities.Subscription, now time.Time) error {
+	if now.Before(sub.ExpiresAt) {
+		return nil
+	}
+
+	scheduled, err := uc.subscriptionRepo.FindScheduled(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+	if sub.CancelAtPeriodEnd && scheduled == nil {
+		return uc.expire(ctx, sub,
+			"Tu plan terminó",
+			fmt.Sprintf("Tu plan %s terminó como pediste y tu cuenta pasó a la versión gratuita. Tus datos siguen guardados: podés elegir un plan cuando quieras en %s/billing.", sub.PlanName, uc.appClientURL),
+		)
+	}
+
+	if sub.RenewalRequestedAt.IsZero() {
+		return uc.requestRenewal(ctx, sub, scheduled, now)
+	}
+
+	if now.Before(sub.ExpiresAt.Add(uc.gracePeriod)) {
+		return nil
+	}
+	return uc.expire(ctx, sub,
+		"Tu plan venció",
+		fmt.Sprintf("No recibimos el pago de la renovación del plan %s y tu cuenta pasó a la versión gratuita. Tus datos siguen guardados: si pagás el link que te mandamos o elegís un plan en %s/billing, se reactiva en el momento.", sub.PlanName, uc.appClientURL),
+	)
+}
+
+// requestRenewal abre el checkout del período siguiente y le manda el link al usuario
+func (uc *ProcessSubscriptionsUseCase) requestRenewal(ctx context.Context, sub, scheduled *entities.Subscription, now time.Time) error {
+	planID := sub.PlanID
+	if scheduled != nil {
+		planID = scheduled.PlanID
+	}
+	plan, err := uc.subscriptionRepo.FindPlanByID(ctx, planID)
+	if err != nil {
+		return err
+	}
+	if plan == nil {
+		return ErrPlanNotFound
+	}
+
+	user, err := uc.userRepo.FindByID(sub.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	// Una cuenta borrada no se renueva, el plan se cierra sin avisos
+	if user == nil || user.Deleted {
+		_, err := uc.subscriptionRepo.Cancel(ctx, sub)
+		return err
+	}
+
+	ok, err := uc.subscriptionRepo.MarkRenewalRequested(ctx, sub.ID)
+	if err != nil || !ok {
+		return err
+	}
+	_, session, err := uc.checkouts.open(ctx, user, plan, now)
+	if err != nil {
+		// Se libera la marca para que la próxima ejecución vuelva a intentarlo
+		_ = uc.subscriptionRepo.ClearRenewalRequested(ctx, sub.ID)
+		return err
+	}
+
+	graceEndsAt := sub.ExpiresAt.Add(uc.gracePeriod)
+	uc.logger.Info().
+		Int("user_id", sub.UserID).
+		Int("subscription_id", sub.ID).
+		Int("plan_id", plan.ID).
+		Time("grace_ends_at", graceEndsAt).
+		Msg("Subscription renewal requested")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Renová tu plan %s", plan.Name),
+		Body: fmt.Sprintf(
+			"Tu plan %s venció el %s. Para renovarlo por %d días más pagá en <a href=\"%s\">%s</a>. Tu cuenta sigue funcionando normalmente hasta el %s.",
+			plan.Name, sub.ExpiresAt.Format("02/01/2006"), plan.DurationDays, session.URL, session.URL, graceEndsAt.Format("02/01/2006"),
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", sub.UserID).
+			Msg("Failed to send renewal email")
+	}
+	return nil
+}
+
+func (uc *ProcessSubscriptionsUseCase) expire(ctx context.Context, sub *entities.Subscription, subject, body string) error {
+	ok, err := uc.subscriptionRepo.Cancel(ctx, sub)
+	if err != nil || !ok {
+		return err
+	}
+
+	uc.logger.Info().
+		Int("user_id", sub.UserID).
+		Int("subscription_id", sub.ID).
+		Str("plan", sub.PlanName).
+		Msg("Subscription expired")
+
+	_, err = uc.finish(ctx, sub.UserID, subject, body)
+	return err
+}
@@ -0,0 +1,45 @@
+package subscription
+
+import (
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type SubscriptionUseCases struct {
+	ListPlans            *ListPlansUseCase
+	GetSubscription      *GetSubscriptionUseCase
+	ChangePlan           *ChangePlanUseCase
+	CancelSubscription   *CancelSubscriptionUseCase
+	ResumeSubscription   *ResumeSubscriptionUseCase
+	HandleWebhook        *HandlePaymentWebhookUseCase
+	ProcessSubscriptions *ProcessSubscriptionsUseCase
+	Entitlements         *EntitlementsUseCase
+}
+
+func NewSubscriptionUseCases(
+	userRepo repository.UserRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	paymentEventRepo repository.PaymentEventRepository,
+	gateway payment.PaymentGateway,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+	gracePeriod time.Duration) *SubscriptionUseCases {
+
+	return &SubscriptionUseCases{
+		ListPlans:            NewListPlansUseCase(subscriptionRepo),
+		GetSubscription:      NewGetSubscriptionUseCase(subscriptionRepo),
+		ChangePlan:           NewChangePlanUseCase(userRepo, subscriptionRepo, gateway, cacheService, emailService, logger, appClientURL),
+		CancelSubscription:   NewCancelSubscriptionUseCase(userRepo, subscriptionRepo, cacheService, emailService, logger),
+		ResumeSubscription:   NewResumeSubscriptionUseCase(userRepo, subscriptionRepo, cacheService, emailService, logger),
+		HandleWebhook:        NewHandlePaymentWebhookUseCase(userRepo, subscriptionRepo, paymentEventRepo, gateway, cacheService, emailService, logger),
+		ProcessSubscriptions: NewProcessSubscriptionsUseCase(userRepo, subscriptionRepo, gateway, cacheService, emailService, logger, appClientURL, gracePeriod),
+		Entitlements:         NewEntitlementsUseCase(subscriptionRepo, cacheService),
+	}
+}
@@ -0,0 +1,175 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Los tokens vencidos siguen contando hasta que el usuario los borra
+const maxAccessTokensPerUser = 20
+
+var (
+	ErrAccessTokenNotFound     = errors.New("access token not found")
+	ErrInvalidAccessTokenScope = errors.New("invalid access token scope")
+	ErrTooManyAccessTokens     = errors.New("access token limit reached, revoke an existing token first")
+)
+
+func newAccessTokenResponse(token *entities.PersonalAccessToken) dtos.AccessTokenResponse {
+	return dtos.AccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+type CreateAccessTokenUseCase struct {
+	userRepo     repository.UserRepository
+	tokenRepo    repository.PersonalAccessTokenRepository
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+}
+
+func NewCreateAccessTokenUseCase(
+	userRepo repository.UserRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *CreateAccessTokenUseCase {
+	return &CreateAccessTokenUseCase{userRepo, tokenRepo, emailService, logger}
+}
+
+func (uc *CreateAccessTokenUseCase) Execute(ctx context.Context, userID int, input dtos.CreateAccessTokenInput) (*dtos.CreateAccessTokenResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	scopes := make([]string, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(entities.AccessTokenScopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAccessTokenScope, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	count, err := uc.tokenRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count access tokens: %w", err)
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, ErrTooManyAccessTokens
+	}
+
+	plain, prefix, err := security.GenerateAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	tokenHash, err := security.HashToken(plain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash access token: %w", err)
+	}
+
+	token := &entities.PersonalAccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(input.Name),
+		TokenHash: tokenHash,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().AddDate(0, 0, input.ExpiresInDays),
+		CreatedAt: time.Now(),
+	}
+	if err := uc.tokenRepo.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to save access token: %w", err)
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("token_id", token.ID).
+		Strs("scopes", scopes).
+		Msg("Personal access token created")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Se creó un token de acceso",
+		Body: fmt.Sprintf(
+			"Se creó el token de acceso \"%s\" con permisos %s, válido hasta el %s. Si no fuiste vos, revocalo desde tu perfil y cambiá tu contraseña.",
+			token.Name, strings.Join(scopes, ", "), token.ExpiresAt.Format("02/01/2006"),
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to send access token created email")
+	}
+
+	return &dtos.CreateAccessTokenResponse{
+		AccessTokenResponse: newAccessTokenResponse(token),
+		Token:               plain,
+	}, nil
+}
+
+type ListAccessTokensUseCase struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+}
+
+func NewListAccessTokensUseCase(tokenRepo repository.PersonalAccessTokenRepository) *ListAccessTokensUseCase {
+	return &ListAccessTokensUseCase{tokenRepo}
+}
+
+func (uc *ListAccessTokensUseCase) Execute(ctx context.Context, userID int) ([]dtos.AccessTokenResponse, error) {
+	tokens, err := uc.tokenRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find access tokens: %w", err)
+	}
+
+	result := make([]dtos.AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, newAccessTokenResponse(token))
+	}
+	return result, nil
+}
+
+type RevokeAccessTokenUseCase struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+	logger    *zerolog.Logger
+}
+
+func NewRevokeAccessTokenUseCase(tokenRepo repository.PersonalAccessTokenRepository, logger *zerolog.Logger) *RevokeAccessTokenUseCase {
+	return &RevokeAccessTokenUseCase{tokenRepo, logger}
+}
+
+func (uc *RevokeAccessTokenUseCase) Execute(ctx context.Context, userID, tokenID int) error {
+	deleted, err := uc.tokenRepo.Delete(ctx, userID, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	if !deleted {
+		return ErrAccessTokenNotFound
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("token_id", tokenID).
+		Msg("Personal access token revoked")
+	return nil
+}
@@ -0,0 +1,274 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Las cuentas sin contraseña tienen que haber iniciado sesión hace menos de esto para borrarse
+const accountDeletionReauthWindow = 10 * time.Minute
+
+var ErrAccountAlreadyDeleted = errors.New("account already deleted")
+
+// DeleteAccountUseCase es el borrado pedido por el propio usuario: la cuenta queda marcada como
+// borrada y se puede recuperar iniciando sesión hasta que vence el plazo de gracia.
+type DeleteAccountUseCase struct {
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	tokenRepo    repository.PersonalAccessTokenRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+	gracePeriod  time.Duration
+}
+
+func NewDeleteAccountUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	cache *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	gracePeriod time.Duration,
+) *DeleteAccountUseCase {
+	return &DeleteAccountUseCase{userRepo, sessionRepo, tokenRepo, cache, emailService, logger, gracePeriod}
+}
+
+func (uc *DeleteAccountUseCase) Execute(ctx context.Context, userID, sessionID int, input dtos.DeleteAccountInput) (*dtos.DeleteAccountResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Deleted {
+		return nil, ErrAccountAlreadyDeleted
+	}
+
+	if user.Password != "" {
+		if !security.ComparePasswords(user.Password, input.Password) {
+			return nil, ErrInvalidCurrentPassword
+		}
+	} else {
+		recent, err := recentlyAuthenticated(ctx, uc.sessionRepo, userID, sessionID, accountDeletionReauthWindow)
+		if err != nil {
+			return nil, err
+		}
+		if !recent {
+			return nil, ErrReauthRequired
+		}
+	}
+
+	purgeAfter := time.Now().Add(uc.gracePeriod)
+	if err := uc.userRepo.ScheduleDeletion(userID, purgeAfter); err != nil {
+		return nil, fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	if err := uc.sessionRepo.InvalidateByUserID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+	// Los tokens no se recuperan con la cuenta, el dueño los vuelve a crear si los necesita
+	if err := uc.tokenRepo.DeleteByUserID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	clearEmailCaches(ctx, uc.cache, userID)
+	// Un cambio de email pendiente no tiene sentido en una cuenta borrada
+	_ = uc.cache.Delete(ctx, emailChangeKey(userID))
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Str("email", user.Email).
+		Time("purge_after", purgeAfter).
+		Msg("Account deleted by its owner")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Eliminamos tu cuenta",
+		Body: fmt.Sprintf(
+			"Tu cuenta quedó eliminada y cerramos todas tus sesiones. Tus datos se borran definitivamente el %s. Si cambiás de idea, iniciá sesión antes de esa fecha y la recuperás tal como estaba.",
+			purgeAfter.Format("02/01/2006"),
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Str("email", user.Email).
+			Msg("Failed to send account deleted email")
+	}
+
+	return &dtos.DeleteAccountResponse{PurgeAfter: purgeAfter}, nil
+}
+
+// PurgeDeletedAccountsUseCase borra definitivamente las cuentas cuyo plazo de recuperación venció.
+// Lo corre el scheduler, las claves foráneas con ON DELETE CASCADE se llevan el resto de los datos.
+type PurgeDeletedAccountsUseCase struct {
+	userRepo     repository.UserRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+}
+
+func NewPurgeDeletedAccountsUseCase(
+	userRepo repository.UserRepository,
+	cache *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *PurgeDeletedAccountsUseCase {
+	return &PurgeDeletedAccountsUseCase{userRepo, cache, emailService, logger}
+}
+
+func (uc *PurgeDeletedAccountsUseCase) Execute(ctx context.Context) error {
+	now := time.Now()
+	userIDs, err := uc.userRepo.FindPendingPurge(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		user, err := uc.userRepo.FindByID(userID)
+		if err != nil || user == nil {
+			continue
+		}
+
+		ok, err := uc.userRepo.Purge(ctx, userID, now)
+		if err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Msg("Failed to purge deleted account")
+			continue
+		}
+		// Otra instancia ya la borró o el usuario la recuperó entre la búsqueda y el DELETE
+		if !ok {
+			continue
+		}
+		purged++
+		clearEmailCaches(ctx, uc.cache, userID, user.Email)
+
+		uc.logger.Info().
+			Int("user_id", userID).
+			Str("email", user.Email).
+			Msg("Deleted account purged")
+
+		emailJob := email.EmailJob{
+			To:      user.Email,
+			Subject: "Tu cuenta fue eliminada definitivamente",
+			Body:    "Venció el plazo para recuperar tu cuenta y borramos todos tus datos. Si querés volver a usar el servicio podés registrarte de nuevo con este email.",
+		}
+		if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+			uc.logger.Error().
+				Err(err).
+				Int("user_id", userID).
+				Str("email", user.Email).
+				Msg("Failed to send account purged email")
+		}
+	}
+
+	if purged > 0 {
+		uc.logger.Info().
+			Int("purged", purged).
+			Msg("Purged deleted accounts")
+	}
+	return nil
+}
+
+// ExportAccountUseCase arma la copia de los datos personales que puede pedir el usuario (GDPR).
+// Cuando existan los datos del taller (instrumentos, clientes, órdenes) se agregan como secciones nuevas.
+type ExportAccountUseCase struct {
+	userRepo         repository.UserRepository
+	sessionRepo      repository.SessionRepository
+	subscriptionRepo repository.SubscriptionRepository
+	identityRepo     repository.UserIdentityRepository
+	passkeyRepo      repository.WebAuthnCredentialRepository
+	tokenRepo        repository.PersonalAccessTokenRepository
+}
+
+func NewExportAccountUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	subscriptionRepo repository.SubscriptionRepository,
+	identityRepo repository.UserIdentityRepository,
+	passkeyRepo repository.WebAuthnCredentialRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+) *ExportAccountUseCase {
+	return &ExportAccountUseCase{userRepo, sessionRepo, subscriptionRepo, identityRepo, passkeyRepo, tokenRepo}
+}
+
+func (uc *ExportAccountUseCase) Execute(ctx context.Context, userID, currentSessionID int) (*dtos.AccountExport, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	// Se exportan todas las sesiones, también las cerradas, porque son parte del historial de accesos
+	sessions, err := uc.sessionRepo.FindHistoryByUserID(ctx, userID, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sessions: %w", err)
+	}
+	subscriptions, err := uc.subscriptionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscriptions: %w", err)
+	}
+	identities, err := uc.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identities: %w", err)
+	}
+	passkeys, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkeys: %w", err)
+	}
+	tokens, err := uc.tokenRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find access tokens: %w", err)
+	}
+
+	export := &dtos.AccountExport{
+		ExportedAt:    time.Now(),
+		Profile:       newProfileResponse(user),
+		Sessions:      make([]dtos.SessionResponse, 0, len(sessions)),
+		Subscriptions: subscriptions,
+		Identities:    make([]dtos.IdentityResponse, 0, len(identities)),
+		Passkeys:      make([]dtos.PasskeyResponse, 0, len(passkeys)),
+		AccessTokens:  make([]dtos.AccessTokenResponse, 0, len(tokens)),
+	}
+
+	for _, session := range sessions {
+		lastActiveAt := session.UpdatedAt
+		if lastActiveAt.IsZero() {
+			lastActiveAt = session.CreatedAt
+		}
+		export.Sessions = append(export.Sessions, dtos.SessionResponse{
+			ID:           session.ID,
+			DeviceInfo:   session.DeviceInfo,
+			CreatedAt:    session.CreatedAt,
+			LastActiveAt: lastActiveAt,
+			ExpiresAt:    session.RefreshExpiresAt,
+			Current:      session.ID == currentSessionID,
+			Impersonated: session.ImpersonatorID != 0,
+		})
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, dtos.IdentityResponse{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.CreatedAt,
+		})
+	}
+	for _, passkey := range passkeys {
+		export.Passkeys = append(export.Passkeys, newPasskeyResponse(passkey))
+	}
+	for _, token := range tokens {
+		export.AccessTokens = append(export.AccessTokens, newAccessTokenResponse(token))
+	}
+
+	return export, nil
+}
@@ -0,0 +1,398 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	emailChangeTTL = 15 * time.Minute
+	// El link de cancelación sigue sirviendo después de confirmar, para recuperar la cuenta si el cambio no fue del dueño
+	emailChangeCancelTTL = 72 * time.Hour
+	// Las cuentas sin contraseña tienen que haber iniciado sesión con su proveedor hace menos de esto
+	emailChangeReauthWindow = 10 * time.Minute
+	maxEmailChangeAttempts  = 5
+)
+
+var (
+	ErrSameEmail                  = errors.New("new email is the same as the current one")
+	ErrEmailInUse                 = errors.New("email already in use")
+	ErrInvalidCurrentPassword     = errors.New("invalid current password")
+	ErrReauthRequired             = errors.New("sign in again to confirm this change")
+	ErrNoPendingEmailChange       = errors.New("no pending email change")
+	ErrInvalidEmailChangeCode     = errors.New("email change code is incorrect")
+	ErrTooManyEmailChangeAttempts = errors.New("too many incorrect codes, request the change again")
+	ErrInvalidEmailChangeToken    = errors.New("invalid or expired cancel link")
+)
+
+// pendingEmailChange es el cambio que espera el código enviado a la dirección nueva
+type pendingEmailChange struct {
+	NewEmail string `json:"new_email"`
+	CodeHash string `json:"code_hash"`
+	// CancelHash permite descartar el link de un pedido anterior cuando se pide otro cambio
+	CancelHash string `json:"cancel_hash"`
+}
+
+// emailChangeCancel es lo que necesita el link enviado a la dirección vieja para deshacer el cambio
+type emailChangeCancel struct {
+	UserID   int    `json:"user_id"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+func emailChangeKey(userID int) string {
+	return fmt.Sprintf("email_change:user:%d", userID)
+}
+
+func emailChangeAttemptsKey(userID int) string {
+	return fmt.Sprintf("email_change:attempts:%d", userID)
+}
+
+func emailChangeCancelKey(tokenHash string) string {
+	return "email_change:cancel:" + tokenHash
+}
+
+func normalizeEmail(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// clearEmailCaches descarta el perfil cacheado y el resultado de check-email de ambas direcciones
+func clearEmailCaches(ctx context.Context, cacheService *cache.Cache, userID int, emails ...string) {
+	_ = cacheService.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+	for _, address := range emails {
+		_ = cacheService.Delete(ctx, fmt.Sprintf("email:check:%s", address))
+	}
+}
+
+type RequestEmailChangeUseCase struct {
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+	appClientURL string
+}
+
+func NewRequestEmailChangeUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	cache *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+	appClientURL string,
+) *RequestEmailChangeUseCase {
+	return &RequestEmailChangeUseCase{userRepo, sessionRepo, cache, emailService, logger, appClientURL}
+}
+
+func (uc *RequestEmailChangeUseCase) Execute(ctx context.Context, userID, sessionID int, input dtos.ChangeEmailInput) (*dtos.ChangeEmailResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	newEmail := normalizeEmail(input.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrSameEmail
+	}
+
+	if user.Password != "" {
+		if !security.ComparePasswords(user.Password, input.Password) {
+			return nil, ErrInvalidCurrentPassword
+		}
+	} else {
+		recent, err := recentlyAuthenticated(ctx, uc.sessionRepo, userID, sessionID, emailChangeReauthWindow)
+		if err != nil {
+			return nil, err
+		}
+		if !recent {
+			return nil, ErrReauthRequired
+		}
+	}
+
+	exists, err := uc.userRepo.EmailExists(newEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if exists {
+		return nil, ErrEmailInUse
+	}
+
+	// Solo el último pedido es válido, el link de cancelación del anterior deja de servir
+	if previous, err := uc.cache.Get(ctx, emailChangeKey(userID)); err == nil {
+		var pending pendingEmailChange
+		if json.Unmarshal([]byte(previous), &pending) == nil {
+			_ = uc.cache.Delete(ctx, emailChangeCancelKey(pending.CancelHash))
+		}
+	}
+
+	code, err := security.GenerateVerificationCode(6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code: %w", err)
+	}
+	cancelToken, err := security.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cancel token: %w", err)
+	}
+	cancelHash, err := security.HashToken(cancelToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash cancel token: %w", err)
+	}
+
+	pending, err := json.Marshal(pendingEmailChange{
+		NewEmail:   newEmail,
+		CodeHash:   security.HashVerificationCode(code),
+		CancelHash: cancelHash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode email change: %w", err)
+	}
+	cancel, err := json.Marshal(emailChangeCancel{
+		UserID:   userID,
+		OldEmail: user.Email,
+		NewEmail: newEmail,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode email change: %w", err)
+	}
+
+	if err := uc.cache.Set(ctx, emailChangeKey(userID), string(pending), emailChangeTTL); err != nil {
+		return nil, fmt.Errorf("failed to store email change: %w", err)
+	}
+	if err := uc.cache.Set(ctx, emailChangeCancelKey(cancelHash), string(cancel), emailChangeCancelTTL); err != nil {
+		return nil, fmt.Errorf("failed to store email change: %w", err)
+	}
+	_ = uc.cache.Delete(ctx, emailChangeAttemptsKey(userID))
+
+	codeEmail := email.EmailJob{
+		To:      newEmail,
+		Subject: "Confirmá tu nuevo email",
+		Body:    fmt.Sprintf("Tu código para confirmar el cambio de email es: %s. Vence en %d minutos.", code, int(emailChangeTTL.Minutes())),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, codeEmail); err != nil {
+		return nil, fmt.Errorf("falló el envío del email de confirmación: %w", err)
+	}
+
+	cancelLink := fmt.Sprintf("%s/account/email-change/cancel?token=%s", uc.appClientURL, url.QueryEscape(cancelToken))
+	noticeEmail := email.EmailJob{
+		To:      user.Email,
+		Subject: "Se pidió cambiar el email de tu cuenta",
+		Body: fmt.Sprintf(
+			"Se pidió cambiar el email de tu cuenta a %s. Si no fuiste vos, cancelalo desde este link: <a href=\"%s\">%s</a>. El link sirve durante %d horas, incluso si el cambio ya se confirmó.",
+			newEmail, cancelLink, cancelLink, int(emailChangeCancelTTL.Hours()),
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, noticeEmail); err != nil {
+		return nil, fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Str("email", user.Email).
+		Str("new_email", newEmail).
+		Msg("Email change requested")
+
+	return &dtos.ChangeEmailResponse{
+		NewEmail:  newEmail,
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}, nil
+}
+
+type ConfirmEmailChangeUseCase struct {
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+}
+
+func NewConfirmEmailChangeUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	cache *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *ConfirmEmailChangeUseCase {
+	return &ConfirmEmailChangeUseCase{userRepo, sessionRepo, cache, emailService, logger}
+}
+
+func (uc *ConfirmEmailChangeUseCase) Execute(ctx context.Context, userID, sessionID int, input dtos.ConfirmEmailChangeInput) error {
+	stored, err := uc.cache.Get(ctx, emailChangeKey(userID))
+	if err != nil {
+		return ErrNoPendingEmailChange
+	}
+	var pending pendingEmailChange
+	if err := json.Unmarshal([]byte(stored), &pending); err != nil {
+		return ErrNoPendingEmailChange
+	}
+
+	codeHash := security.HashVerificationCode(input.Code)
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(pending.CodeHash)) != 1 {
+		attempts, err := uc.cache.Incr(ctx, emailChangeAttemptsKey(userID), emailChangeTTL)
+		if err != nil {
+			return fmt.Errorf("failed to count attempts: %w", err)
+		}
+		if attempts >= maxEmailChangeAttempts {
+			_ = uc.cache.Delete(ctx, emailChangeKey(userID))
+			_ = uc.cache.Delete(ctx, emailChangeAttemptsKey(userID))
+			return ErrTooManyEmailChangeAttempts
+		}
+		return ErrInvalidEmailChangeCode
+	}
+
+	// GetDel consume el pedido, dos confirmaciones simultáneas no lo aplican dos veces
+	if _, err := uc.cache.GetDel(ctx, emailChangeKey(userID)); err != nil {
+		return ErrNoPendingEmailChange
+	}
+	_ = uc.cache.Delete(ctx, emailChangeAttemptsKey(userID))
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	oldEmail := user.Email
+
+	exists, err := uc.userRepo.EmailExists(pending.NewEmail)
+	if err != nil {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+	if exists {
+		return ErrEmailInUse
+	}
+
+	if err := uc.userRepo.UpdateEmail(userID, pending.NewEmail); err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+	clearEmailCaches(ctx, uc.cache, userID, oldEmail, pending.NewEmail)
+
+	// Las demás sesiones se iniciaron con el email anterior, solo queda la que confirmó el cambio
+	sessions, err := activeSessions(ctx, uc.sessionRepo, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			continue
+		}
+		if err := uc.sessionRepo.Invalidate(ctx, session.AccessTokenHash); err != nil {
+			return fmt.Errorf("failed to revoke session %d: %w", session.ID, err)
+		}
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Str("old_email", oldEmail).
+		Str("email", pending.NewEmail).
+		Msg("Email changed")
+
+	emailJob := email.EmailJob{
+		To:      oldEmail,
+		Subject: "Se cambió el email de tu cuenta",
+		Body: fmt.Sprintf(
+			"El email de tu cuenta ahora es %s. Si no fuiste vos, usá el link de cancelación que te enviamos al pedirse el cambio para recuperar tu cuenta.",
+			pending.NewEmail,
+		),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		return fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	return nil
+}
+
+// CancelEmailChangeUseCase atiende el link enviado a la dirección vieja. No requiere sesión porque
+// quien recibe ese link puede haber perdido el acceso a la cuenta.
+type CancelEmailChangeUseCase struct {
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	tokenRepo    repository.PersonalAccessTokenRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+}
+
+func NewCancelEmailChangeUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	cache *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *CancelEmailChangeUseCase {
+	return &CancelEmailChangeUseCase{userRepo, sessionRepo, tokenRepo, cache, emailService, logger}
+}
+
+func (uc *CancelEmailChangeUseCase) Execute(ctx context.Context, token string) error {
+	tokenHash, err := security.HashToken(token)
+	if err != nil {
+		return fmt.Errorf("failed to hash cancel token: %w", err)
+	}
+
+	stored, err := uc.cache.GetDel(ctx, emailChangeCancelKey(tokenHash))
+	if err != nil {
+		return ErrInvalidEmailChangeToken
+	}
+	var cancel emailChangeCancel
+	if err := json.Unmarshal([]byte(stored), &cancel); err != nil {
+		return ErrInvalidEmailChangeToken
+	}
+
+	_ = uc.cache.Delete(ctx, emailChangeKey(cancel.UserID))
+	_ = uc.cache.Delete(ctx, emailChangeAttemptsKey(cancel.UserID))
+
+	user, err := uc.userRepo.FindByID(cancel.UserID)
+	if err != nil || user == nil {
+		return ErrInvalidEmailChangeToken
+	}
+
+	// Si todavía no se confirmó alcanza con descartar el pedido
+	if !strings.EqualFold(user.Email, cancel.NewEmail) {
+		uc.logger.Info().
+			Int("user_id", user.ID).
+			Str("new_email", cancel.NewEmail).
+			Msg("Pending email change canceled")
+		return nil
+	}
+
+	if err := uc.userRepo.UpdateEmail(user.ID, cancel.OldEmail); err != nil {
+		return fmt.Errorf("failed to restore email: %w", err)
+	}
+	clearEmailCaches(ctx, uc.cache, user.ID, cancel.OldEmail, cancel.NewEmail)
+
+	// Quien hizo el cambio puede seguir teniendo sesiones abiertas o tokens personales
+	if err := uc.sessionRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	uc.logger.Warn().
+		Int("user_id", user.ID).
+		Str("email", cancel.OldEmail).
+		Str("reverted_email", cancel.NewEmail).
+		Msg("Email change reverted from cancel link")
+
+	emailJob := email.EmailJob{
+		To:      cancel.OldEmail,
+		Subject: "Recuperamos el email de tu cuenta",
+		Body:    "Deshicimos el cambio de email y cerramos todas las sesiones. Te recomendamos restablecer tu contraseña y revisar los métodos de inicio de sesión vinculados.",
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		return fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	return nil
+}
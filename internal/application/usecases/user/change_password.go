@@ -14,12 +14,13 @@ import (
 type ChangePasswordUseCase struct {
 	userRepo repository.UserRepository
 	sessionRepo repository.SessionRepository
+	tokenRepo repository.PersonalAccessTokenRepository
 	cache    *cache.Cache
 	emailService *email.EmailService
 }
 
-func NewChangePasswordUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, cache *cache.Cache, emailService *email.EmailService) *ChangePasswordUseCase {
-	return &ChangePasswordUseCase{userRepo, sessionRepo, cache, emailService}
+func NewChangePasswordUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenRepo repository.PersonalAccessTokenRepository, cache *cache.Cache, emailService *email.EmailService) *ChangePasswordUseCase {
+	return &ChangePasswordUseCase{userRepo, sessionRepo, tokenRepo, cache, emailService}
 }
 
 func (uc *ChangePasswordUseCase) Execute(userID int, input dtos.ChangePasswordInput) (error) {
@@ -28,10 +29,10 @@ func (uc *ChangePasswordUseCase) Execute(userID int, input dtos.ChangePasswordIn
 		return errors.New("user not found")
 	}
     
-    // no es necesario supongo
-    // if user.LoginMethod != nil{
-	// 	return errors.New("invalid login method")
-	// }
+    // Las cuentas que solo usan un proveedor externo primero tienen que vincular una contraseña
+    if user.Password == "" {
+		return errors.New("account has no password, link one from your profile")
+	}
 
     if !security.ComparePasswords(user.Password, input.Password) {
         return errors.New("invalid current password")
@@ -51,6 +52,11 @@ func (uc *ChangePasswordUseCase) Execute(userID int, input dtos.ChangePasswordIn
         return errors.New("failed to update password")
     }
 
+    // Los tokens personales se crearon con la contraseña anterior, hay que volver a generarlos
+    if err := uc.tokenRepo.DeleteByUserID(context.Background(), userID); err != nil {
+        return errors.New("failed to revoke access tokens")
+    }
+
     emailJob := email.EmailJob{
 		To:      user.Email,
 		Subject: "Se modificó tu contraseña",
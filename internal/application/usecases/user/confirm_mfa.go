@@ -0,0 +1,105 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const recoveryCodesCount = 10
+
+type ConfirmMFAUseCase struct {
+	userRepo         repository.UserRepository
+	recoveryCodeRepo repository.MFARecoveryCodeRepository
+	cache            *cache.Cache
+	emailService     *email.EmailService
+	logger           *zerolog.Logger
+}
+
+func NewConfirmMFAUseCase(userRepo repository.UserRepository, recoveryCodeRepo repository.MFARecoveryCodeRepository, cache *cache.Cache, emailService *email.EmailService, logger *zerolog.Logger) *ConfirmMFAUseCase {
+	return &ConfirmMFAUseCase{userRepo, recoveryCodeRepo, cache, emailService, logger}
+}
+
+func (uc *ConfirmMFAUseCase) Execute(userID int, input dtos.ConfirmMFAInput) (*dtos.MFARecoveryCodesResponse, error) {
+	ctx := context.Background()
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.MFAEnabled {
+		return nil, errors.New("mfa already enabled")
+	}
+
+	if user.MFASecret == "" {
+		return nil, errors.New("mfa setup not started")
+	}
+
+	secret, err := security.DecryptSecret(user.MFASecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt mfa secret: %w", err)
+	}
+
+	step, ok := security.ValidateTOTP(secret, input.Code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid mfa code")
+	}
+
+	// El código con el que se activa queda usado, no sirve después para el login ni para desactivar
+	usedKey := fmt.Sprintf("mfa:used:%d:%d", userID, step)
+	fresh, err := uc.cache.Client().SetNX(ctx, usedKey, "1", time.Duration(2*security.TOTPSkew+1)*security.TOTPPeriod*time.Second).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store mfa step: %w", err)
+	}
+	if !fresh {
+		return nil, errors.New("invalid mfa code")
+	}
+
+	codes, err := security.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hash, err := security.HashToken(security.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if err := uc.recoveryCodeRepo.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	if err := uc.userRepo.UpdateMFAEnabled(userID, true); err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	_ = uc.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Activaste la verificación en dos pasos",
+		Body:    "La verificación en dos pasos fue activada en tu cuenta. Si no fuiste vos, contactate con soporte.",
+	}
+
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to send mfa enabled email")
+	}
+
+	return &dtos.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Intentos para desactivar la verificación en dos pasos cada 5 minutos, igual que al iniciar sesión
+const maxDisableMFAAttempts = 5
+
+type DisableMFAUseCase struct {
+	userRepo         repository.UserRepository
+	recoveryCodeRepo repository.MFARecoveryCodeRepository
+	cache            *cache.Cache
+	emailService     *email.EmailService
+	logger           *zerolog.Logger
+}
+
+func NewDisableMFAUseCase(userRepo repository.UserRepository, recoveryCodeRepo repository.MFARecoveryCodeRepository, cache *cache.Cache, emailService *email.EmailService, logger *zerolog.Logger) *DisableMFAUseCase {
+	return &DisableMFAUseCase{userRepo, recoveryCodeRepo, cache, emailService, logger}
+}
+
+func (uc *DisableMFAUseCase) Execute(userID int, input dtos.DisableMFAInput) error {
+	ctx := context.Background()
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+
+	if !user.MFAEnabled {
+		return errors.New("mfa not enabled")
+	}
+
+	// Sin contraseña el segundo factor es lo único que se pide, así que los intentos se cuentan siempre
+	attemptsKey := fmt.Sprintf("mfa:attempts:disable:%d", userID)
+	attempts, err := uc.cache.Incr(ctx, attemptsKey, 5*time.Minute)
+	if err != nil {
+		return fmt.Errorf("failed to track mfa attempts: %w", err)
+	}
+	if attempts > maxDisableMFAAttempts {
+		uc.logger.Warn().
+			Int("user_id", userID).
+			Msg("Too many attempts to disable mfa")
+		return errors.New("too many attempts, try again later")
+	}
+
+	// Los usuarios de proveedores externos no tienen contraseña, en ese caso alcanza con el segundo factor
+	if user.Password != "" && !security.ComparePasswords(user.Password, input.Password) {
+		return errors.New("invalid current password")
+	}
+
+	switch {
+	case input.Code != "":
+		secret, err := security.DecryptSecret(user.MFASecret)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt mfa secret: %w", err)
+		}
+		step, ok := security.ValidateTOTP(secret, input.Code, time.Now())
+		if !ok {
+			return errors.New("invalid mfa code")
+		}
+
+		// Comparte la marca con el login: un código ya usado no sirve para ninguna de las dos cosas
+		usedKey := fmt.Sprintf("mfa:used:%d:%d", userID, step)
+		fresh, err := uc.cache.Client().SetNX(ctx, usedKey, "1", time.Duration(2*security.TOTPSkew+1)*security.TOTPPeriod*time.Second).Result()
+		if err != nil {
+			return fmt.Errorf("failed to store mfa step: %w", err)
+		}
+		if !fresh {
+			return errors.New("invalid mfa code")
+		}
+	case input.RecoveryCode != "":
+		codeHash, err := security.HashToken(security.NormalizeRecoveryCode(input.RecoveryCode))
+		if err != nil {
+			return fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		consumed, err := uc.recoveryCodeRepo.Consume(ctx, userID, codeHash)
+		if err != nil {
+			return fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+		if !consumed {
+			return errors.New("invalid recovery code")
+		}
+	default:
+		return errors.New("code or recovery_code is required")
+	}
+
+	if err := uc.userRepo.UpdateMFAEnabled(userID, false); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	if err := uc.userRepo.UpdateMFASecret(userID, ""); err != nil {
+		return fmt.Errorf("failed to clear mfa secret: %w", err)
+	}
+
+	if err := uc.recoveryCodeRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	_ = uc.cache.Delete(ctx, attemptsKey)
+	_ = uc.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Desactivaste la verificación en dos pasos",
+		Body:    "La verificación en dos pasos fue desactivada en tu cuenta. Si no fuiste vos, cambiá tu contraseña y contactate con soporte.",
+	}
+
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		uc.logger.Error().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to send mfa disabled email")
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+)
+
+// passwordLinkReauthWindow es cuánto puede haber pasado desde el login con el proveedor para agregar una contraseña
+const passwordLinkReauthWindow = 10 * time.Minute
+
+var (
+	ErrIdentityNotFound      = errors.New("identity not linked")
+	ErrLastCredential        = errors.New("cannot remove the only way to sign in to this account")
+	ErrPasswordAlreadyLinked = errors.New("account already has a password")
+)
+
+type ListIdentitiesUseCase struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+}
+
+func NewListIdentitiesUseCase(userRepo repository.UserRepository, identityRepo repository.UserIdentityRepository) *ListIdentitiesUseCase {
+	return &ListIdentitiesUseCase{userRepo, identityRepo}
+}
+
+func (uc *ListIdentitiesUseCase) Execute(ctx context.Context, userID int) ([]dtos.IdentityResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	identities, err := uc.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identities: %w", err)
+	}
+
+	result := make([]dtos.IdentityResponse, 0, len(identities)+1)
+	if user.Password != "" {
+		result = append(result, dtos.IdentityResponse{Provider: entities.ProviderPassword, Email: user.Email})
+	}
+	for _, identity := range identities {
+		result = append(result, dtos.IdentityResponse{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// SetPasswordUseCase vincula una contraseña a una cuenta que hasta ahora solo usaba un proveedor externo
+type SetPasswordUseCase struct {
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+}
+
+func NewSetPasswordUseCase(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, cache *cache.Cache, emailService *email.EmailService) *SetPasswordUseCase {
+	return &SetPasswordUseCase{userRepo, sessionRepo, cache, emailService}
+}
+
+func (uc *SetPasswordUseCase) Execute(ctx context.Context, userID, sessionID int, input dtos.SetPasswordInput) error {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if user.Password != "" {
+		return ErrPasswordAlreadyLinked
+	}
+
+	// La cuenta no tiene contraseña que pedir, así que el login con el proveedor tiene que ser reciente
+	recent, err := recentlyAuthenticated(ctx, uc.sessionRepo, userID, sessionID, passwordLinkReauthWindow)
+	if err != nil {
+		return err
+	}
+	if !recent {
+		return ErrReauthRequired
+	}
+
+	hashedPassword, err := security.HashPassword(input.Password)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	if err := uc.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return errors.New("failed to update password")
+	}
+	_ = uc.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Agregaste una contraseña a tu cuenta",
+		Body:    "Ahora también podés iniciar sesión con tu email y contraseña. Si no fuiste vos, restablecé la contraseña y cerrá todas las sesiones.",
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		return fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	return nil
+}
+
+type UnlinkIdentityUseCase struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	passkeyRepo  repository.WebAuthnCredentialRepository
+	cache        *cache.Cache
+	emailService *email.EmailService
+}
+
+func NewUnlinkIdentityUseCase(userRepo repository.UserRepository, identityRepo repository.UserIdentityRepository, passkeyRepo repository.WebAuthnCredentialRepository, cache *cache.Cache, emailService *email.EmailService) *UnlinkIdentityUseCase {
+	return &UnlinkIdentityUseCase{userRepo, identityRepo, passkeyRepo, cache, emailService}
+}
+
+func (uc *UnlinkIdentityUseCase) Execute(ctx context.Context, userID int, provider string) error {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+
+	identities, err := uc.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find identities: %w", err)
+	}
+
+	passkeys, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find passkeys: %w", err)
+	}
+
+	// Se cuentan las credenciales que quedarían, la cuenta nunca puede quedar sin forma de ingresar
+	remaining := len(identities) + len(passkeys)
+	if user.Password != "" {
+		remaining++
+	}
+
+	if provider == entities.ProviderPassword {
+		if user.Password == "" {
+			return ErrIdentityNotFound
+		}
+		if remaining <= 1 {
+			return ErrLastCredential
+		}
+		if err := uc.userRepo.UpdatePassword(userID, ""); err != nil {
+			return errors.New("failed to remove password")
+		}
+	} else {
+		linked := false
+		for _, identity := range identities {
+			if identity.Provider == provider {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			return ErrIdentityNotFound
+		}
+		if remaining <= 1 {
+			return ErrLastCredential
+		}
+		if err := uc.identityRepo.Delete(ctx, userID, provider); err != nil {
+			return fmt.Errorf("failed to unlink identity: %w", err)
+		}
+	}
+	_ = uc.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Se desvinculó un método de inicio de sesión",
+		Body:    fmt.Sprintf("Ya no podés iniciar sesión con %s. Si no fuiste vos, restablecé tu contraseña y cerrá todas las sesiones.", provider),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		return fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache/cachetest"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/queue"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+func (r *fakeUserRepo) UpdatePassword(userID int, newPassword string) error {
+	r.user.Password = newPassword
+	return nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	sessions []*entities.Session
+}
+
+func (r *fakeSessionRepo) FindByUserID(ctx context.Context, userID int64) ([]*entities.Session, error) {
+	var sessions []*entities.Session
+	for _, session := range r.sessions {
+		if int64(session.UserID) == userID && session.IsValid {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepo) FindByFamilyID(ctx context.Context, familyID string) ([]*entities.Session, error) {
+	var family []*entities.Session
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			family = append(family, session)
+		}
+	}
+	return family, nil
+}
+
+func TestSetPasswordRequiresRecentLogin(t *testing.T) {
+	tests := []struct {
+		name      string
+		loginAt   time.Time
+		sessionID int
+		wantErr   error
+	}{
+		{name: "recent login", loginAt: time.Now().Add(-time.Minute), sessionID: 2},
+		// La sesión actual es nueva pero sale de rotar el refresh token de un login viejo
+		{name: "stale login", loginAt: time.Now().Add(-time.Hour), sessionID: 2, wantErr: ErrReauthRequired},
+		{name: "unknown session", loginAt: time.Now().Add(-time.Minute), sessionID: 9, wantErr: ErrReauthRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESEND_API_KEY", "test")
+			cacheService, _ := cachetest.NewCache(t)
+			emailService := email.NewEmailService(queue.NewQueue(cacheService.Client(), "emails"))
+
+			users := &fakeUserRepo{user: &entities.User{ID: 7, Email: "luthier@example.com", Verified: true, IsActive: true}}
+			sessions := &fakeSessionRepo{sessions: []*entities.Session{
+				{ID: 1, UserID: 7, FamilyID: "family", Consumed: true, CreatedAt: tt.loginAt},
+				{ID: 2, UserID: 7, FamilyID: "family", IsValid: true, CreatedAt: time.Now()},
+			}}
+			setPassword := NewSetPasswordUseCase(users, sessions, cacheService, emailService)
+
+			err := setPassword.Execute(context.Background(), 7, tt.sessionID, dtos.SetPasswordInput{Password: "una-contraseña-larga"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if linked := users.user.Password != ""; linked != (tt.wantErr == nil) {
+				t.Errorf("password linked = %v, want %v", linked, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+
+	idp "luthierSaas/internal/infrastructure/auth"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/rs/zerolog"
+)
+
+const passkeyRegistrationTTL = 5 * time.Minute
+
+var (
+	ErrPasskeyNotFound            = errors.New("passkey not found")
+	ErrPasskeyAlreadyRegistered   = errors.New("passkey already registered")
+	ErrInvalidPasskeyRegistration = errors.New("invalid or expired passkey registration")
+)
+
+// El challenge de la ceremonia queda en Redis entre el begin y el finish, uno por usuario
+func passkeyRegistrationKey(userID int) string {
+	return fmt.Sprintf("webauthn:registration:%d", userID)
+}
+
+func newPasskeyResponse(credential *entities.WebAuthnCredential) dtos.PasskeyResponse {
+	return dtos.PasskeyResponse{
+		ID:             credential.ID,
+		Name:           credential.Name,
+		BackupEligible: credential.BackupEligible,
+		CreatedAt:      credential.CreatedAt,
+		LastUsedAt:     credential.LastUsedAt,
+	}
+}
+
+type BeginPasskeyRegistrationUseCase struct {
+	userRepo     repository.UserRepository
+	passkeyRepo  repository.WebAuthnCredentialRepository
+	webAuthn     *webauthn.WebAuthn
+	cacheService *cache.Cache
+}
+
+func NewBeginPasskeyRegistrationUseCase(
+	userRepo repository.UserRepository,
+	passkeyRepo repository.WebAuthnCredentialRepository,
+	webAuthn *webauthn.WebAuthn,
+	cacheService *cache.Cache,
+) *BeginPasskeyRegistrationUseCase {
+	return &BeginPasskeyRegistrationUseCase{userRepo, passkeyRepo, webAuthn, cacheService}
+}
+
+// Execute devuelve las opciones para navigator.credentials.create()
+func (uc *BeginPasskeyRegistrationUseCase) Execute(ctx context.Context, userID int) (*protocol.CredentialCreation, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	credentials, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkeys: %w", err)
+	}
+	passkeyUser := idp.NewPasskeyUser(user, credentials)
+
+	// Las passkeys ya registradas se excluyen para que el autenticador no cree una segunda en el mismo dispositivo
+	options, session, err := uc.webAuthn.BeginRegistration(passkeyUser,
+		webauthn.WithExclusions(webauthn.Credentials(passkeyUser.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin passkey registration: %w", err)
+	}
+
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode passkey session: %w", err)
+	}
+	if err := uc.cacheService.Set(ctx, passkeyRegistrationKey(userID), string(sessionData), passkeyRegistrationTTL); err != nil {
+		return nil, fmt.Errorf("failed to store passkey session: %w", err)
+	}
+
+	return options, nil
+}
+
+type FinishPasskeyRegistrationUseCase struct {
+	userRepo     repository.UserRepository
+	passkeyRepo  repository.WebAuthnCredentialRepository
+	webAuthn     *webauthn.WebAuthn
+	cacheService *cache.Cache
+	emailService *email.EmailService
+	logger       *zerolog.Logger
+}
+
+func NewFinishPasskeyRegistrationUseCase(
+	userRepo repository.UserRepository,
+	passkeyRepo repository.WebAuthnCredentialRepository,
+	webAuthn *webauthn.WebAuthn,
+	cacheService *cache.Cache,
+	emailService *email.EmailService,
+	logger *zerolog.Logger,
+) *FinishPasskeyRegistrationUseCase {
+	return &FinishPasskeyRegistrationUseCase{userRepo, passkeyRepo, webAuthn, cacheService, emailService, logger}
+}
+
+func (uc *FinishPasskeyRegistrationUseCase) Execute(ctx context.Context, userID int, input dtos.PasskeyRegistrationInput) (*dtos.PasskeyResponse, error) {
+	// GetDel consume el challenge, una misma ceremonia no se puede completar dos veces
+	sessionData, err := uc.cacheService.GetDel(ctx, passkeyRegistrationKey(userID))
+	if err != nil {
+		return nil, ErrInvalidPasskeyRegistration
+	}
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
+		return nil, ErrInvalidPasskeyRegistration
+	}
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	credentials, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkeys: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(input.Credential)
+	if err != nil {
+		uc.logger.Warn().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Failed to parse passkey registration response")
+		return nil, ErrInvalidPasskeyRegistration
+	}
+
+	credential, err := uc.webAuthn.CreateCredential(idp.NewPasskeyUser(user, credentials), session, parsed)
+	if err != nil {
+		uc.logger.Warn().
+			Err(err).
+			Int("user_id", userID).
+			Msg("Passkey registration verification failed")
+		return nil, ErrInvalidPasskeyRegistration
+	}
+
+	existing, err := uc.passkeyRepo.FindByCredentialID(ctx, credential.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkey: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrPasskeyAlreadyRegistered
+	}
+
+	passkey := idp.NewWebAuthnCredential(userID, input.Name, credential)
+	passkey.CreatedAt = time.Now()
+	if err := uc.passkeyRepo.Create(ctx, passkey); err != nil {
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	uc.logger.Info().
+		Int("user_id", userID).
+		Int("passkey_id", passkey.ID).
+		Msg("Passkey registered")
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Agregaste una passkey a tu cuenta",
+		Body:    fmt.Sprintf("Registraste la passkey \"%s\" para iniciar sesión sin contraseña. Si no fuiste vos, eliminala desde tu perfil y cerrá todas las sesiones.", passkey.Name),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		return nil, fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	response := newPasskeyResponse(passkey)
+	return &response, nil
+}
+
+type ListPasskeysUseCase struct {
+	passkeyRepo repository.WebAuthnCredentialRepository
+}
+
+func NewListPasskeysUseCase(passkeyRepo repository.WebAuthnCredentialRepository) *ListPasskeysUseCase {
+	return &ListPasskeysUseCase{passkeyRepo}
+}
+
+func (uc *ListPasskeysUseCase) Execute(ctx context.Context, userID int) ([]dtos.PasskeyResponse, error) {
+	credentials, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkeys: %w", err)
+	}
+
+	result := make([]dtos.PasskeyResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		result = append(result, newPasskeyResponse(credential))
+	}
+	return result, nil
+}
+
+type DeletePasskeyUseCase struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	passkeyRepo  repository.WebAuthnCredentialRepository
+	emailService *email.EmailService
+}
+
+func NewDeletePasskeyUseCase(
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	passkeyRepo repository.WebAuthnCredentialRepository,
+	emailService *email.EmailService,
+) *DeletePasskeyUseCase {
+	return &DeletePasskeyUseCase{userRepo, identityRepo, passkeyRepo, emailService}
+}
+
+func (uc *DeletePasskeyUseCase) Execute(ctx context.Context, userID, passkeyID int) error {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+
+	credentials, err := uc.passkeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find passkeys: %w", err)
+	}
+	var name string
+	for _, credential := range credentials {
+		if credential.ID == passkeyID {
+			name = credential.Name
+			break
+		}
+	}
+	if name == "" {
+		return ErrPasskeyNotFound
+	}
+
+	identities, err := uc.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find identities: %w", err)
+	}
+	remaining := len(identities) + len(credentials)
+	if user.Password != "" {
+		remaining++
+	}
+	if remaining <= 1 {
+		return ErrLastCredential
+	}
+
+	deleted, err := uc.passkeyRepo.Delete(ctx, userID, passkeyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+
+	emailJob := email.EmailJob{
+		To:      user.Email,
+		Subject: "Se eliminó una passkey de tu cuenta",
+		Body:    fmt.Sprintf("Ya no podés iniciar sesión con la passkey \"%s\". Si no fuiste vos, restablecé tu contraseña y cerrá todas las sesiones.", name),
+	}
+	if err := uc.emailService.SendEmailAsync(ctx, emailJob); err != nil {
+		return fmt.Errorf("falló el envío del email de notificación: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache/cachetest"
+	"luthierSaas/internal/infrastructure/config"
+	"luthierSaas/internal/infrastructure/email"
+	"luthierSaas/internal/infrastructure/queue"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+
+	idp "luthierSaas/internal/infrastructure/auth"
+	"luthierSaas/internal/infrastructure/auth/passkeytest"
+
+	"github.com/rs/zerolog"
+)
+
+const testOrigin = "http://localhost:5173"
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	user *entities.User
+}
+
+func (r *fakeUserRepo) FindByID(id int) (*entities.User, error) {
+	if r.user.ID != id {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+// fakePasskeyRepo guarda las passkeys en memoria
+type fakePasskeyRepo struct {
+	mu       sync.Mutex
+	passkeys []*entities.WebAuthnCredential
+}
+
+func (r *fakePasskeyRepo) Create(ctx context.Context, credential *entities.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential.ID = len(r.passkeys) + 1
+	r.passkeys = append(r.passkeys, credential)
+	return nil
+}
+
+func (r *fakePasskeyRepo) FindByCredentialID(ctx context.Context, credentialID []byte) (*entities.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, passkey := range r.passkeys {
+		if string(passkey.CredentialID) == string(credentialID) {
+			return passkey, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakePasskeyRepo) FindByUserID(ctx context.Context, userID int) ([]*entities.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var passkeys []*entities.WebAuthnCredential
+	for _, passkey := range r.passkeys {
+		if passkey.UserID == userID {
+			passkeys = append(passkeys, passkey)
+		}
+	}
+	return passkeys, nil
+}
+
+func (r *fakePasskeyRepo) UpdateUsage(ctx context.Context, id int, signCount uint32, backupState bool, usedAt time.Time) error {
+	return errors.New("not used in registration")
+}
+
+func (r *fakePasskeyRepo) Delete(ctx context.Context, userID, id int) (bool, error) {
+	return false, errors.New("not used in registration")
+}
+
+type passkeyRegistrationTest struct {
+	begin    *BeginPasskeyRegistrationUseCase
+	finish   *FinishPasskeyRegistrationUseCase
+	passkeys *fakePasskeyRepo
+}
+
+func newPasskeyRegistrationTest(t *testing.T) *passkeyRegistrationTest {
+	t.Helper()
+	t.Setenv("RESEND_API_KEY", "test")
+
+	webAuthn, err := idp.NewWebAuthn(config.WebAuthnConfig{RPID: "localhost", RPDisplayName: "Luthier SaaS", RPOrigins: []string{testOrigin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheService, _ := cachetest.NewCache(t)
+	emailService := email.NewEmailService(queue.NewQueue(cacheService.Client(), "emails"))
+	logger := zerolog.Nop()
+
+	users := &fakeUserRepo{user: &entities.User{ID: 7, Email: "luthier@example.com", FirstName: "Ana", Verified: true, IsActive: true}}
+	passkeys := &fakePasskeyRepo{}
+	return &passkeyRegistrationTest{
+		begin:    NewBeginPasskeyRegistrationUseCase(users, passkeys, webAuthn, cacheService),
+		finish:   NewFinishPasskeyRegistrationUseCase(users, passkeys, webAuthn, cacheService, emailService, &logger),
+		passkeys: passkeys,
+	}
+}
+
+func TestPasskeyRegistration(t *testing.T) {
+	rt := newPasskeyRegistrationTest(t)
+	authenticator, err := passkeytest.NewAuthenticator(testOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := rt.begin.Execute(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	credential, err := authenticator.Register(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := rt.finish.Execute(context.Background(), 7, dtos.PasskeyRegistrationInput{Name: "Llave", Credential: credential})
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if response.Name != "Llave" {
+		t.Errorf("got name %q, want %q", response.Name, "Llave")
+	}
+
+	stored, err := rt.passkeys.FindByCredentialID(context.Background(), authenticator.CredentialID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == nil || stored.UserID != 7 || len(stored.PublicKey) == 0 {
+		t.Fatalf("stored passkey %+v, want the authenticator's credential for user 7", stored)
+	}
+
+	// El challenge se consume en el finish, la misma respuesta no se puede volver a usar
+	if _, err := rt.finish.Execute(context.Background(), 7, dtos.PasskeyRegistrationInput{Name: "Llave", Credential: credential}); !errors.Is(err, ErrInvalidPasskeyRegistration) {
+		t.Fatalf("replayed finish: got %v, want %v", err, ErrInvalidPasskeyRegistration)
+	}
+}
+
+func TestPasskeyRegistrationRejectsOtherOrigin(t *testing.T) {
+	rt := newPasskeyRegistrationTest(t)
+	authenticator, err := passkeytest.NewAuthenticator("https://evil.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := rt.begin.Execute(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := authenticator.Register(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rt.finish.Execute(context.Background(), 7, dtos.PasskeyRegistrationInput{Name: "Llave", Credential: credential}); !errors.Is(err, ErrInvalidPasskeyRegistration) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidPasskeyRegistration)
+	}
+	if len(rt.passkeys.passkeys) != 0 {
+		t.Errorf("stored %d passkeys from another origin", len(rt.passkeys.passkeys))
+	}
+}
+
+func TestPasskeyRegistrationRejectsRegisteredCredential(t *testing.T) {
+	rt := newPasskeyRegistrationTest(t)
+	authenticator, err := passkeytest.NewAuthenticator(testOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, wantErr := range []error{nil, ErrPasskeyAlreadyRegistered} {
+		options, err := rt.begin.Execute(context.Background(), 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Un navegador respetaría excludeCredentials, el autenticador simulado no: el servidor lo tiene que frenar igual
+		credential, err := authenticator.Register(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rt.finish.Execute(context.Background(), 7, dtos.PasskeyRegistrationInput{Name: "Llave", Credential: credential}); !errors.Is(err, wantErr) {
+			t.Fatalf("registration %d: got %v, want %v", i+1, err, wantErr)
+		}
+	}
+}
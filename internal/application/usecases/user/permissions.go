@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type PermissionsUseCase struct {
+	userRepo       repository.UserRepository
+	permissionRepo repository.PermissionRepository
+}
+
+func NewPermissionsUseCase(userRepo repository.UserRepository, permissionRepo repository.PermissionRepository) *PermissionsUseCase {
+	return &PermissionsUseCase{userRepo, permissionRepo}
+}
+
+// Execute le permite al frontend saber qué secciones mostrar, la autorización real la hace el middleware
+func (uc *PermissionsUseCase) Execute(ctx context.Context, userID int) (*dtos.PermissionsResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	permissions, err := uc.permissionRepo.FindByRole(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find permissions: %w", err)
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &dtos.PermissionsResponse{
+		Role:        user.Role,
+		Permissions: permissions,
+	}, nil
+}
@@ -5,12 +5,36 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"luthierSaas/internal/domain/entities"
 	"luthierSaas/internal/infrastructure/cache"
 	"luthierSaas/internal/interfaces/http/dtos"
 	"luthierSaas/internal/interfaces/repository"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/go-playground/validator/v10"
 )
 
+// e164Pattern es un número internacional: + seguido de hasta 15 dígitos sin ceros al inicio
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// phoneSeparators son los caracteres que se aceptan al escribir el teléfono y se descartan al guardarlo
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+var countryValidator = validator.New()
+
+var ErrNoProfileChanges = errors.New("no profile fields to update")
+
+// ProfileValidationError lista los campos rechazados con el motivo de cada uno
+type ProfileValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ProfileValidationError) Error() string {
+	return "invalid profile fields"
+}
+
 type ProfileUseCase struct {
 	userRepo repository.UserRepository
 	sessionRepo repository.SessionRepository
@@ -47,7 +71,23 @@ func (uc *ProfileUseCase) Execute(userID int) (*dtos.ProfileResponse, error) {
 		return nil, errors.New("user not verified")
 	}
 
-	profile := &dtos.ProfileResponse{
+	profile := newProfileResponse(user)
+
+	profileJSON, err := json.Marshal(profile)
+	if err != nil {
+		fmt.Printf("Error serializing profile for user %d: %v\n", userID, err)
+	} else {
+		ttl := 5 * time.Minute
+		if err := uc.cache.Set(ctx, cacheKey, string(profileJSON), ttl); err != nil {
+			fmt.Printf("Error caching profile for user %d: %v\n", userID, err)
+		}
+	}
+
+	return profile, nil
+}
+
+func newProfileResponse(user *entities.User) *dtos.ProfileResponse {
+	return &dtos.ProfileResponse{
 		ID:           user.ID,
 		Email:        user.Email,
 		FirstName:    user.FirstName,
@@ -57,20 +97,96 @@ func (uc *ProfileUseCase) Execute(userID int) (*dtos.ProfileResponse, error) {
 		Country:      user.Country,
 		WorkshopName: user.WorkshopName,
 		LastLogin:    user.LastLogin,
-		LoginMethod:  func() string { if user.LoginMethod != nil { return *user.LoginMethod }; return "" }(),
+		Providers:    user.Providers,
 		HasPassword:  user.Password != "",
+		MFAEnabled:   user.MFAEnabled,
+		Role:         user.Role,
 		Subscription: user.Subscription,
 	}
+}
 
-	profileJSON, err := json.Marshal(profile)
+type UpdateProfileUseCase struct {
+	userRepo repository.UserRepository
+	cache    *cache.Cache
+}
+
+func NewUpdateProfileUseCase(userRepo repository.UserRepository, cache *cache.Cache) *UpdateProfileUseCase {
+	return &UpdateProfileUseCase{userRepo, cache}
+}
+
+func (uc *UpdateProfileUseCase) Execute(ctx context.Context, userID int, input dtos.UpdateProfileInput) (*dtos.ProfileResponse, error) {
+	update, err := normalizeProfileUpdate(input)
 	if err != nil {
-		fmt.Printf("Error serializing profile for user %d: %v\n", userID, err)
-	} else {
-		ttl := 5 * time.Minute
-		if err := uc.cache.Set(ctx, cacheKey, string(profileJSON), ttl); err != nil {
-			fmt.Printf("Error caching profile for user %d: %v\n", userID, err)
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Deleted {
+		return nil, errors.New("user deleted")
+	}
+
+	if err := uc.userRepo.UpdateProfile(ctx, userID, update); err != nil {
+		return nil, err
+	}
+	_ = uc.cache.Delete(ctx, fmt.Sprintf("profile:user:%d", userID))
+
+	user, err = uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return newProfileResponse(user), nil
+}
+
+// normalizeProfileUpdate limpia los valores recibidos y valida cada campo presente
+func normalizeProfileUpdate(input dtos.UpdateProfileInput) (repository.ProfileUpdate, error) {
+	var update repository.ProfileUpdate
+	fields := map[string]string{}
+
+	trimmed := func(value *string) *string {
+		if value == nil {
+			return nil
 		}
+		v := strings.TrimSpace(*value)
+		return &v
 	}
 
-	return profile, nil
-}
\ No newline at end of file
+	update.FirstName = trimmed(input.FirstName)
+	if update.FirstName != nil && *update.FirstName == "" {
+		fields["first_name"] = "cannot be empty"
+	}
+	update.LastName = trimmed(input.LastName)
+	if update.LastName != nil && *update.LastName == "" {
+		fields["last_name"] = "cannot be empty"
+	}
+
+	if input.Phone != nil {
+		phone := phoneSeparators.Replace(strings.TrimSpace(*input.Phone))
+		if phone != "" && !e164Pattern.MatchString(phone) {
+			fields["phone"] = "must be in E.164 format, e.g. +5491122334455"
+		}
+		update.Phone = &phone
+	}
+
+	if input.Country != nil {
+		country := strings.ToUpper(strings.TrimSpace(*input.Country))
+		if country != "" && countryValidator.Var(country, "iso3166_1_alpha2") != nil {
+			fields["country"] = "must be an ISO 3166-1 alpha-2 code, e.g. AR"
+		}
+		update.Country = &country
+	}
+
+	update.Address = trimmed(input.Address)
+	update.WorkshopName = trimmed(input.WorkshopName)
+
+	if len(fields) > 0 {
+		return update, &ProfileValidationError{Fields: fields}
+	}
+	if update == (repository.ProfileUpdate{}) {
+		return update, ErrNoProfileChanges
+	}
+	return update, nil
+}
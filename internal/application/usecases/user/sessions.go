@@ -0,0 +1,156 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type ListSessionsUseCase struct {
+	sessionRepo repository.SessionRepository
+}
+
+func NewListSessionsUseCase(sessionRepo repository.SessionRepository) *ListSessionsUseCase {
+	return &ListSessionsUseCase{sessionRepo}
+}
+
+func (uc *ListSessionsUseCase) Execute(ctx context.Context, userID int, currentSessionID int) ([]dtos.SessionResponse, error) {
+	sessions, err := activeSessions(ctx, uc.sessionRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dtos.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		lastActiveAt := session.UpdatedAt
+		if lastActiveAt.IsZero() {
+			lastActiveAt = session.CreatedAt
+		}
+
+		result = append(result, dtos.SessionResponse{
+			ID:           session.ID,
+			DeviceInfo:   session.DeviceInfo,
+			CreatedAt:    session.CreatedAt,
+			LastActiveAt: lastActiveAt,
+			ExpiresAt:    session.RefreshExpiresAt,
+			Current:      session.ID == currentSessionID,
+			Impersonated: session.ImpersonatorID != 0,
+		})
+	}
+
+	return result, nil
+}
+
+type RevokeSessionUseCase struct {
+	sessionRepo repository.SessionRepository
+}
+
+func NewRevokeSessionUseCase(sessionRepo repository.SessionRepository) *RevokeSessionUseCase {
+	return &RevokeSessionUseCase{sessionRepo}
+}
+
+func (uc *RevokeSessionUseCase) Execute(ctx context.Context, userID int, sessionID int) error {
+	sessions, err := activeSessions(ctx, uc.sessionRepo, userID)
+	if err != nil {
+		return err
+	}
+
+	// Solo se buscan las sesiones del propio usuario, así no puede revocar sesiones ajenas
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			if err := uc.sessionRepo.Invalidate(ctx, session.AccessTokenHash); err != nil {
+				return fmt.Errorf("failed to revoke session: %w", err)
+			}
+			return nil
+		}
+	}
+
+	return ErrSessionNotFound
+}
+
+type RevokeOtherSessionsUseCase struct {
+	sessionRepo repository.SessionRepository
+}
+
+func NewRevokeOtherSessionsUseCase(sessionRepo repository.SessionRepository) *RevokeOtherSessionsUseCase {
+	return &RevokeOtherSessionsUseCase{sessionRepo}
+}
+
+func (uc *RevokeOtherSessionsUseCase) Execute(ctx context.Context, userID int, currentSessionID int) (int, error) {
+	sessions, err := activeSessions(ctx, uc.sessionRepo, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+		if err := uc.sessionRepo.Invalidate(ctx, session.AccessTokenHash); err != nil {
+			return revoked, fmt.Errorf("failed to revoke session %d: %w", session.ID, err)
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// activeSessions descarta las sesiones cuyo refresh token ya venció aunque sigan marcadas como válidas
+func activeSessions(ctx context.Context, sessionRepo repository.SessionRepository, userID int) ([]*entities.Session, error) {
+	sessions, err := sessionRepo.FindByUserID(ctx, int64(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sessions: %w", err)
+	}
+
+	now := time.Now()
+	active := make([]*entities.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.RefreshExpiresAt.IsZero() || now.Before(session.RefreshExpiresAt) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// recentlyAuthenticated mira cuándo empezó la familia de la sesión actual: las rotaciones del refresh
+// token crean sesiones nuevas, pero la primera de la familia corresponde al login. Lo usan las cuentas
+// sin contraseña para confirmar acciones sensibles.
+func recentlyAuthenticated(ctx context.Context, sessionRepo repository.SessionRepository, userID, sessionID int, window time.Duration) (bool, error) {
+	sessions, err := sessionRepo.FindByUserID(ctx, int64(userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to find sessions: %w", err)
+	}
+
+	var current *entities.Session
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			current = session
+			break
+		}
+	}
+	if current == nil {
+		return false, nil
+	}
+
+	loginAt := current.CreatedAt
+	if current.FamilyID != "" {
+		family, err := sessionRepo.FindByFamilyID(ctx, current.FamilyID)
+		if err != nil {
+			return false, fmt.Errorf("failed to find session family: %w", err)
+		}
+		for _, session := range family {
+			if session.CreatedAt.Before(loginAt) {
+				loginAt = session.CreatedAt
+			}
+		}
+	}
+
+	return time.Since(loginAt) <= window, nil
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/http/dtos"
+	"luthierSaas/internal/interfaces/repository"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const mfaIssuer = "Luthier SaaS"
+
+type SetupMFAUseCase struct {
+	userRepo repository.UserRepository
+}
+
+func NewSetupMFAUseCase(userRepo repository.UserRepository) *SetupMFAUseCase {
+	return &SetupMFAUseCase{userRepo}
+}
+
+// Execute genera un secreto nuevo que queda pendiente hasta que el usuario lo confirme con un código
+func (uc *SetupMFAUseCase) Execute(userID int) (*dtos.MFASetupResponse, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.MFAEnabled {
+		return nil, errors.New("mfa already enabled")
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+
+	encryptedSecret, err := security.EncryptSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mfa secret: %w", err)
+	}
+
+	if err := uc.userRepo.UpdateMFASecret(userID, encryptedSecret); err != nil {
+		return nil, fmt.Errorf("failed to store mfa secret: %w", err)
+	}
+
+	uri := security.TOTPURI(mfaIssuer, user.Email, secret)
+
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate qr code: %w", err)
+	}
+
+	return &dtos.MFASetupResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
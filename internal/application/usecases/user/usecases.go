@@ -4,24 +4,83 @@ import (
 	"luthierSaas/internal/infrastructure/cache"
 	"luthierSaas/internal/infrastructure/email"
 	"luthierSaas/internal/interfaces/repository"
+	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/rs/zerolog"
 )
 
 type UserUseCases struct {
     Profile      *ProfileUseCase
+    UpdateProfile *UpdateProfileUseCase
     ChangePassword *ChangePasswordUseCase
+    SetupMFA *SetupMFAUseCase
+    ConfirmMFA *ConfirmMFAUseCase
+    DisableMFA *DisableMFAUseCase
+    ListSessions *ListSessionsUseCase
+    RevokeSession *RevokeSessionUseCase
+    RevokeOtherSessions *RevokeOtherSessionsUseCase
+    Permissions *PermissionsUseCase
+    ListIdentities *ListIdentitiesUseCase
+    SetPassword *SetPasswordUseCase
+    UnlinkIdentity *UnlinkIdentityUseCase
+    BeginPasskeyRegistration *BeginPasskeyRegistrationUseCase
+    FinishPasskeyRegistration *FinishPasskeyRegistrationUseCase
+    ListPasskeys *ListPasskeysUseCase
+    DeletePasskey *DeletePasskeyUseCase
+    RequestEmailChange *RequestEmailChangeUseCase
+    ConfirmEmailChange *ConfirmEmailChangeUseCase
+    CancelEmailChange *CancelEmailChangeUseCase
+    DeleteAccount *DeleteAccountUseCase
+    PurgeDeletedAccounts *PurgeDeletedAccountsUseCase
+    ExportAccount *ExportAccountUseCase
+    CreateAccessToken *CreateAccessTokenUseCase
+    ListAccessTokens *ListAccessTokensUseCase
+    RevokeAccessToken *RevokeAccessTokenUseCase
 }
 
 func NewUserUseCases(
     userRepo repository.UserRepository, 
     sessionRepo repository.SessionRepository, 
+    subscriptionRepo repository.SubscriptionRepository,
+    recoveryCodeRepo repository.MFARecoveryCodeRepository,
+    permissionRepo repository.PermissionRepository,
+    identityRepo repository.UserIdentityRepository,
+    passkeyRepo repository.WebAuthnCredentialRepository,
+    tokenRepo repository.PersonalAccessTokenRepository,
+    webAuthn *webauthn.WebAuthn,
     cacheService *cache.Cache, 
     emailService *email.EmailService,
-    logger      *zerolog.Logger) *UserUseCases{
+    logger      *zerolog.Logger,
+    appClientURL string,
+    accountDeletionGracePeriod time.Duration) *UserUseCases{
         
     return &UserUseCases{
         Profile:      NewProfileUseCase(userRepo, sessionRepo, cacheService ),
-        ChangePassword: NewChangePasswordUseCase(userRepo, sessionRepo, cacheService, emailService),
+        UpdateProfile: NewUpdateProfileUseCase(userRepo, cacheService),
+        ChangePassword: NewChangePasswordUseCase(userRepo, sessionRepo, tokenRepo, cacheService, emailService),
+        SetupMFA: NewSetupMFAUseCase(userRepo),
+        ConfirmMFA: NewConfirmMFAUseCase(userRepo, recoveryCodeRepo, cacheService, emailService, logger),
+        DisableMFA: NewDisableMFAUseCase(userRepo, recoveryCodeRepo, cacheService, emailService, logger),
+        ListSessions: NewListSessionsUseCase(sessionRepo),
+        RevokeSession: NewRevokeSessionUseCase(sessionRepo),
+        RevokeOtherSessions: NewRevokeOtherSessionsUseCase(sessionRepo),
+        Permissions: NewPermissionsUseCase(userRepo, permissionRepo),
+        ListIdentities: NewListIdentitiesUseCase(userRepo, identityRepo),
+        SetPassword: NewSetPasswordUseCase(userRepo, sessionRepo, cacheService, emailService),
+        UnlinkIdentity: NewUnlinkIdentityUseCase(userRepo, identityRepo, passkeyRepo, cacheService, emailService),
+        BeginPasskeyRegistration: NewBeginPasskeyRegistrationUseCase(userRepo, passkeyRepo, webAuthn, cacheService),
+        FinishPasskeyRegistration: NewFinishPasskeyRegistrationUseCase(userRepo, passkeyRepo, webAuthn, cacheService, emailService, logger),
+        ListPasskeys: NewListPasskeysUseCase(passkeyRepo),
+        DeletePasskey: NewDeletePasskeyUseCase(userRepo, identityRepo, passkeyRepo, emailService),
+        RequestEmailChange: NewRequestEmailChangeUseCase(userRepo, sessionRepo, cacheService, emailService, logger, appClientURL),
+        ConfirmEmailChange: NewConfirmEmailChangeUseCase(userRepo, sessionRepo, cacheService, emailService, logger),
+        CancelEmailChange: NewCancelEmailChangeUseCase(userRepo, sessionRepo, tokenRepo, cacheService, emailService, logger),
+        DeleteAccount: NewDeleteAccountUseCase(userRepo, sessionRepo, tokenRepo, cacheService, emailService, logger, accountDeletionGracePeriod),
+        PurgeDeletedAccounts: NewPurgeDeletedAccountsUseCase(userRepo, cacheService, emailService, logger),
+        ExportAccount: NewExportAccountUseCase(userRepo, sessionRepo, subscriptionRepo, identityRepo, passkeyRepo, tokenRepo),
+        CreateAccessToken: NewCreateAccessTokenUseCase(userRepo, tokenRepo, emailService, logger),
+        ListAccessTokens: NewListAccessTokensUseCase(tokenRepo),
+        RevokeAccessToken: NewRevokeAccessTokenUseCase(tokenRepo, logger),
     }
 }
\ No newline at end of file
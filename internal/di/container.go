@@ -2,24 +2,43 @@ package di
 
 import (
 	"database/sql"
+	"luthierSaas/internal/application/usecases/admin"
 	"luthierSaas/internal/application/usecases/auth"
+	"luthierSaas/internal/application/usecases/subscription"
 	"luthierSaas/internal/application/usecases/user"
+	idp "luthierSaas/internal/infrastructure/auth"
 	"luthierSaas/internal/infrastructure/cache"
 	"luthierSaas/internal/infrastructure/config"
 	"luthierSaas/internal/infrastructure/email"
 	"luthierSaas/internal/infrastructure/logger"
+	"luthierSaas/internal/infrastructure/payment"
 	"luthierSaas/internal/infrastructure/persistance/repositories"
 	"luthierSaas/internal/infrastructure/queue"
+	"luthierSaas/internal/infrastructure/scheduler"
+	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/http/handlers"
+	"luthierSaas/internal/interfaces/http/middlewares"
+	"luthierSaas/internal/interfaces/repository"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type Container struct {
-	AuthHandler   *handlers.AuthHandler
-	UserHandler   *handlers.UserHandler
-	RedisClient   *redis.Client
-	CacheService  *cache.Cache
+	AuthHandler         *handlers.AuthHandler
+	UserHandler         *handlers.UserHandler
+	AdminHandler        *handlers.AdminHandler
+	SubscriptionHandler *handlers.SubscriptionHandler
+	PaymentHandler      *handlers.PaymentHandler
+	JWKSHandler         *handlers.JWKSHandler
+	RedisClient         *redis.Client
+	CacheService        *cache.Cache
+	SessionRepo         repository.SessionRepository
+	Authorizer          *middlewares.Authorizer
+	TokenAuth           *middlewares.TokenAuthenticator
+	Entitlements        *middlewares.EntitlementGuard
+	RateLimiter         *middlewares.RateLimiter
+	Scheduler           *scheduler.Scheduler
 }
 
 func NewContainer(db *sql.DB, cfg *config.Config) (*Container, *email.EmailService) {
@@ -30,7 +49,14 @@ func NewContainer(db *sql.DB, cfg *config.Config) (*Container, *email.EmailServi
 	userRepo := repositories.NewUserRepository(db)
 	suscriptionRepo := repositories.NewSubscriptionRepository(db)
 	emailVerificationRepo := repositories.NewEmailVerificationRepository(db)
-	sessionRepo := repositories.NewSessionRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	recoveryCodeRepo := repositories.NewMFARecoveryCodeRepository(db)
+	permissionRepo := repositories.NewPermissionRepository(db)
+	identityRepo := repositories.NewUserIdentityRepository(db)
+	passkeyRepo := repositories.NewWebAuthnCredentialRepository(db)
+	accessTokenRepo := repositories.NewPersonalAccessTokenRepository(db)
+	auditLogRepo := repositories.NewAuditLogRepository(db)
+	paymentEventRepo := repositories.NewPaymentEventRepository(db)
 
 	// Cliente Redis
 	redisClient := redis.NewClient(&redis.Options{
@@ -46,18 +72,56 @@ func NewContainer(db *sql.DB, cfg *config.Config) (*Container, *email.EmailServi
 	// Cache Service
 	cacheService := cache.NewCache(redisClient)
 
+	// Intentos de contraseña fallidos por cuenta, los comparten el login y el panel de administración
+	loginLockout := security.NewLoginLockout(cacheService)
+
+	// Las sesiones se consultan en cada request autenticado, se cachean en Redis
+	sessionRepo := repositories.NewCachedSessionRepository(repositories.NewSessionRepository(db), cacheService)
+
+	// Proveedores de login externos configurados (OIDC y GitHub)
+	identityProviders, err := idp.NewRegistryFromConfig(cfg.IdentityProviders)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure identity providers")
+	}
+
+	// Relying party de WebAuthn para registrar passkeys e iniciar sesión con ellas
+	webAuthn, err := idp.NewWebAuthn(cfg.WebAuthn)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure webauthn")
+	}
+
+	// Pasarela de pagos, la simulada expone además sus rutas para cerrar checkouts en local
+	paymentGateway, err := payment.NewGatewayFromConfig(cfg.Payment)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure payment gateway")
+	}
+	fakeGateway, _ := paymentGateway.(*payment.FakeGateway)
+	if fakeGateway != nil {
+		log.Warn().Msg("Using the fake payment gateway, checkouts are not charged")
+	}
+
 	// Casos de uso
 	authUC := auth.NewAuthUseCases(
 		userRepo,
 		suscriptionRepo,
 		emailVerificationRepo,
 		sessionRepo,
+		passwordResetRepo,
+		recoveryCodeRepo,
+		identityRepo,
+		passkeyRepo,
+		accessTokenRepo,
 		emailService,
 		cacheService,
 		log,
-		cfg.GoogleOAuth, // Inyectar la configuración de Google OAuth
+		identityProviders,
+		webAuthn,
+		loginLockout,
+		cfg.AppClientURL,
 	)
-	userUC := user.NewUserUseCases(userRepo, sessionRepo, cacheService, emailService, log)
+	userUC := user.NewUserUseCases(userRepo, sessionRepo, suscriptionRepo, recoveryCodeRepo, permissionRepo, identityRepo, passkeyRepo, accessTokenRepo, webAuthn, cacheService, emailService, log, cfg.AppClientURL, cfg.AccountDeletionGracePeriod)
+	adminUC := admin.NewAdminUseCases(userRepo, sessionRepo, accessTokenRepo, emailVerificationRepo, auditLogRepo, cacheService, loginLockout, log)
+	subscriptionUC := subscription.NewSubscriptionUseCases(userRepo, suscriptionRepo, paymentEventRepo, paymentGateway, cacheService, emailService, log, cfg.AppClientURL, cfg.SubscriptionGracePeriod)
 
 	// Handlers
 	authHandler := handlers.NewAuthHandler(
@@ -67,16 +131,86 @@ func NewContainer(db *sql.DB, cfg *config.Config) (*Container, *email.EmailServi
 		authUC.VerifyEmail,
 		authUC.ResendVerificationCode,
 		authUC.RefreshToken,
-		authUC.GoogleLogin,
-		authUC.GoogleCallback,
+		authUC.OAuthLogin,
+		authUC.OAuthCallback,
 		authUC.Logout,
+		authUC.ForgotPassword,
+		authUC.ResetPassword,
+		authUC.VerifyMFA,
+		authUC.RequestMagicLink,
+		authUC.MagicLinkLogin,
+		authUC.BeginPasskeyLogin,
+		authUC.PasskeyLogin,
+		authUC.ReportUnrecognizedLogin,
 	)
-	userHandler := handlers.NewUserHandler(userUC.Profile, userUC.ChangePassword)
+	userHandler := handlers.NewUserHandler(
+		userUC.Profile,
+		userUC.UpdateProfile,
+		userUC.ChangePassword,
+		userUC.SetupMFA,
+		userUC.ConfirmMFA,
+		userUC.DisableMFA,
+		userUC.ListSessions,
+		userUC.RevokeSession,
+		userUC.RevokeOtherSessions,
+		userUC.Permissions,
+		userUC.ListIdentities,
+		userUC.SetPassword,
+		userUC.UnlinkIdentity,
+		authUC.LinkProvider,
+		userUC.BeginPasskeyRegistration,
+		userUC.FinishPasskeyRegistration,
+		userUC.ListPasskeys,
+		userUC.DeletePasskey,
+		userUC.RequestEmailChange,
+		userUC.ConfirmEmailChange,
+		userUC.CancelEmailChange,
+		userUC.DeleteAccount,
+		userUC.ExportAccount,
+		userUC.CreateAccessToken,
+		userUC.ListAccessTokens,
+		userUC.RevokeAccessToken,
+	)
+	adminHandler := handlers.NewAdminHandler(
+		adminUC.ListUsers,
+		adminUC.GetUser,
+		adminUC.SetUserActive,
+		adminUC.SetUserDeleted,
+		adminUC.ForceVerifyEmail,
+		adminUC.ForceLogout,
+		adminUC.UnlockLogin,
+		adminUC.StartImpersonation,
+		adminUC.StopImpersonation,
+	)
+	subscriptionHandler := handlers.NewSubscriptionHandler(
+		subscriptionUC.ListPlans,
+		subscriptionUC.GetSubscription,
+		subscriptionUC.ChangePlan,
+		subscriptionUC.CancelSubscription,
+		subscriptionUC.ResumeSubscription,
+		subscriptionUC.Entitlements,
+	)
+	paymentHandler := handlers.NewPaymentHandler(subscriptionUC.HandleWebhook, fakeGateway)
+
+	// Tareas periódicas, main las arranca junto con el worker de emails
+	jobs := scheduler.New(log)
+	jobs.Every("purge_deleted_accounts", time.Hour, userUC.PurgeDeletedAccounts.Execute)
+	jobs.Every("process_subscriptions", 15*time.Minute, subscriptionUC.ProcessSubscriptions.Execute)
 
 	return &Container{
-		AuthHandler:  authHandler,
-		UserHandler:  userHandler,
-		RedisClient:  redisClient,
-		CacheService: cacheService,
+		AuthHandler:         authHandler,
+		UserHandler:         userHandler,
+		AdminHandler:        adminHandler,
+		SubscriptionHandler: subscriptionHandler,
+		PaymentHandler:      paymentHandler,
+		JWKSHandler:         handlers.NewJWKSHandler(),
+		RedisClient:         redisClient,
+		CacheService:        cacheService,
+		SessionRepo:         sessionRepo,
+		Authorizer:          middlewares.NewAuthorizer(permissionRepo, cacheService),
+		TokenAuth:           middlewares.NewTokenAuthenticator(sessionRepo, accessTokenRepo, userRepo),
+		Entitlements:        middlewares.NewEntitlementGuard(subscriptionUC.Entitlements),
+		RateLimiter:         middlewares.NewRateLimiter(cacheService, accessTokenRepo, subscriptionUC.Entitlements, cfg.RateLimits, log),
+		Scheduler:           jobs,
 	}, emailService
-}
\ No newline at end of file
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "time"
+
+const (
+	AuditActionImpersonationStart = "impersonation.start"
+	AuditActionImpersonationStop  = "impersonation.stop"
+)
+
+// AuditLog registra una acción de un administrador sobre la cuenta de otro usuario
+type AuditLog struct {
+	ID           int
+	ActorID      int
+	Action       string
+	TargetUserID int
+	SessionID    int
+	IP           string
+	// Metadata es un JSON libre con el detalle de cada acción
+	Metadata  string
+	CreatedAt time.Time
+}
@@ -3,9 +3,12 @@ package entities
 import "time"
 
 type EmailVerification struct {
-	ID        int
-	UserID    int
-	Code      string
+	ID     int
+	UserID int
+	// CodeHash es el SHA-256 del código enviado por email, el código en claro nunca se guarda
+	CodeHash string
+	// Attempts cuenta los códigos incorrectos ingresados desde el último envío
+	Attempts  int
 	ExpiresAt time.Time
 	Verified  bool
 	CreatedAt time.Time
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+type PasswordReset struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	Used      bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
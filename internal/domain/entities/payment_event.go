@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// PaymentEvent es un webhook de la pasarela ya procesado, evita aplicar dos veces el mismo evento
+type PaymentEvent struct {
+	ID             int
+	Provider       string
+	EventID        string
+	Type           string
+	SubscriptionID int
+	CreatedAt      time.Time
+}
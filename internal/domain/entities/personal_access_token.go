@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes que puede llevar un token de acceso personal. Cada grupo de rutas que acepta tokens
+// declara cuál necesita; las rutas sin scope solo aceptan la sesión del navegador.
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+)
+
+var AccessTokenScopes = []string{
+	ScopeProfileRead,
+	ScopeProfileWrite,
+}
+
+// PersonalAccessToken es un token para scripts e integraciones, solo se guarda su hash
+type PersonalAccessToken struct {
+	ID        int
+	UserID    int
+	Name      string
+	TokenHash string
+	// Prefix son los primeros caracteres del token, para que el usuario lo reconozca en el listado
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	LastUsedIP string
+	CreatedAt  time.Time
+}
+
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+func (t *PersonalAccessToken) Expired() bool {
+	return !time.Now().Before(t.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package entities
+
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// RoleRank ordena los roles para las acciones de administración: nadie gestiona una cuenta de su
+// mismo rango o superior. Un rol desconocido vale como user.
+func RoleRank(role string) int {
+	switch role {
+	case RoleSuperAdmin:
+		return 2
+	case RoleAdmin:
+		return 1
+	default:
+		return 0
+	}
+}
+
+const (
+	PermissionUsersRead         = "users:read"
+	PermissionUsersWrite        = "users:write"
+	PermissionPermissionsManage = "permissions:manage"
+	PermissionUsersImpersonate  = "users:impersonate"
+)
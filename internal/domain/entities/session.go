@@ -11,6 +11,13 @@ type Session struct {
     RefreshExpiresAt  time.Time
     IsValid           bool
     DeviceInfo       string
+    IPAddress         string
+    // FamilyID agrupa todas las sesiones que salen de un mismo login a través de las rotaciones
+    FamilyID          string
+    // Consumed marca un refresh token que ya fue rotado, si se vuelve a presentar es un robo
+    Consumed          bool
+    // ImpersonatorID es el administrador que abrió la sesión en nombre del usuario, 0 si es una sesión normal
+    ImpersonatorID    int
     CreatedAt         time.Time
     UpdatedAt         time.Time
-}
\ No newline at end of file
+}
@@ -1,5 +1,27 @@
 package entities
 
+// FreeTierPlanName es la prueba gratuita que recibe toda cuenta nueva, no se puede elegir después
+const FreeTierPlanName = "Free Tier"
+
+// Niveles de plan para los rate limits. La prueba gratuita y las cuentas sin plan son free.
+const (
+	PlanTierFree    = "free"
+	PlanTierBasic   = "basic"
+	PlanTierPremium = "premium"
+)
+
+// PlanTier devuelve el nivel de un plan por su nombre, un plan desconocido no da más que free
+func PlanTier(planName string) string {
+	switch planName {
+	case "Basic":
+		return PlanTierBasic
+	case "Premium":
+		return PlanTierPremium
+	default:
+		return PlanTierFree
+	}
+}
+
 type SubscriptionPlan struct {
 	ID           int
 	Name         string
@@ -8,4 +30,60 @@ type SubscriptionPlan struct {
 	DurationDays int
 	CreatedAt    string
 	UpdatedAt    string
-}
\ No newline at end of file
+}
+
+// Funciones que dependen del plan. Las que tienen cupo se controlan contra el uso, pdf_branding
+// está o no está.
+const (
+	FeatureMaxActiveRepairOrders = "max_active_repair_orders"
+	FeatureMaxClients            = "max_clients"
+	FeatureStorageQuotaMB        = "storage_quota_mb"
+	FeatureStaffSeats            = "staff_seats"
+	FeaturePDFBranding           = "pdf_branding"
+)
+
+// PlanFeatures son los límites de un plan, un cupo nil es ilimitado
+type PlanFeatures struct {
+	MaxActiveRepairOrders *int `json:"max_active_repair_orders"`
+	MaxClients            *int `json:"max_clients"`
+	StorageQuotaMB        *int `json:"storage_quota_mb"`
+	StaffSeats            *int `json:"staff_seats"`
+	PDFBranding           bool `json:"pdf_branding"`
+}
+
+// FreeFeatures son los límites de una cuenta sin suscripción activa, cuando venció la prueba o el plan.
+// Coinciden con los del plan Free Tier que carga la migración 000023
+func FreeFeatures() *PlanFeatures {
+	limit := func(n int) *int { return &n }
+	return &PlanFeatures{
+		MaxActiveRepairOrders: limit(20),
+		MaxClients:            limit(50),
+		StorageQuotaMB:        limit(500),
+		StaffSeats:            limit(1),
+		PDFBranding:           false,
+	}
+}
+
+// Allows indica si usage, lo que quedaría usado después de la operación, entra en el plan. Devuelve
+// también el límite, -1 si no hay cupo. Para pdf_branding usage no importa.
+func (f *PlanFeatures) Allows(feature string, usage int) (int, bool) {
+	var quota *int
+	switch feature {
+	case FeaturePDFBranding:
+		return -1, f.PDFBranding
+	case FeatureMaxActiveRepairOrders:
+		quota = f.MaxActiveRepairOrders
+	case FeatureMaxClients:
+		quota = f.MaxClients
+	case FeatureStorageQuotaMB:
+		quota = f.StorageQuotaMB
+	case FeatureStaffSeats:
+		quota = f.StaffSeats
+	default:
+		return 0, false
+	}
+	if quota == nil {
+		return -1, true
+	}
+	return *quota, usage <= *quota
+}
@@ -2,13 +2,29 @@ package entities
 
 import "time"
 
+const (
+	SubscriptionStatusActive   = "active"
+	SubscriptionStatusPending  = "pending"
+	SubscriptionStatusCanceled = "canceled"
+	// SubscriptionStatusScheduled es un cambio de plan que arranca cuando vence la suscripción activa
+	SubscriptionStatusScheduled = "scheduled"
+)
+
 type Subscription struct {
-	ID        int    		`json:"id"`
-	UserID    int    		`json:"user_id"`   
-    PlanID    int    		`json:"plan_id"`
-    PlanName  string 		`json:"plan_name"`
-    Status    string 		`json:"status"`
-    StartedAt time.Time  	`json:"started_at"`
-    ExpiresAt time.Time  	`json:"expires_at"`
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	PlanID    int       `json:"plan_id"`
+	PlanName  string    `json:"plan_name"`
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// CancelAtPeriodEnd indica que la suscripción no se renueva al vencer
+	CancelAtPeriodEnd bool      `json:"cancel_at_period_end"`
+	CanceledAt        time.Time `json:"canceled_at,omitempty"`
+	// Checkout de la pasarela con el que se paga una suscripción pendiente
+	PaymentProvider string `json:"-"`
+	CheckoutID      string `json:"-"`
+	// Marcas del scheduler para no repetir el aviso de vencimiento ni el pedido de renovación
+	ReminderSentAt     time.Time `json:"-"`
+	RenewalRequestedAt time.Time `json:"-"`
 }
-
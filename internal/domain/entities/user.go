@@ -6,8 +6,6 @@ type User struct {
 	ID           int
 	Email        string
 	Password     string
-	GoogleID     string
-	LoginMethod  *string
 	Role         string
 	FirstName    string
 	LastName     string
@@ -17,8 +15,18 @@ type User struct {
 	WorkshopName string
 	IsActive     bool
 	Deleted      bool
-	Verified     bool
-	CreatedAt    time.Time
-	LastLogin    string
+	DeletedAt    time.Time
+	// PurgeAfter solo tiene valor si el usuario borró su propia cuenta, hasta entonces puede recuperarla
+	PurgeAfter time.Time
+	Verified   bool
+	MFAEnabled bool
+	MFASecret  string
+	// PasswordResetRequired bloquea el login con contraseña hasta que se restablezca, se activa
+	// cuando el usuario avisa que un inicio de sesión no fue suyo
+	PasswordResetRequired bool
+	CreatedAt             time.Time
+	LastLogin             string
+	// Providers lista los proveedores externos vinculados, la contraseña se deduce de Password
+	Providers    []string
 	Subscription *Subscription
-}
\ No newline at end of file
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// ProviderPassword identifica la contraseña propia, que vive en users.password y no en user_identities
+const ProviderPassword = "password"
+
+// UserIdentity vincula una cuenta con un proveedor externo de identidad
+type UserIdentity struct {
+	ID        int
+	UserID    int
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
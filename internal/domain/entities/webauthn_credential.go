@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// WebAuthnCredential es una passkey registrada por el usuario
+type WebAuthnCredential struct {
+	ID              int
+	UserID          int
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	BackupEligible  bool
+	BackupState     bool
+	LastUsedAt      time.Time
+	CreatedAt       time.Time
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/config"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newSignedClientSecret arma el client secret que pide Apple: un JWT ES256 firmado con la clave
+// del equipo (archivo .p8), con vida corta porque se genera en cada intercambio de código.
+func newSignedClientSecret(cfg config.IdentityProviderConfig) (func() (string, error), error) {
+	if cfg.TeamID == "" || cfg.KeyID == "" {
+		return nil, errors.New("signed client secret requires team id and key id")
+	}
+
+	data, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	privateKey, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key must be an ECDSA key")
+	}
+
+	audience := strings.TrimSuffix(cfg.Issuer, "/")
+
+	return func() (string, error) {
+		now := time.Now()
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"iss": cfg.TeamID,
+			"sub": cfg.ClientID,
+			"aud": audience,
+			"iat": now.Unix(),
+			"exp": now.Add(5 * time.Minute).Unix(),
+		})
+		token.Header["kid"] = cfg.KeyID
+		return token.SignedString(privateKey)
+	}, nil
+}
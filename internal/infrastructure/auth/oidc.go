@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/config"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// supportedIDTokenAlgs son los algoritmos que aceptamos aunque el proveedor anuncie otros
+var supportedIDTokenAlgs = map[string]bool{
+	"RS256": true, "RS384": true, "RS512": true,
+	"PS256": true, "PS384": true, "PS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+	"EdDSA": true,
+}
+
+type discoveryDocument struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	IDTokenSigningAlgs    []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// flexibleBool acepta true y "true", Apple manda email_verified como string
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = flexibleBool(v == "true")
+	}
+	return nil
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string       `json:"nonce"`
+	AuthorizedParty string       `json:"azp"`
+	TenantID        string       `json:"tid"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+	GivenName       string       `json:"given_name"`
+	FamilyName      string       `json:"family_name"`
+}
+
+// OIDCProvider implementa IdentityProvider para cualquier emisor OpenID Connect usando su discovery document
+type OIDCProvider struct {
+	cfg          config.IdentityProviderConfig
+	httpClient   *http.Client
+	clientSecret func() (string, error)
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      *remoteKeySet
+}
+
+func NewOIDCProvider(cfg config.IdentityProviderConfig, httpClient *http.Client) (*OIDCProvider, error) {
+	provider := &OIDCProvider{
+		cfg:        cfg,
+		httpClient: httpClient,
+		clientSecret: func() (string, error) {
+			return cfg.ClientSecret, nil
+		},
+	}
+
+	if cfg.PrivateKeyFile != "" {
+		clientSecret, err := newSignedClientSecret(cfg)
+		if err != nil {
+			return nil, err
+		}
+		provider.clientSecret = clientSecret
+	}
+
+	return provider, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+// discover descarga el discovery document una sola vez, si falla se reintenta en el próximo login
+func (p *OIDCProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.Issuer, "/")
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.httpClient, issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+
+	// Microsoft multi-tenant publica el issuer con {tenantid}, se resuelve con el claim tid de cada token
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer && !strings.Contains(doc.Issuer, "{tenantid}") {
+		return nil, fmt.Errorf("discovery issuer %q does not match configured issuer %q", doc.Issuer, p.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing required endpoints")
+	}
+
+	p.discovery = &doc
+	p.keys = newRemoteKeySet(doc.JWKSURI, p.httpClient)
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) oauthConfig(doc *discoveryDocument, clientSecret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+	}
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	opts := []oauth2.AuthCodeOption{
+		oauth2.S256ChallengeOption(req.CodeVerifier),
+		oauth2.SetAuthURLParam("nonce", req.Nonce),
+	}
+	if p.cfg.ResponseMode != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("response_mode", p.cfg.ResponseMode))
+	}
+
+	return p.oauthConfig(doc, "").AuthCodeURL(req.State, opts...), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, req AuthRequest) (*ExternalIdentity, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret, err := p.clientSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client secret: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	token, err := p.oauthConfig(doc, clientSecret).Exchange(ctx, code, oauth2.VerifierOption(req.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, doc, rawIDToken, req.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	identity := &ExternalIdentity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}
+
+	// Algunos proveedores solo mandan el perfil en userinfo, el sub tiene que coincidir con el del ID token
+	if (identity.Email == "" || identity.Name == "") && doc.UserinfoEndpoint != "" {
+		var userinfo idTokenClaims
+		if err := getJSON(ctx, p.httpClient, doc.UserinfoEndpoint, token.AccessToken, &userinfo); err == nil && userinfo.Subject == claims.Subject {
+			if identity.Email == "" {
+				identity.Email = userinfo.Email
+				identity.EmailVerified = bool(userinfo.EmailVerified)
+			}
+			if identity.Name == "" {
+				identity.Name = userinfo.Name
+				identity.GivenName = userinfo.GivenName
+				identity.FamilyName = userinfo.FamilyName
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, doc *discoveryDocument, rawIDToken, nonce string) (*idTokenClaims, error) {
+	var algs []string
+	for _, alg := range doc.IDTokenSigningAlgs {
+		if supportedIDTokenAlgs[alg] {
+			algs = append(algs, alg)
+		}
+	}
+	if len(algs) == 0 {
+		algs = []string{"RS256"}
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(algs),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+
+	var claims idTokenClaims
+	_, err := parser.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("id token has no kid")
+		}
+		return p.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expectedIssuer := strings.ReplaceAll(doc.Issuer, "{tenantid}", claims.TenantID)
+	if claims.Issuer != expectedIssuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("unexpected authorized party")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	return &claims, nil
+}
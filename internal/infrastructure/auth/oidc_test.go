@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/infrastructure/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "luthier-client"
+	testKeyID    = "test-key"
+	testCode     = "auth-code"
+)
+
+// fakeIssuer es un emisor OIDC local: publica el discovery document y el JWKS, y su token endpoint
+// verifica PKCE contra el code_challenge del authorize antes de entregar el ID token
+type fakeIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu        sync.Mutex
+	challenge string
+	nonce     string
+	// signingKey firma el ID token, por defecto key; otra clave simula una firma inválida
+	signingKey *rsa.PrivateKey
+	// mutate cambia los claims del ID token antes de firmarlo
+	mutate func(claims jwt.MapClaims)
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeIssuer{t: t, key: key, signingKey: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", f.discovery)
+	mux.HandleFunc("/jwks", f.jwks)
+	mux.HandleFunc("/token", f.token)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                f.server.URL,
+		"authorization_endpoint":                f.server.URL + "/authorize",
+		"token_endpoint":                        f.server.URL + "/token",
+		"jwks_uri":                              f.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (f *fakeIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := f.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (f *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != testCode || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            f.server.URL,
+		"sub":            "subject-123",
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          f.nonce,
+		"email":          "luthier@example.com",
+		"email_verified": true,
+		"name":           "Ana Luthier",
+		"given_name":     "Ana",
+		"family_name":    "Luthier",
+	}
+	if f.mutate != nil {
+		f.mutate(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	idToken, err := token.SignedString(f.signingKey)
+	if err != nil {
+		f.t.Errorf("failed to sign id token: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize hace de navegador: arma la URL de login y guarda el challenge y el nonce como lo haría el emisor
+func (f *fakeIssuer) authorize(t *testing.T, provider *OIDCProvider, req AuthRequest) {
+	t.Helper()
+	authURL, err := provider.AuthCodeURL(context.Background(), req)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	if !strings.HasPrefix(authURL, f.server.URL+"/authorize") {
+		t.Fatalf("unexpected authorization endpoint %q", authURL)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("state") != req.State || query.Get("client_id") != testClientID {
+		t.Fatalf("unexpected authorization parameters %v", query)
+	}
+
+	f.mu.Lock()
+	f.challenge = query.Get("code_challenge")
+	f.nonce = query.Get("nonce")
+	f.mu.Unlock()
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func newTestProvider(t *testing.T, issuer *fakeIssuer) *OIDCProvider {
+	t.Helper()
+	provider, err := NewOIDCProvider(config.IdentityProviderConfig{
+		Name:         "fake",
+		Type:         "oidc",
+		Issuer:       issuer.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/v1/auth/fake/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}, issuer.server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func newTestAuthRequest(t *testing.T) AuthRequest {
+	t.Helper()
+	req, err := NewAuthRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestOIDCExchange(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := newTestProvider(t, issuer)
+	req := newTestAuthRequest(t)
+	issuer.authorize(t, provider, req)
+
+	identity, err := provider.Exchange(context.Background(), testCode, req)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	want := ExternalIdentity{
+		Provider:      "fake",
+		Subject:       "subject-123",
+		Email:         "luthier@example.com",
+		EmailVerified: true,
+		Name:          "Ana Luthier",
+		GivenName:     "Ana",
+		FamilyName:    "Luthier",
+	}
+	if *identity != want {
+		t.Errorf("got %+v, want %+v", *identity, want)
+	}
+}
+
+func TestOIDCExchangeRequiresPKCEVerifier(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := newTestProvider(t, issuer)
+	req := newTestAuthRequest(t)
+	issuer.authorize(t, provider, req)
+
+	// Un verifier distinto del que generó el challenge no puede canjear el código
+	other := req
+	other.CodeVerifier = newTestAuthRequest(t).CodeVerifier
+	if _, err := provider.Exchange(context.Background(), testCode, other); err == nil {
+		t.Fatal("exchange with a different code verifier succeeded")
+	}
+
+	if _, err := provider.Exchange(context.Background(), testCode, req); err != nil {
+		t.Fatalf("exchange with the original code verifier failed: %v", err)
+	}
+}
+
+func TestOIDCRejectsInvalidIDTokens(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		mutate     func(claims jwt.MapClaims)
+		signingKey *rsa.PrivateKey
+		nonce      string
+	}{
+		{name: "signature", signingKey: otherKey},
+		{name: "issuer", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "audience", mutate: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "expired", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Minute).Unix() }},
+		{name: "missing exp", mutate: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "nonce", nonce: "another-nonce"},
+		{name: "missing nonce", mutate: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "azp", mutate: func(c jwt.MapClaims) {
+			c["aud"] = []string{testClientID, "another-client"}
+			c["azp"] = "another-client"
+		}},
+		{name: "subject", mutate: func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newFakeIssuer(t)
+			provider := newTestProvider(t, issuer)
+			req := newTestAuthRequest(t)
+			issuer.authorize(t, provider, req)
+
+			issuer.mutate = tt.mutate
+			if tt.signingKey != nil {
+				issuer.signingKey = tt.signingKey
+			}
+			if tt.nonce != "" {
+				issuer.nonce = tt.nonce
+			}
+
+			if _, err := provider.Exchange(context.Background(), testCode, req); err == nil || !strings.Contains(err.Error(), "invalid id token") {
+				t.Fatalf("got %v, want an invalid id token error", err)
+			}
+		})
+	}
+}
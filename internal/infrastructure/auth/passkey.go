@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/config"
+	"strconv"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+var ErrInvalidUserHandle = errors.New("invalid passkey user handle")
+
+// NewWebAuthn configura el relying party para las ceremonias de passkeys
+func NewWebAuthn(cfg config.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+}
+
+// PasskeyUser adapta un usuario y sus passkeys a la interfaz que espera la librería de WebAuthn
+type PasskeyUser struct {
+	user        *entities.User
+	credentials []*entities.WebAuthnCredential
+}
+
+func NewPasskeyUser(user *entities.User, credentials []*entities.WebAuthnCredential) *PasskeyUser {
+	return &PasskeyUser{user: user, credentials: credentials}
+}
+
+func (u *PasskeyUser) User() *entities.User {
+	return u.user
+}
+
+// WebAuthnID es el user handle que guarda el autenticador, con él se resuelve el usuario en el login sin email
+func (u *PasskeyUser) WebAuthnID() []byte {
+	return UserHandle(u.user.ID)
+}
+
+func (u *PasskeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *PasskeyUser) WebAuthnDisplayName() string {
+	name := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName)
+	if name == "" {
+		return u.user.Email
+	}
+	return name
+}
+
+func (u *PasskeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+	for i, credential := range u.credentials {
+		credentials[i] = toWebAuthnCredential(credential)
+	}
+	return credentials
+}
+
+// FindCredential devuelve la passkey guardada que corresponde al id que presentó el autenticador
+func (u *PasskeyUser) FindCredential(credentialID []byte) *entities.WebAuthnCredential {
+	for _, credential := range u.credentials {
+		if string(credential.CredentialID) == string(credentialID) {
+			return credential
+		}
+	}
+	return nil
+}
+
+func UserHandle(userID int) []byte {
+	return []byte(strconv.Itoa(userID))
+}
+
+func UserIDFromHandle(handle []byte) (int, error) {
+	userID, err := strconv.Atoi(string(handle))
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidUserHandle
+	}
+	return userID, nil
+}
+
+// NewWebAuthnCredential arma la entidad a guardar a partir de una ceremonia de registro exitosa
+func NewWebAuthnCredential(userID int, name string, credential *webauthn.Credential) *entities.WebAuthnCredential {
+	transports := make([]string, len(credential.Transport))
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+	return &entities.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+}
+
+func toWebAuthnCredential(credential *entities.WebAuthnCredential) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, len(credential.Transports))
+	for i, transport := range credential.Transports {
+		transports[i] = protocol.AuthenticatorTransport(transport)
+	}
+	return webauthn.Credential{
+		ID:              credential.CredentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    true,
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: credential.SignCount,
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/config"
+	"net/http"
+	"sort"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// ExternalIdentity es lo que sabemos del usuario después de autenticarse con un proveedor
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+}
+
+// AuthRequest son los valores de un intento de login que hay que recordar hasta el callback
+type AuthRequest struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// IdentityProvider abstrae un proveedor de login basado en authorization code con PKCE
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, req AuthRequest) (string, error)
+	Exchange(ctx context.Context, code string, req AuthRequest) (*ExternalIdentity, error)
+}
+
+// NewAuthRequest genera el state, el nonce y el code verifier de PKCE para un nuevo login
+func NewAuthRequest() (AuthRequest, error) {
+	state, err := randomString(24)
+	if err != nil {
+		return AuthRequest{}, err
+	}
+	nonce, err := randomString(24)
+	if err != nil {
+		return AuthRequest{}, err
+	}
+	return AuthRequest{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+	}, nil
+}
+
+type Registry struct {
+	providers map[string]IdentityProvider
+}
+
+func NewRegistry(providers ...IdentityProvider) *Registry {
+	registry := &Registry{providers: make(map[string]IdentityProvider, len(providers))}
+	for _, provider := range providers {
+		registry.providers[provider.Name()] = provider
+	}
+	return registry
+}
+
+// NewRegistryFromConfig arma los proveedores configurados, el discovery se resuelve recién en el primer uso
+func NewRegistryFromConfig(configs []config.IdentityProviderConfig) (*Registry, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	providers := make([]IdentityProvider, 0, len(configs))
+	for _, cfg := range configs {
+		switch cfg.Type {
+		case "oidc":
+			provider, err := NewOIDCProvider(cfg, httpClient)
+			if err != nil {
+				return nil, fmt.Errorf("identity provider %s: %w", cfg.Name, err)
+			}
+			providers = append(providers, provider)
+		case "github":
+			providers = append(providers, NewGitHubProvider(cfg, httpClient))
+		default:
+			return nil, fmt.Errorf("identity provider %s: unsupported type %q", cfg.Name, cfg.Type)
+		}
+	}
+
+	return NewRegistry(providers...), nil
+}
+
+func (r *Registry) Get(name string) (IdentityProvider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
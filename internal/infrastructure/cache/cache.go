@@ -27,6 +27,56 @@ func (c *Cache) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
+// GetDel lee y borra la clave en una sola operación, útil para tokens de un solo uso
+func (c *Cache) GetDel(ctx context.Context, key string) (string, error) {
+	return c.client.GetDel(ctx, key).Result()
+}
+
 func (c *Cache) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
 }
+
+// Incr suma uno al contador y le fija el ttl cuando se crea, la ventana corre desde el primer incremento
+func (c *Cache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	count, err := c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := c.client.Expire(ctx, key, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// setUnlessScript guarda KEYS[1] solo si KEYS[2] no existe, en una sola operación
+var setUnlessScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// SetUnless guarda la clave salvo que exista blocker, devuelve false si no la guardó.
+// Sirve para que una marca de borrado le gane a quien está llenando el cache con un valor viejo.
+func (c *Cache) SetUnless(ctx context.Context, key, blocker, value string, ttl time.Duration) (bool, error) {
+	set, err := setUnlessScript.Run(ctx, c.client, []string{key, blocker}, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return set == 1, nil
+}
+
+// TTL devuelve el tiempo de vida restante, cero si la clave no existe o no expira
+func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
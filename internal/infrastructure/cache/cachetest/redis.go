@@ -0,0 +1,289 @@
+// Package cachetest levanta un Redis en memoria para los tests de los casos de uso que dependen del
+// cache. Entiende solo los comandos que usa la aplicación (strings, contadores y LPUSH de la cola
+// de emails), no los scripts.
+package cachetest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"luthierSaas/internal/infrastructure/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Server es el Redis en memoria, se cierra solo al terminar el test
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	values  map[string]string
+	lists   map[string][]string
+	expires map[string]time.Time
+}
+
+// NewCache devuelve un cache conectado a un Server nuevo
+func NewCache(t *testing.T) (*cache.Cache, *Server) {
+	t.Helper()
+	server := NewServer(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	return cache.NewCache(client), server
+}
+
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		listener: listener,
+		values:   map[string]string{},
+		lists:    map[string][]string{},
+		expires:  map[string]time.Time{},
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Get lee una clave directamente, para verificar qué dejó el caso de uso
+func (s *Server) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(key)
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("expected an array")
+	}
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		header, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimPrefix(header, "$"))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+func (s *Server) exec(args []string) string {
+	if len(args) == 0 {
+		return "-ERR empty command\r\n"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range args[1:] {
+		s.expire(key)
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "CLIENT", "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		return s.set(args[1:])
+	case "SETNX":
+		if s.exists(args[1]) {
+			return integer(0)
+		}
+		s.values[args[1]] = args[2]
+		return integer(1)
+	case "GET":
+		return s.get(args[1], false)
+	case "GETDEL":
+		return s.get(args[1], true)
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if s.exists(key) {
+				deleted++
+			}
+			s.delete(key)
+		}
+		return integer(deleted)
+	case "EXISTS":
+		found := 0
+		for _, key := range args[1:] {
+			if s.exists(key) {
+				found++
+			}
+		}
+		return integer(found)
+	case "INCR":
+		count, err := strconv.Atoi(s.valueOr(args[1], "0"))
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		s.values[args[1]] = strconv.Itoa(count + 1)
+		return integer(count + 1)
+	case "EXPIRE", "PEXPIRE":
+		if !s.exists(args[1]) {
+			return integer(0)
+		}
+		amount, err := strconv.Atoi(args[2])
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		unit := time.Second
+		if strings.ToUpper(args[0]) == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		s.expires[args[1]] = time.Now().Add(time.Duration(amount) * unit)
+		return integer(1)
+	case "TTL":
+		if !s.exists(args[1]) {
+			return integer(-2)
+		}
+		expiresAt, ok := s.expires[args[1]]
+		if !ok {
+			return integer(-1)
+		}
+		return integer(int(time.Until(expiresAt).Seconds()))
+	case "LPUSH":
+		s.lists[args[1]] = append(args[2:], s.lists[args[1]]...)
+		return integer(len(s.lists[args[1]]))
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// set entiende SET key value [EX s | PX ms] [NX]
+func (s *Server) set(args []string) string {
+	key, value := args[0], args[1]
+	var ttl time.Duration
+	onlyIfAbsent := false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EX", "PX":
+			amount, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return "-ERR value is not an integer or out of range\r\n"
+			}
+			ttl = time.Duration(amount) * time.Second
+			if strings.ToUpper(args[i]) == "PX" {
+				ttl = time.Duration(amount) * time.Millisecond
+			}
+			i++
+		case "NX":
+			onlyIfAbsent = true
+		}
+	}
+
+	if onlyIfAbsent && s.exists(key) {
+		return "$-1\r\n"
+	}
+	s.delete(key)
+	s.values[key] = value
+	if ttl > 0 {
+		s.expires[key] = time.Now().Add(ttl)
+	}
+	return "+OK\r\n"
+}
+
+func (s *Server) get(key string, remove bool) string {
+	value, ok := s.values[key]
+	if !ok {
+		return "$-1\r\n"
+	}
+	if remove {
+		s.delete(key)
+	}
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func (s *Server) valueOr(key, fallback string) string {
+	if value, ok := s.values[key]; ok {
+		return value
+	}
+	return fallback
+}
+
+func (s *Server) exists(key string) bool {
+	_, isValue := s.values[key]
+	_, isList := s.lists[key]
+	return isValue || isList
+}
+
+func (s *Server) delete(key string) {
+	delete(s.values, key)
+	delete(s.lists, key)
+	delete(s.expires, key)
+}
+
+func (s *Server) expire(key string) {
+	if expiresAt, ok := s.expires[key]; ok && !time.Now().Before(expiresAt) {
+		s.delete(key)
+	}
+}
+
+func integer(n int) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
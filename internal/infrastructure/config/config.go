@@ -3,16 +3,60 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
 type Config struct {
 	DatabaseURL   string
-	GoogleOAuth   *oauth2.Config
+	IdentityProviders []IdentityProviderConfig
 	AppClientURL  string
+	JWTKeysDir    string
+	JWTActiveKeyID string
+	JWTIssuer     string
+	// JWTEphemeralKey permite arrancar sin JWT_KEYS_DIR con una clave generada al inicio, solo en desarrollo
+	JWTEphemeralKey bool
+	WebAuthn      WebAuthnConfig
+	// AccountDeletionGracePeriod es el plazo para recuperar una cuenta borrada antes del borrado definitivo
+	AccountDeletionGracePeriod time.Duration
+	Payment       PaymentConfig
+	// SubscriptionGracePeriod es cuánto sigue activo un plan pago vencido mientras se espera el pago de la renovación
+	SubscriptionGracePeriod time.Duration
+	// RateLimits es el límite de cada grupo de rutas por plan, ver loadRateLimits
+	RateLimits    map[string]RateLimitTiers
+}
+
+// RateLimitTiers es el límite de un grupo de rutas para cada plan, en el formato de ulule/limiter:
+// "100-M" son 100 requests por minuto (S, M, H o D). Los requests anónimos usan Free.
+type RateLimitTiers struct {
+	Free    string
+	Basic   string
+	Premium string
+	// ByUser cuenta por usuario con el límite de su plan. Sin él se cuenta por IP con Free, que es lo
+	// que necesitan las rutas de auth: por usuario, una IP tendría un contador por cada cuenta que maneja.
+	ByUser bool
+}
+
+// PaymentConfig elige la pasarela de cobro. Provider "stripe" cobra de verdad, "fake" simula el
+// checkout en local y manda los mismos webhooks firmados a WebhookURL. La simulada activa planes
+// sin cobrar, así que solo se acepta con APP_ENV=development (AllowFake).
+type PaymentConfig struct {
+	Provider      string
+	AllowFake     bool
+	SecretKey     string
+	WebhookSecret string
+	Currency      string
+	WebhookURL    string
+}
+
+// WebAuthnConfig identifica al relying party ante los autenticadores de passkeys
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
 }
 
 func LoadConfig() (*Config, error) {
@@ -34,21 +78,247 @@ func LoadConfig() (*Config, error) {
 		databaseURL = "root:@tcp(localhost:3306)/luthier_sass_db?charset=utf8mb4&parseTime=true&loc=Local"
 	}
 
-	// Configuración de Google OAuth2
-	googleOAuth := &oauth2.Config{
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
-		Scopes: []string{
-			"https://www.googleapis.com/auth/userinfo.email",
-			"https://www.googleapis.com/auth/userinfo.profile",
-		},
-		Endpoint: google.Endpoint,
+	development := strings.ToLower(os.Getenv("APP_ENV")) == "development"
+
+	// Claves asimétricas para firmar JWT, un archivo <kid>.pem por clave
+	jwtKeysDir := os.Getenv("JWT_KEYS_DIR")
+	jwtActiveKeyID := os.Getenv("JWT_ACTIVE_KID")
+	jwtIssuer := os.Getenv("JWT_ISSUER")
+	if jwtIssuer == "" {
+		jwtIssuer = "luthier-saas"
+	}
+
+	// Proveedores de login externos (Google, Microsoft, Apple, GitHub o cualquier emisor OIDC)
+	identityProviders := loadIdentityProviders()
+
+	// Passkeys: el RP ID es el dominio del frontend y los orígenes permitidos van separados por coma
+	webAuthn := WebAuthnConfig{
+		RPID:          os.Getenv("WEBAUTHN_RP_ID"),
+		RPDisplayName: os.Getenv("WEBAUTHN_RP_NAME"),
+		RPOrigins:     splitList(os.Getenv("WEBAUTHN_RP_ORIGINS")),
+	}
+	if webAuthn.RPID == "" {
+		webAuthn.RPID = "localhost"
+	}
+	if webAuthn.RPDisplayName == "" {
+		webAuthn.RPDisplayName = "Luthier SaaS"
+	}
+	if len(webAuthn.RPOrigins) == 0 {
+		webAuthn.RPOrigins = []string{"http://localhost:5173"}
+	}
+
+	// Días que una cuenta borrada por su dueño se puede recuperar iniciando sesión
+	deletionGraceDays, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || deletionGraceDays <= 0 {
+		deletionGraceDays = 30
+	}
+
+	// Pasarela de pagos. PAYMENT_PROVIDER es obligatorio salvo en desarrollo, donde se usa la simulada
+	payment := PaymentConfig{
+		Provider:      strings.ToLower(os.Getenv("PAYMENT_PROVIDER")),
+		AllowFake:     development,
+		SecretKey:     os.Getenv("PAYMENT_SECRET_KEY"),
+		WebhookSecret: os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+		Currency:      strings.ToLower(os.Getenv("PAYMENT_CURRENCY")),
+		WebhookURL:    os.Getenv("PAYMENT_WEBHOOK_URL"),
+	}
+	if payment.Provider == "" && development {
+		payment.Provider = "fake"
+	}
+	if payment.Currency == "" {
+		payment.Currency = "usd"
+	}
+	if payment.WebhookURL == "" {
+		payment.WebhookURL = "http://localhost:8080/v1/payments/webhook"
+	}
+
+	// Días de gracia para pagar la renovación antes de perder el plan
+	subscriptionGraceDays, err := strconv.Atoi(os.Getenv("SUBSCRIPTION_GRACE_DAYS"))
+	if err != nil || subscriptionGraceDays < 0 {
+		subscriptionGraceDays = 3
 	}
 
 	return &Config{
 		DatabaseURL: databaseURL,
-		GoogleOAuth: googleOAuth,
+		IdentityProviders: identityProviders,
 		AppClientURL: appClientURL,
+		JWTKeysDir: jwtKeysDir,
+		JWTActiveKeyID: jwtActiveKeyID,
+		JWTIssuer: jwtIssuer,
+		JWTEphemeralKey: development,
+		WebAuthn: webAuthn,
+		AccountDeletionGracePeriod: time.Duration(deletionGraceDays) * 24 * time.Hour,
+		Payment: payment,
+		SubscriptionGracePeriod: time.Duration(subscriptionGraceDays) * 24 * time.Hour,
+		RateLimits: loadRateLimits(),
 	}, nil
-}
\ No newline at end of file
+}
+
+// IdentityProviderConfig describe un proveedor de login. Type "oidc" usa el discovery document
+// del Issuer, "github" usa la API de GitHub porque no es un emisor OIDC.
+type IdentityProviderConfig struct {
+	Name         string
+	Type         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	ResponseMode string
+	// Apple no acepta un client secret fijo, se firma con la clave del equipo en cada intercambio
+	TeamID         string
+	KeyID          string
+	PrivateKeyFile string
+}
+
+// knownProviders son los valores por defecto de cada proveedor conocido, las variables de entorno los pisan
+var knownProviders = map[string]IdentityProviderConfig{
+	"google": {
+		Type:   "oidc",
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	"microsoft": {
+		Type:   "oidc",
+		Issuer: "https://login.microsoftonline.com/common/v2.0",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	"apple": {
+		Type:         "oidc",
+		Issuer:       "https://appleid.apple.com",
+		Scopes:       []string{"openid", "email", "name"},
+		ResponseMode: "form_post",
+	},
+	"github": {
+		Type:   "github",
+		Scopes: []string{"read:user", "user:email"},
+	},
+}
+
+// loadIdentityProviders lee OIDC_PROVIDERS (ej: "google,microsoft") y para cada uno las variables
+// OIDC_<NOMBRE>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL, _SCOPES, _TYPE y _RESPONSE_MODE.
+// Sin OIDC_PROVIDERS se mantiene la configuración anterior de Google con GOOGLE_CLIENT_ID.
+func loadIdentityProviders() []IdentityProviderConfig {
+	names := os.Getenv("OIDC_PROVIDERS")
+	if names == "" && os.Getenv("GOOGLE_CLIENT_ID") != "" {
+		names = "google"
+	}
+
+	var providers []IdentityProviderConfig
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		provider := knownProviders[name]
+		provider.Name = name
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		if v := os.Getenv(prefix + "TYPE"); v != "" {
+			provider.Type = v
+		}
+		if provider.Type == "" {
+			provider.Type = "oidc"
+		}
+		if v := os.Getenv(prefix + "ISSUER"); v != "" {
+			provider.Issuer = v
+		}
+		if v := os.Getenv(prefix + "SCOPES"); v != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(v, ",", " "))
+		}
+		if v := os.Getenv(prefix + "RESPONSE_MODE"); v != "" {
+			provider.ResponseMode = v
+		}
+		provider.ClientID = os.Getenv(prefix + "CLIENT_ID")
+		provider.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		provider.RedirectURL = os.Getenv(prefix + "REDIRECT_URL")
+		provider.TeamID = os.Getenv(prefix + "TEAM_ID")
+		provider.KeyID = os.Getenv(prefix + "KEY_ID")
+		provider.PrivateKeyFile = os.Getenv(prefix + "PRIVATE_KEY_FILE")
+
+		if name == "google" {
+			if provider.ClientID == "" {
+				provider.ClientID = os.Getenv("GOOGLE_CLIENT_ID")
+			}
+			if provider.ClientSecret == "" {
+				provider.ClientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
+			}
+			if provider.RedirectURL == "" {
+				provider.RedirectURL = os.Getenv("GOOGLE_REDIRECT_URL")
+			}
+		}
+
+		if provider.ClientID == "" {
+			log.Printf("Identity provider %q has no client id, skipping", name)
+			continue
+		}
+		if provider.Type == "oidc" && provider.Issuer == "" {
+			log.Printf("Identity provider %q has no issuer, skipping", name)
+			continue
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// defaultRateLimits son los límites si no hay variables de entorno. Solo api cuenta por usuario y
+// distingue planes, las rutas de auth cuentan siempre por IP.
+var defaultRateLimits = map[string]RateLimitTiers{
+	"api":               {Free: "100-M", Basic: "300-M", Premium: "1000-M", ByUser: true},
+	"signin":            {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+	"signup":            {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+	"check-email":       {Free: "10-M", Basic: "10-M", Premium: "10-M"},
+	"verify-email":      {Free: "10-M", Basic: "10-M", Premium: "10-M"},
+	"resend-code":       {Free: "3-M", Basic: "3-M", Premium: "3-M"},
+	"refresh":           {Free: "10-M", Basic: "10-M", Premium: "10-M"},
+	"logout":            {Free: "20-M", Basic: "20-M", Premium: "20-M"},
+	"forgot-password":   {Free: "3-M", Basic: "3-M", Premium: "3-M"},
+	"reset-password":    {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+	"mfa-verify":        {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+	"magic-link":        {Free: "3-M", Basic: "3-M", Premium: "3-M"},
+	"magic-link-verify": {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+	"passkey-begin":     {Free: "10-M", Basic: "10-M", Premium: "10-M"},
+	"passkey-finish":    {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+	"oauth-login":       {Free: "10-M", Basic: "10-M", Premium: "10-M"},
+	"not-me":            {Free: "5-M", Basic: "5-M", Premium: "5-M"},
+}
+
+// loadRateLimits pisa los valores por defecto con RATE_LIMIT_<GRUPO> (todos los planes) y
+// RATE_LIMIT_<GRUPO>_<PLAN>, ej: RATE_LIMIT_API_PREMIUM=2000-M o RATE_LIMIT_FORGOT_PASSWORD=2-M.
+// El formato se valida al armar las rutas.
+func loadRateLimits() map[string]RateLimitTiers {
+	limits := make(map[string]RateLimitTiers, len(defaultRateLimits))
+	for name, tiers := range defaultRateLimits {
+		prefix := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+
+		// Solo se pisan los límites, si el grupo cuenta por usuario lo sigue haciendo
+		if v := os.Getenv(prefix); v != "" {
+			tiers.Free, tiers.Basic, tiers.Premium = v, v, v
+		}
+		if v := os.Getenv(prefix + "_FREE"); v != "" {
+			tiers.Free = v
+		}
+		if v := os.Getenv(prefix + "_BASIC"); v != "" {
+			tiers.Basic = v
+		}
+		if v := os.Getenv(prefix + "_PREMIUM"); v != "" {
+			tiers.Premium = v
+		}
+		limits[name] = tiers
+	}
+	return limits
+}
+
+// splitList separa una lista por comas descartando los elementos vacíos
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,177 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/config"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownCheckout = errors.New("unknown checkout session")
+	ErrUnknownOutcome  = errors.New("outcome must be paid, failed or expired")
+)
+
+// Resultados con los que se puede cerrar un checkout simulado
+const (
+	FakeOutcomePaid    = "paid"
+	FakeOutcomeFailed  = "failed"
+	FakeOutcomeExpired = "expired"
+)
+
+// FakeGateway simula la pasarela en desarrollo: no cobra nada, pero al cerrar un checkout manda
+// a WebhookURL el mismo webhook firmado que mandaría Stripe, así se prueba el circuito completo
+type FakeGateway struct {
+	webhookSecret string
+	webhookURL    string
+	checkoutURL   string
+	httpClient    *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]FakeCheckout
+}
+
+// FakeCheckout es lo que la pasarela simulada recuerda de cada checkout, se pierde al reiniciar
+type FakeCheckout struct {
+	ID          string  `json:"id"`
+	Reference   string  `json:"reference"`
+	UserID      int     `json:"-"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	SuccessURL  string  `json:"success_url"`
+	CancelURL   string  `json:"cancel_url"`
+}
+
+func NewFakeGateway(cfg config.PaymentConfig, httpClient *http.Client) (*FakeGateway, error) {
+	webhookURL, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+
+	secret := cfg.WebhookSecret
+	if secret == "" {
+		if secret, err = randomID(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FakeGateway{
+		webhookSecret: secret,
+		webhookURL:    cfg.WebhookURL,
+		checkoutURL:   fmt.Sprintf("%s://%s/v1/payments/fake/checkouts/", webhookURL.Scheme, webhookURL.Host),
+		httpClient:    httpClient,
+		sessions:      make(map[string]FakeCheckout),
+	}, nil
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) CreateCheckout(ctx context.Context, req CheckoutRequest) (*CheckoutSession, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	id = "cs_fake_" + id
+
+	g.mu.Lock()
+	g.sessions[id] = FakeCheckout{
+		ID:          id,
+		Reference:   req.Reference,
+		UserID:      req.UserID,
+		Description: req.Description,
+		Amount:      req.Amount,
+		SuccessURL:  req.SuccessURL,
+		CancelURL:   req.CancelURL,
+	}
+	g.mu.Unlock()
+
+	return &CheckoutSession{ID: id, URL: g.checkoutURL + id}, nil
+}
+
+func (g *FakeGateway) ParseWebhook(header http.Header, payload []byte) (*Event, error) {
+	return parseStripeWebhook(header, payload, g.webhookSecret, time.Now())
+}
+
+func (g *FakeGateway) Checkout(id string) (FakeCheckout, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	checkout, ok := g.sessions[id]
+	return checkout, ok
+}
+
+// Complete cierra el checkout con el resultado pedido y entrega el webhook. Devuelve el id del
+// evento; con eventID distinto de vacío reenvía ese mismo evento, como hace Stripe al reintentar.
+func (g *FakeGateway) Complete(ctx context.Context, id, outcome, eventID string) (string, error) {
+	checkout, ok := g.Checkout(id)
+	if !ok {
+		return "", ErrUnknownCheckout
+	}
+
+	eventType, paymentStatus := "", "unpaid"
+	switch outcome {
+	case FakeOutcomePaid:
+		eventType, paymentStatus = "checkout.session.completed", "paid"
+	case FakeOutcomeFailed:
+		eventType = "checkout.session.async_payment_failed"
+	case FakeOutcomeExpired:
+		eventType = "checkout.session.expired"
+	default:
+		return "", ErrUnknownOutcome
+	}
+
+	if eventID == "" {
+		random, err := randomID()
+		if err != nil {
+			return "", err
+		}
+		eventID = "evt_fake_" + random
+	}
+
+	var event stripeEvent
+	event.ID = eventID
+	event.Type = eventType
+	event.Data.Object.ID = checkout.ID
+	event.Data.Object.ClientReferenceID = checkout.Reference
+	event.Data.Object.PaymentStatus = paymentStatus
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", fmt.Sprintf("t=%s,v1=%s", timestamp, signStripePayload(g.webhookSecret, timestamp, payload)))
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+	return eventID, nil
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,137 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"luthierSaas/internal/infrastructure/config"
+)
+
+// webhookRecorder hace de endpoint de webhooks: verifica cada entrega con la pasarela y la guarda
+type webhookRecorder struct {
+	t       *testing.T
+	gateway *FakeGateway
+	status  int
+
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.t.Errorf("failed to read webhook: %v", err)
+		return
+	}
+	event, err := r.gateway.ParseWebhook(req.Header, payload)
+	if err != nil {
+		r.t.Errorf("webhook did not verify: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+	w.WriteHeader(r.status)
+}
+
+func newTestFakeGateway(t *testing.T) (*FakeGateway, *webhookRecorder) {
+	t.Helper()
+	recorder := &webhookRecorder{t: t, status: http.StatusOK}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+
+	gateway, err := NewFakeGateway(config.PaymentConfig{
+		Provider:      "fake",
+		AllowFake:     true,
+		WebhookSecret: testWebhookSecret,
+		WebhookURL:    server.URL + "/v1/payments/webhook",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.gateway = gateway
+	return gateway, recorder
+}
+
+func TestFakeGatewayComplete(t *testing.T) {
+	tests := []struct {
+		outcome string
+		want    string
+	}{
+		{outcome: FakeOutcomePaid, want: EventCheckoutCompleted},
+		{outcome: FakeOutcomeFailed, want: EventCheckoutFailed},
+		{outcome: FakeOutcomeExpired, want: EventCheckoutExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			gateway, recorder := newTestFakeGateway(t)
+			session, err := gateway.CreateCheckout(context.Background(), CheckoutRequest{Reference: "42", UserID: 7, Amount: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			eventID, err := gateway.Complete(context.Background(), session.ID, tt.outcome, "")
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+
+			if len(recorder.events) != 1 {
+				t.Fatalf("got %d webhooks, want 1", len(recorder.events))
+			}
+			got := recorder.events[0]
+			if got.ID != eventID || got.Type != tt.want || got.CheckoutID != session.ID || got.Reference != "42" {
+				t.Errorf("got %+v, want event %q of type %q for checkout %q", *got, eventID, tt.want, session.ID)
+			}
+		})
+	}
+}
+
+func TestFakeGatewayCompleteResendsEvent(t *testing.T) {
+	gateway, recorder := newTestFakeGateway(t)
+	session, err := gateway.CreateCheckout(context.Background(), CheckoutRequest{Reference: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventID, err := gateway.Complete(context.Background(), session.ID, FakeOutcomePaid, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resent, err := gateway.Complete(context.Background(), session.ID, FakeOutcomePaid, eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resent != eventID || len(recorder.events) != 2 || recorder.events[1].ID != eventID {
+		t.Fatalf("resend delivered %d events with id %q, want the original %q twice", len(recorder.events), resent, eventID)
+	}
+}
+
+func TestFakeGatewayCompleteErrors(t *testing.T) {
+	gateway, recorder := newTestFakeGateway(t)
+	session, err := gateway.CreateCheckout(context.Background(), CheckoutRequest{Reference: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gateway.Complete(context.Background(), "cs_fake_unknown", FakeOutcomePaid, ""); !errors.Is(err, ErrUnknownCheckout) {
+		t.Errorf("unknown checkout: got %v, want %v", err, ErrUnknownCheckout)
+	}
+	if _, err := gateway.Complete(context.Background(), session.ID, "refunded", ""); !errors.Is(err, ErrUnknownOutcome) {
+		t.Errorf("unknown outcome: got %v, want %v", err, ErrUnknownOutcome)
+	}
+
+	// Si el endpoint no responde 2xx el webhook no se dio por entregado
+	recorder.status = http.StatusInternalServerError
+	if _, err := gateway.Complete(context.Background(), session.ID, FakeOutcomePaid, ""); err == nil {
+		t.Error("failed delivery returned no error")
+	}
+}
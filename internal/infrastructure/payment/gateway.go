@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"luthierSaas/internal/infrastructure/config"
+	"math"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidPayload   = errors.New("invalid webhook payload")
+)
+
+// Tipos de evento normalizados, cada pasarela traduce los suyos a estos
+const (
+	EventCheckoutCompleted = "checkout.completed"
+	EventCheckoutFailed    = "checkout.failed"
+	EventCheckoutExpired   = "checkout.expired"
+	// EventIgnored son los eventos que la pasarela manda pero no cambian ninguna suscripción
+	EventIgnored = "ignored"
+)
+
+// CheckoutRequest describe el cobro de un período de un plan. Reference vuelve en los webhooks
+// para encontrar la suscripción pendiente.
+type CheckoutRequest struct {
+	Reference string
+	// UserID es el dueño del checkout, la pasarela simulada solo se lo deja cerrar a él
+	UserID        int
+	CustomerEmail string
+	Description   string
+	Amount        float64
+	SuccessURL    string
+	CancelURL     string
+}
+
+// CheckoutSession es la página de pago a la que se manda al usuario
+type CheckoutSession struct {
+	ID  string
+	URL string
+}
+
+// Event es un webhook ya verificado
+type Event struct {
+	ID         string
+	Type       string
+	RawType    string
+	CheckoutID string
+	Reference  string
+}
+
+// PaymentGateway abstrae la pasarela de cobro: crea el checkout y verifica los webhooks que
+// informan cómo terminó
+type PaymentGateway interface {
+	Name() string
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*CheckoutSession, error)
+	ParseWebhook(header http.Header, payload []byte) (*Event, error)
+}
+
+// NewGatewayFromConfig arma la pasarela configurada
+func NewGatewayFromConfig(cfg config.PaymentConfig) (PaymentGateway, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	switch cfg.Provider {
+	case "stripe":
+		if cfg.SecretKey == "" || cfg.WebhookSecret == "" {
+			return nil, errors.New("stripe needs PAYMENT_SECRET_KEY and PAYMENT_WEBHOOK_SECRET")
+		}
+		return NewStripeGateway(cfg, httpClient), nil
+	case "fake":
+		if !cfg.AllowFake {
+			return nil, errors.New("the fake payment gateway activates plans without charging, it needs APP_ENV=development")
+		}
+		return NewFakeGateway(cfg, httpClient)
+	case "":
+		return nil, errors.New("PAYMENT_PROVIDER is required outside APP_ENV=development")
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
+
+// minorUnits pasa el precio a centavos, que es como las pasarelas reciben los montos
+func minorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,181 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"luthierSaas/internal/infrastructure/config"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stripeAPIURL = "https://api.stripe.com/v1"
+	// Un webhook firmado hace más de 5 minutos se toma como repetido por un tercero
+	stripeSignatureTolerance = 5 * time.Minute
+)
+
+// StripeGateway cobra cada período con una Checkout Session de pago único
+type StripeGateway struct {
+	secretKey     string
+	webhookSecret string
+	currency      string
+	httpClient    *http.Client
+}
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID                string `json:"id"`
+			ClientReferenceID string `json:"client_reference_id"`
+			PaymentStatus     string `json:"payment_status"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func NewStripeGateway(cfg config.PaymentConfig, httpClient *http.Client) *StripeGateway {
+	return &StripeGateway{
+		secretKey:     cfg.SecretKey,
+		webhookSecret: cfg.WebhookSecret,
+		currency:      cfg.Currency,
+		httpClient:    httpClient,
+	}
+}
+
+func (g *StripeGateway) Name() string {
+	return "stripe"
+}
+
+func (g *StripeGateway) CreateCheckout(ctx context.Context, req CheckoutRequest) (*CheckoutSession, error) {
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("client_reference_id", req.Reference)
+	form.Set("customer_email", req.CustomerEmail)
+	form.Set("success_url", req.SuccessURL)
+	form.Set("cancel_url", req.CancelURL)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("line_items[0][price_data][currency]", g.currency)
+	form.Set("line_items[0][price_data][unit_amount]", strconv.FormatInt(minorUnits(req.Amount), 10))
+	form.Set("line_items[0][price_data][product_data][name]", req.Description)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, stripeAPIURL+"/checkout/sessions", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+g.secretKey)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Si el request se reintenta, Stripe devuelve la misma sesión en lugar de crear otra
+	httpReq.Header.Set("Idempotency-Key", "checkout-"+req.Reference)
+
+	resp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return nil, fmt.Errorf("stripe returned %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+
+	var session struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return nil, fmt.Errorf("failed to decode checkout session: %w", err)
+	}
+	return &CheckoutSession{ID: session.ID, URL: session.URL}, nil
+}
+
+func (g *StripeGateway) ParseWebhook(header http.Header, payload []byte) (*Event, error) {
+	return parseStripeWebhook(header, payload, g.webhookSecret, time.Now())
+}
+
+// parseStripeWebhook verifica el header Stripe-Signature ("t=<unix>,v1=<hmac>") y traduce el evento.
+// La pasarela simulada firma igual, así los dos recorren el mismo camino.
+func parseStripeWebhook(header http.Header, payload []byte, secret string, now time.Time) (*Event, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	signedAt := time.Unix(unix, 0)
+	if now.Sub(signedAt) > stripeSignatureTolerance || signedAt.Sub(now) > stripeSignatureTolerance {
+		return nil, ErrInvalidSignature
+	}
+
+	expected := []byte(signStripePayload(secret, timestamp, payload))
+	valid := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	var raw stripeEvent
+	if err := json.Unmarshal(payload, &raw); err != nil || raw.ID == "" {
+		return nil, ErrInvalidPayload
+	}
+
+	event := &Event{
+		ID:         raw.ID,
+		Type:       EventIgnored,
+		RawType:    raw.Type,
+		CheckoutID: raw.Data.Object.ID,
+		Reference:  raw.Data.Object.ClientReferenceID,
+	}
+	switch raw.Type {
+	case "checkout.session.completed":
+		// Los medios de pago diferidos completan el checkout sin cobrar, el resultado llega después
+		if raw.Data.Object.PaymentStatus == "paid" {
+			event.Type = EventCheckoutCompleted
+		}
+	case "checkout.session.async_payment_succeeded":
+		event.Type = EventCheckoutCompleted
+	case "checkout.session.async_payment_failed":
+		event.Type = EventCheckoutFailed
+	case "checkout.session.expired":
+		event.Type = EventCheckoutExpired
+	}
+	return event, nil
+}
+
+func signStripePayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
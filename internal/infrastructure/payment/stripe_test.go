@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func signedHeader(secret string, signedAt time.Time, payload []byte) http.Header {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	header := http.Header{}
+	header.Set("Stripe-Signature", fmt.Sprintf("t=%s,v1=%s", timestamp, signStripePayload(secret, timestamp, payload)))
+	return header
+}
+
+func TestParseStripeWebhook(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	payload := []byte(`{"id":"evt_1","type":"checkout.session.completed","data":{"object":{"id":"cs_1","client_reference_id":"42","payment_status":"paid"}}}`)
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		payload []byte
+		wantErr error
+	}{
+		{name: "valid", header: signedHeader(testWebhookSecret, now, payload), payload: payload},
+		{name: "bad signature", header: signedHeader("whsec_other", now, payload), payload: payload, wantErr: ErrInvalidSignature},
+		{name: "tampered payload", header: signedHeader(testWebhookSecret, now, payload), payload: []byte(`{"id":"evt_2"}`), wantErr: ErrInvalidSignature},
+		{name: "missing header", header: http.Header{}, payload: payload, wantErr: ErrInvalidSignature},
+		{name: "timestamp too old", header: signedHeader(testWebhookSecret, now.Add(-6*time.Minute), payload), payload: payload, wantErr: ErrInvalidSignature},
+		{name: "timestamp in the future", header: signedHeader(testWebhookSecret, now.Add(6*time.Minute), payload), payload: payload, wantErr: ErrInvalidSignature},
+		{name: "within tolerance", header: signedHeader(testWebhookSecret, now.Add(-4*time.Minute), payload), payload: payload},
+		{name: "missing event id", header: signedHeader(testWebhookSecret, now, []byte(`{"type":"checkout.session.completed"}`)), payload: []byte(`{"type":"checkout.session.completed"}`), wantErr: ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseStripeWebhook(tt.header, tt.payload, testWebhookSecret, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want := Event{ID: "evt_1", Type: EventCheckoutCompleted, RawType: "checkout.session.completed", CheckoutID: "cs_1", Reference: "42"}
+			if *event != want {
+				t.Errorf("got %+v, want %+v", *event, want)
+			}
+		})
+	}
+}
+
+// Un webhook capturado se puede volver a mandar tal cual: pasa mientras la firma está dentro de la
+// tolerancia (el id repetido lo descarta el caso de uso) y se rechaza después
+func TestParseStripeWebhookReplay(t *testing.T) {
+	signedAt := time.Unix(1_700_000_000, 0)
+	payload := []byte(`{"id":"evt_1","type":"checkout.session.completed","data":{"object":{"id":"cs_1","payment_status":"paid"}}}`)
+	header := signedHeader(testWebhookSecret, signedAt, payload)
+
+	first, err := parseStripeWebhook(header, payload, testWebhookSecret, signedAt)
+	if err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	again, err := parseStripeWebhook(header, payload, testWebhookSecret, signedAt.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("replay within tolerance: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("replayed event id %q, want %q", again.ID, first.ID)
+	}
+
+	if _, err := parseStripeWebhook(header, payload, testWebhookSecret, signedAt.Add(10*time.Minute)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("late replay: got %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestParseStripeWebhookEventTypes(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		rawType       string
+		paymentStatus string
+		want          string
+	}{
+		{rawType: "checkout.session.completed", paymentStatus: "paid", want: EventCheckoutCompleted},
+		{rawType: "checkout.session.completed", paymentStatus: "unpaid", want: EventIgnored},
+		{rawType: "checkout.session.async_payment_succeeded", want: EventCheckoutCompleted},
+		{rawType: "checkout.session.async_payment_failed", want: EventCheckoutFailed},
+		{rawType: "checkout.session.expired", want: EventCheckoutExpired},
+		{rawType: "customer.created", want: EventIgnored},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawType+"/"+tt.paymentStatus, func(t *testing.T) {
+			payload := []byte(fmt.Sprintf(`{"id":"evt_1","type":%q,"data":{"object":{"id":"cs_1","payment_status":%q}}}`, tt.rawType, tt.paymentStatus))
+			event, err := parseStripeWebhook(signedHeader(testWebhookSecret, now, payload), payload, testWebhookSecret, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Type != tt.want {
+				t.Errorf("got %q, want %q", event.Type, tt.want)
+			}
+		})
+	}
+}
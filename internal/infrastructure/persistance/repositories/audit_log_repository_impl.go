@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type auditLogRepository struct {
+	db *sql.DB
+}
+
+func NewAuditLogRepository(db *sql.DB) repository.AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *entities.AuditLog) error {
+	query := `INSERT INTO audit_logs (actor_id, action, target_user_id, session_id, ip, metadata)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query,
+		sql.NullInt64{Int64: int64(entry.ActorID), Valid: entry.ActorID != 0},
+		entry.Action,
+		sql.NullInt64{Int64: int64(entry.TargetUserID), Valid: entry.TargetUserID != 0},
+		sql.NullInt64{Int64: int64(entry.SessionID), Valid: entry.SessionID != 0},
+		entry.IP,
+		sql.NullString{String: entry.Metadata, Valid: entry.Metadata != ""},
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = int(id)
+	return nil
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+const sessionCacheTTL = 5 * time.Minute
+
+// cachedSessionRepository evita consultar MySQL en cada request autenticado.
+// Toda operación que invalida o borra una sesión limpia su entrada del cache,
+// así una sesión revocada deja de funcionar en el mismo momento.
+//
+// Un request que leyó la sesión de la base justo antes de la revocación podría volver a guardarla
+// en el cache después del borrado. Para evitarlo, antes de tocar la base se deja una marca de
+// revocación y el cache solo se llena si la marca no existe.
+type cachedSessionRepository struct {
+	repository.SessionRepository
+	cache *cache.Cache
+}
+
+func NewCachedSessionRepository(inner repository.SessionRepository, cacheService *cache.Cache) repository.SessionRepository {
+	return &cachedSessionRepository{SessionRepository: inner, cache: cacheService}
+}
+
+func sessionCacheKey(accessTokenHash string) string {
+	return fmt.Sprintf("session:access:%s", accessTokenHash)
+}
+
+// sessionRevokedKey marca una sesión que se está revocando. Dura lo mismo que una entrada del cache,
+// alcanza para cualquier lectura de la base que haya empezado antes.
+func sessionRevokedKey(accessTokenHash string) string {
+	return fmt.Sprintf("session:revoked:%s", accessTokenHash)
+}
+
+func (r *cachedSessionRepository) FindByAccessTokenHash(ctx context.Context, accessTokenHash string) (*entities.Session, error) {
+	cacheKey := sessionCacheKey(accessTokenHash)
+
+	cached, err := r.cache.Get(ctx, cacheKey)
+	if err == nil && cached != "" {
+		var session entities.Session
+		if err := json.Unmarshal([]byte(cached), &session); err == nil && session.IsValid && time.Now().Before(session.ExpiresAt) {
+			return &session, nil
+		}
+	}
+
+	session, err := r.SessionRepository.FindByAccessTokenHash(ctx, accessTokenHash)
+	if err != nil || session == nil {
+		return session, err
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+	if ttl > sessionCacheTTL {
+		ttl = sessionCacheTTL
+	}
+	if ttl > 0 {
+		if data, err := json.Marshal(session); err == nil {
+			if _, err := r.cache.SetUnless(ctx, cacheKey, sessionRevokedKey(accessTokenHash), string(data), ttl); err != nil {
+				fmt.Printf("Failed to set cache for %s: %v\n", cacheKey, err)
+			}
+		}
+	}
+
+	return session, nil
+}
+
+func (r *cachedSessionRepository) Update(ctx context.Context, session *entities.Session) error {
+	// El hash anterior puede no coincidir con el nuevo, se busca por id antes de actualizar.
+	// Solo el anterior se marca como revocado, el nuevo tiene que poder cachearse.
+	hashes := []string{session.AccessTokenHash}
+	if previous, err := r.findByID(ctx, session.UserID, session.ID); err == nil && previous != nil {
+		r.revoke(ctx, previous.AccessTokenHash)
+		hashes = append(hashes, previous.AccessTokenHash)
+	}
+	if err := r.SessionRepository.Update(ctx, session); err != nil {
+		return err
+	}
+	r.forget(ctx, hashes...)
+	return nil
+}
+
+func (r *cachedSessionRepository) Invalidate(ctx context.Context, accessTokenHash string) error {
+	r.revoke(ctx, accessTokenHash)
+	if err := r.SessionRepository.Invalidate(ctx, accessTokenHash); err != nil {
+		return err
+	}
+	return r.cache.Delete(ctx, sessionCacheKey(accessTokenHash))
+}
+
+func (r *cachedSessionRepository) InvalidateByUserID(ctx context.Context, userID int) error {
+	sessions, err := r.SessionRepository.FindByUserID(ctx, int64(userID))
+	if err != nil {
+		return err
+	}
+
+	hashes := accessTokenHashes(sessions)
+	r.revoke(ctx, hashes...)
+	if err := r.SessionRepository.InvalidateByUserID(ctx, userID); err != nil {
+		return err
+	}
+	r.forget(ctx, hashes...)
+	return nil
+}
+
+func (r *cachedSessionRepository) InvalidateFamily(ctx context.Context, familyID string) error {
+	sessions, err := r.SessionRepository.FindByFamilyID(ctx, familyID)
+	if err != nil {
+		return err
+	}
+
+	hashes := accessTokenHashes(sessions)
+	r.revoke(ctx, hashes...)
+	if err := r.SessionRepository.InvalidateFamily(ctx, familyID); err != nil {
+		return err
+	}
+	r.forget(ctx, hashes...)
+	return nil
+}
+
+func (r *cachedSessionRepository) MarkConsumed(ctx context.Context, accessTokenHash string) (bool, error) {
+	r.revoke(ctx, accessTokenHash)
+	consumed, err := r.SessionRepository.MarkConsumed(ctx, accessTokenHash)
+	if err != nil {
+		return false, err
+	}
+	return consumed, r.cache.Delete(ctx, sessionCacheKey(accessTokenHash))
+}
+
+func (r *cachedSessionRepository) Delete(ctx context.Context, accessTokenHash string) error {
+	r.revoke(ctx, accessTokenHash)
+	if err := r.SessionRepository.Delete(ctx, accessTokenHash); err != nil {
+		return err
+	}
+	return r.cache.Delete(ctx, sessionCacheKey(accessTokenHash))
+}
+
+func (r *cachedSessionRepository) findByID(ctx context.Context, userID, sessionID int) (*entities.Session, error) {
+	sessions, err := r.SessionRepository.FindByUserID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return session, nil
+		}
+	}
+	return nil, nil
+}
+
+// revoke deja la marca de revocación de cada sesión, va antes de cambiarlas en la base
+func (r *cachedSessionRepository) revoke(ctx context.Context, accessTokenHashes ...string) {
+	for _, accessTokenHash := range accessTokenHashes {
+		_ = r.cache.Set(ctx, sessionRevokedKey(accessTokenHash), "1", sessionCacheTTL)
+	}
+}
+
+// forget borra las sesiones del cache, va después de cambiarlas en la base
+func (r *cachedSessionRepository) forget(ctx context.Context, accessTokenHashes ...string) {
+	for _, accessTokenHash := range accessTokenHashes {
+		_ = r.cache.Delete(ctx, sessionCacheKey(accessTokenHash))
+	}
+}
+
+func accessTokenHashes(sessions []*entities.Session) []string {
+	hashes := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		hashes = append(hashes, session.AccessTokenHash)
+	}
+	return hashes
+}
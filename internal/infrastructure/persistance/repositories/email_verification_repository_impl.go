@@ -19,22 +19,23 @@ func NewEmailVerificationRepository(db *sql.DB) repository.EmailVerificationRepo
 }
 
 func (r *emailVerificationRepository) Create(ctx context.Context, ev *entities.EmailVerification) error {
-	query := `INSERT INTO email_verifications (user_id, verification_code, expires_at, verified)
+	query := `INSERT INTO email_verifications (user_id, code_hash, expires_at, verified)
 			  VALUES (?, ?, ?, ?)`
-	_, err := r.db.ExecContext(ctx, query, ev.UserID, ev.Code, ev.ExpiresAt, ev.Verified)
+	_, err := r.db.ExecContext(ctx, query, ev.UserID, ev.CodeHash, ev.ExpiresAt, ev.Verified)
 	return err
 }
 
 func (r *emailVerificationRepository) GetByUserID(ctx context.Context, userID int) (*entities.EmailVerification, error) {
-	query := `SELECT id, user_id, verification_code, expires_at, verified, created_at, updated_at
-			  FROM email_verifications WHERE user_id = ?`
+	query := `SELECT id, user_id, code_hash, attempts, expires_at, verified, created_at, updated_at
+			  FROM email_verifications WHERE user_id = ? ORDER BY id DESC LIMIT 1`
 	row := r.db.QueryRowContext(ctx, query, userID)
 
 	var ev entities.EmailVerification
 	err := row.Scan(
 		&ev.ID,
 		&ev.UserID,
-		&ev.Code,
+		&ev.CodeHash,
+		&ev.Attempts,
 		&ev.ExpiresAt,
 		&ev.Verified,
 		&ev.CreatedAt,
@@ -60,8 +61,24 @@ func (r *emailVerificationRepository) DeleteByUserID(ctx context.Context, userID
 	return err
 }
 
-func (r *emailVerificationRepository) UpdateCode(ctx context.Context, id int, newCode string, newExpiresAt time.Time) error {
-	query := `UPDATE email_verifications SET verification_code = ?, expires_at = ? WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, newCode,newExpiresAt, id)
+// UpdateCode reemplaza el código y reinicia el contador de intentos
+func (r *emailVerificationRepository) UpdateCode(ctx context.Context, id int, newCodeHash string, newExpiresAt time.Time) error {
+	query := `UPDATE email_verifications SET code_hash = ?, expires_at = ?, attempts = 0 WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, newCodeHash, newExpiresAt, id)
 	return err
+}
+
+// IncrementAttempts suma un intento fallido y devuelve el total. LAST_INSERT_ID(expr) deja el valor
+// nuevo en la conexión, así dos intentos simultáneos no leen el mismo número.
+func (r *emailVerificationRepository) IncrementAttempts(ctx context.Context, id int) (int, error) {
+	query := `UPDATE email_verifications SET attempts = LAST_INSERT_ID(attempts + 1) WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return 0, err
+	}
+	attempts, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(attempts), nil
 }
\ No newline at end of file
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type mfaRecoveryCodeRepository struct {
+	db *sql.DB
+}
+
+func NewMFARecoveryCodeRepository(db *sql.DB) repository.MFARecoveryCodeRepository {
+	return &mfaRecoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser borra los códigos anteriores y guarda los nuevos en una única transacción
+func (r *mfaRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *mfaRecoveryCodeRepository) Consume(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE mfa_recovery_codes SET used = TRUE WHERE user_id = ? AND code_hash = ? AND used = FALSE`
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *mfaRecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID int) error {
+	query := `DELETE FROM mfa_recovery_codes WHERE user_id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
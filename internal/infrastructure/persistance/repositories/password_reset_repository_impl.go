@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type passwordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) repository.PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, reset *entities.PasswordReset) error {
+	query := `INSERT INTO password_resets (user_id, reset_token, expires_at, used)
+			  VALUES (?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, reset.UserID, reset.TokenHash, reset.ExpiresAt, reset.Used)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	reset.ID = int(id)
+	return nil
+}
+
+func (r *passwordResetRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.PasswordReset, error) {
+	query := `SELECT id, user_id, reset_token, expires_at, used, created_at, updated_at
+			  FROM password_resets WHERE reset_token = ?`
+	row := r.db.QueryRowContext(ctx, query, tokenHash)
+
+	var reset entities.PasswordReset
+	err := row.Scan(
+		&reset.ID,
+		&reset.UserID,
+		&reset.TokenHash,
+		&reset.ExpiresAt,
+		&reset.Used,
+		&reset.CreatedAt,
+		&reset.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+func (r *passwordResetRepository) MarkAsUsed(ctx context.Context, id int) (bool, error) {
+	query := `UPDATE password_resets SET used = TRUE WHERE id = ? AND used = FALSE`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *passwordResetRepository) InvalidateByUserID(ctx context.Context, userID int) error {
+	query := `UPDATE password_resets SET used = TRUE WHERE user_id = ? AND used = FALSE`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type paymentEventRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentEventRepository(db *sql.DB) repository.PaymentEventRepository {
+	return &paymentEventRepository{db: db}
+}
+
+func (r *paymentEventRepository) Exists(ctx context.Context, provider, eventID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM payment_events WHERE provider = ? AND event_id = ?)`
+	if err := r.db.QueryRowContext(ctx, query, provider, eventID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *paymentEventRepository) Create(ctx context.Context, event *entities.PaymentEvent) error {
+	query := `INSERT IGNORE INTO payment_events (provider, event_id, type, subscription_id) VALUES (?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query,
+		event.Provider,
+		event.EventID,
+		event.Type,
+		sql.NullInt64{Int64: int64(event.SubscriptionID), Valid: event.SubscriptionID != 0},
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event.ID = int(id)
+	return nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type permissionRepository struct {
+	db *sql.DB
+}
+
+func NewPermissionRepository(db *sql.DB) repository.PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+func (r *permissionRepository) FindByRole(ctx context.Context, role string) ([]string, error) {
+	query := `SELECT p.name FROM role_permissions rp
+			  JOIN permissions p ON p.id = rp.permission_id
+			  WHERE rp.role = ?
+			  ORDER BY p.name`
+	rows, err := r.db.QueryContext(ctx, query, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, name)
+	}
+
+	return permissions, rows.Err()
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type personalAccessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPersonalAccessTokenRepository(db *sql.DB) repository.PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+const personalAccessTokenColumns = `id, user_id, name, token_hash, token_prefix, scopes, expires_at,
+	last_used_at, last_used_ip, created_at`
+
+func scanPersonalAccessToken(row rowScanner) (*entities.PersonalAccessToken, error) {
+	var token entities.PersonalAccessToken
+	var scopes string
+	var lastUsedAt sql.NullTime
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.Prefix,
+		&scopes,
+		&token.ExpiresAt,
+		&lastUsedAt,
+		&token.LastUsedIP,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = []string{}
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = lastUsedAt.Time
+	}
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *entities.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.Prefix,
+		strings.Join(token.Scopes, ","),
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	token.ID = int(id)
+	return nil
+}
+
+func (r *personalAccessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*entities.PersonalAccessToken, error) {
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = ?`
+	token, err := scanPersonalAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return token, err
+}
+
+func (r *personalAccessTokenRepository) FindByUserID(ctx context.Context, userID int) ([]*entities.PersonalAccessToken, error) {
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*entities.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *personalAccessTokenRepository) CountByUserID(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = ?`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *personalAccessTokenRepository) UpdateLastUsed(ctx context.Context, id int, usedAt time.Time, ip string) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = ?, last_used_ip = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, usedAt, ip, id)
+	return err
+}
+
+// Delete devuelve false si el token no existe o pertenece a otro usuario
+func (r *personalAccessTokenRepository) Delete(ctx context.Context, userID, id int) (bool, error) {
+	query := `DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeleteByUserID revoca todos los tokens del usuario, va junto con cerrar sus sesiones
+func (r *personalAccessTokenRepository) DeleteByUserID(ctx context.Context, userID int) error {
+	query := `DELETE FROM personal_access_tokens WHERE user_id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"luthierSaas/internal/domain/entities"
 	"luthierSaas/internal/interfaces/repository"
@@ -13,34 +14,19 @@ type sessionRepository struct {
     db *sql.DB
 }
 
+const sessionColumns = `id, user_id, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at, is_valid, device_info, ip_address, family_id, consumed, impersonator_id, created_at, updated_at`
+
 func NewSessionRepository(db *sql.DB) repository.SessionRepository {
     return &sessionRepository{db: db}
 }
 
-func (r *sessionRepository) Create(ctx context.Context, session *entities.Session) error {
-    query := `INSERT INTO sessions (user_id, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at, is_valid, device_info)
-              VALUES (?, ?, ?, ?, ?, ?, ?)`
-    _, err := r.db.ExecContext(ctx, query,
-        session.UserID,
-        session.AccessTokenHash,
-        session.RefreshTokenHash,
-        session.ExpiresAt,
-        session.RefreshExpiresAt,
-        session.IsValid,
-        session.DeviceInfo,
-    )
-    return err
-}
-
-func (r *sessionRepository) FindByAccessTokenHash(ctx context.Context, accessTokenHash string) (*entities.Session, error) {
-    query := `SELECT id, user_id, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at, is_valid, device_info, created_at, updated_at
-              FROM sessions WHERE access_token_hash = ? AND is_valid = TRUE AND expires_at > NOW()`
-    row := r.db.QueryRowContext(ctx, query, accessTokenHash)
-
+func scanSession(row rowScanner) (*entities.Session, error) {
     var session entities.Session
     var refreshExpiresAt sql.NullTime
     var updatedAt sql.NullTime
     var deviceInfo sql.NullString
+    var familyID sql.NullString
+    var impersonatorID sql.NullInt64
 
     err := row.Scan(
         &session.ID,
@@ -51,13 +37,13 @@ func (r *sessionRepository) FindByAccessTokenHash(ctx context.Context, accessTok
         &refreshExpiresAt,
         &session.IsValid,
         &deviceInfo,
+        &session.IPAddress,
+        &familyID,
+        &session.Consumed,
+        &impersonatorID,
         &session.CreatedAt,
         &updatedAt,
     )
-
-    if errors.Is(err, sql.ErrNoRows) {
-        return nil, nil
-    }
     if err != nil {
         return nil, err
     }
@@ -71,51 +57,93 @@ func (r *sessionRepository) FindByAccessTokenHash(ctx context.Context, accessTok
     if deviceInfo.Valid {
         session.DeviceInfo = deviceInfo.String
     }
+    if familyID.Valid {
+        session.FamilyID = familyID.String
+    }
+    if impersonatorID.Valid {
+        session.ImpersonatorID = int(impersonatorID.Int64)
+    }
 
     return &session, nil
 }
 
-func (r *sessionRepository) FindByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entities.Session, error) {
-    query := `SELECT id, user_id, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at, is_valid, device_info, created_at, updated_at
-              FROM sessions WHERE refresh_token_hash = ? AND is_valid = TRUE AND refresh_expires_at > NOW()`
-    row := r.db.QueryRowContext(ctx, query, refreshTokenHash)
-
-    var session entities.Session
-    var refreshExpiresAt sql.NullTime
-    var updatedAt sql.NullTime
-    var deviceInfo sql.NullString
-
-    err := row.Scan(
-        &session.ID,
-        &session.UserID,
-        &session.AccessTokenHash,
-        &session.RefreshTokenHash,
-        &session.ExpiresAt,
-        &refreshExpiresAt,
-        &session.IsValid,
-        &deviceInfo,
-        &session.CreatedAt,
-        &updatedAt,
-    )
-
+func (r *sessionRepository) findOne(ctx context.Context, query string, args ...any) (*entities.Session, error) {
+    session, err := scanSession(r.db.QueryRowContext(ctx, query, args...))
     if errors.Is(err, sql.ErrNoRows) {
         return nil, nil
     }
+    return session, err
+}
+
+func (r *sessionRepository) findMany(ctx context.Context, query string, args ...any) ([]*entities.Session, error) {
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
+    defer rows.Close()
 
-    if refreshExpiresAt.Valid {
-        session.RefreshExpiresAt = refreshExpiresAt.Time
+    var sessions []*entities.Session
+    for rows.Next() {
+        session, err := scanSession(rows)
+        if err != nil {
+            return nil, err
+        }
+        sessions = append(sessions, session)
     }
-    if updatedAt.Valid {
-        session.UpdatedAt = updatedAt.Time
+
+    return sessions, rows.Err()
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entities.Session) error {
+    query := `INSERT INTO sessions (user_id, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at, is_valid, device_info, ip_address, family_id, impersonator_id)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+    result, err := r.db.ExecContext(ctx, query,
+        session.UserID,
+        session.AccessTokenHash,
+        session.RefreshTokenHash,
+        session.ExpiresAt,
+        session.RefreshExpiresAt,
+        session.IsValid,
+        session.DeviceInfo,
+        session.IPAddress,
+        sql.NullString{String: session.FamilyID, Valid: session.FamilyID != ""},
+        sql.NullInt64{Int64: int64(session.ImpersonatorID), Valid: session.ImpersonatorID != 0},
+    )
+    if err != nil {
+        return err
     }
-    if deviceInfo.Valid {
-        session.DeviceInfo = deviceInfo.String
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        return err
     }
 
-    return &session, nil
+    session.ID = int(id)
+    return nil
+}
+
+func (r *sessionRepository) FindByAccessTokenHash(ctx context.Context, accessTokenHash string) (*entities.Session, error) {
+    query := `SELECT ` + sessionColumns + `
+              FROM sessions WHERE access_token_hash = ? AND is_valid = TRUE AND expires_at > NOW()`
+    return r.findOne(ctx, query, accessTokenHash)
+}
+
+func (r *sessionRepository) FindByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entities.Session, error) {
+    query := `SELECT ` + sessionColumns + `
+              FROM sessions WHERE refresh_token_hash = ? AND is_valid = TRUE AND refresh_expires_at > NOW()`
+    return r.findOne(ctx, query, refreshTokenHash)
+}
+
+func (r *sessionRepository) FindAnyByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entities.Session, error) {
+    query := `SELECT ` + sessionColumns + `
+              FROM sessions WHERE refresh_token_hash = ?`
+    return r.findOne(ctx, query, refreshTokenHash)
+}
+
+func (r *sessionRepository) FindByFamilyID(ctx context.Context, familyID string) ([]*entities.Session, error) {
+    query := `SELECT ` + sessionColumns + `
+              FROM sessions WHERE family_id = ?`
+    return r.findMany(ctx, query, familyID)
 }
 
 func (r *sessionRepository) Update(ctx context.Context, session *entities.Session) error {
@@ -138,56 +166,48 @@ func (r *sessionRepository) Invalidate(ctx context.Context, accessTokenHash stri
     _, err := r.db.ExecContext(ctx, query, accessTokenHash)
     return err
 }
-func (r *sessionRepository) Delete(ctx context.Context, accessTokenHash string) error {
-    query := `DELETE FROM sessions WHERE access_token_hash = ?`
-    _, err := r.db.ExecContext(ctx, query, accessTokenHash)
+
+func (r *sessionRepository) InvalidateByUserID(ctx context.Context, userID int) error {
+    query := `UPDATE sessions SET is_valid = FALSE, updated_at = NOW() WHERE user_id = ? AND is_valid = TRUE`
+    _, err := r.db.ExecContext(ctx, query, userID)
     return err
 }
 
-func (r *sessionRepository) FindByUserID(ctx context.Context, userID int64) ([]*entities.Session, error) {
-    query := `SELECT id, user_id, access_token_hash, refresh_token_hash, expires_at, refresh_expires_at, is_valid, device_info, created_at, updated_at
-              FROM sessions WHERE user_id = ? AND is_valid = TRUE`
-    rows, err := r.db.QueryContext(ctx, query, userID)
+func (r *sessionRepository) InvalidateFamily(ctx context.Context, familyID string) error {
+    query := `UPDATE sessions SET is_valid = FALSE, updated_at = NOW() WHERE family_id = ? AND is_valid = TRUE`
+    _, err := r.db.ExecContext(ctx, query, familyID)
+    return err
+}
+
+func (r *sessionRepository) MarkConsumed(ctx context.Context, accessTokenHash string) (bool, error) {
+    query := `UPDATE sessions SET consumed = TRUE, is_valid = FALSE, updated_at = NOW() WHERE access_token_hash = ? AND consumed = FALSE`
+    result, err := r.db.ExecContext(ctx, query, accessTokenHash)
     if err != nil {
-        return nil, err
+        return false, err
     }
-    defer rows.Close()
 
-    var sessions []*entities.Session
-    for rows.Next() {
-        var session entities.Session
-        var refreshExpiresAt sql.NullTime
-        var updatedAt sql.NullTime
-        var deviceInfo sql.NullString
-
-        err := rows.Scan(
-            &session.ID,
-            &session.UserID,
-            &session.AccessTokenHash,
-            &session.RefreshTokenHash,
-            &session.ExpiresAt,
-            &refreshExpiresAt,
-            &session.IsValid,
-            &deviceInfo,
-            &session.CreatedAt,
-            &updatedAt,
-        )
-        if err != nil {
-            return nil, err
-        }
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return false, err
+    }
 
-        if refreshExpiresAt.Valid {
-            session.RefreshExpiresAt = refreshExpiresAt.Time
-        }
-        if updatedAt.Valid {
-            session.UpdatedAt = updatedAt.Time
-        }
-        if deviceInfo.Valid {
-            session.DeviceInfo = deviceInfo.String
-        }
+    return rowsAffected > 0, nil
+}
 
-        sessions = append(sessions, &session)
-    }
+func (r *sessionRepository) Delete(ctx context.Context, accessTokenHash string) error {
+    query := `DELETE FROM sessions WHERE access_token_hash = ?`
+    _, err := r.db.ExecContext(ctx, query, accessTokenHash)
+    return err
+}
 
-    return sessions, nil
-}
\ No newline at end of file
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID int64) ([]*entities.Session, error) {
+    query := `SELECT ` + sessionColumns + `
+              FROM sessions WHERE user_id = ? AND is_valid = TRUE`
+    return r.findMany(ctx, query, userID)
+}
+
+func (r *sessionRepository) FindHistoryByUserID(ctx context.Context, userID int, since time.Time) ([]*entities.Session, error) {
+    query := `SELECT ` + sessionColumns + `
+              FROM sessions WHERE user_id = ? AND created_at >= ? ORDER BY created_at DESC`
+    return r.findMany(ctx, query, userID, since)
+}
@@ -1,9 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"luthierSaas/internal/domain/entities"
+	"time"
 )
 
 type SubscriptionRepository struct {
@@ -73,4 +76,405 @@ func (r *SubscriptionRepository) GetFreeTierPlan() (*entities.SubscriptionPlan,
         UpdatedAt:    sub.UpdatedAt,
     }
     return subscription, nil
-}
\ No newline at end of file
+}
+
+const subscriptionSelect = `
+        SELECT s.id, s.user_id, s.plan_id, sp.name, s.status, s.started_at, s.expires_at, s.cancel_at_period_end, s.canceled_at,
+            s.payment_provider, s.checkout_id, s.expiry_reminder_sent_at, s.renewal_requested_at
+        FROM subscriptions s
+        JOIN subscription_plans sp ON s.plan_id = sp.id
+    `
+
+func scanSubscription(row rowScanner) (*entities.Subscription, error) {
+    var sub entities.Subscription
+    var canceledAt sql.NullTime
+    var paymentProvider, checkoutID sql.NullString
+    var reminderSentAt, renewalRequestedAt sql.NullTime
+    err := row.Scan(&sub.ID, &sub.UserID, &sub.PlanID, &sub.PlanName, &sub.Status, &sub.StartedAt, &sub.ExpiresAt, &sub.CancelAtPeriodEnd, &canceledAt,
+        &paymentProvider, &checkoutID, &reminderSentAt, &renewalRequestedAt)
+    if err != nil {
+        return nil, err
+    }
+    sub.CanceledAt = canceledAt.Time
+    sub.PaymentProvider = paymentProvider.String
+    sub.CheckoutID = checkoutID.String
+    sub.ReminderSentAt = reminderSentAt.Time
+    sub.RenewalRequestedAt = renewalRequestedAt.Time
+    return &sub, nil
+}
+
+func (r *SubscriptionRepository) FindByUserID(ctx context.Context, userID int) ([]*entities.Subscription, error) {
+    query := subscriptionSelect + `
+        WHERE s.user_id = ?
+        ORDER BY s.started_at DESC, s.id DESC
+    `
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query subscriptions for user %d: %w", userID, err)
+    }
+    defer rows.Close()
+
+    subscriptions := []*entities.Subscription{}
+    for rows.Next() {
+        sub, err := scanSubscription(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan subscription: %w", err)
+        }
+        subscriptions = append(subscriptions, sub)
+    }
+    return subscriptions, rows.Err()
+}
+
+func (r *SubscriptionRepository) findOne(ctx context.Context, userID int, status string) (*entities.Subscription, error) {
+    query := subscriptionSelect + `
+        WHERE s.user_id = ? AND s.status = ?
+        ORDER BY s.started_at DESC, s.id DESC
+        LIMIT 1
+    `
+    sub, err := scanSubscription(r.db.QueryRowContext(ctx, query, userID, status))
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to query %s subscription for user %d: %w", status, userID, err)
+    }
+    return sub, nil
+}
+
+func (r *SubscriptionRepository) FindActive(ctx context.Context, userID int) (*entities.Subscription, error) {
+    return r.findOne(ctx, userID, entities.SubscriptionStatusActive)
+}
+
+func (r *SubscriptionRepository) FindScheduled(ctx context.Context, userID int) (*entities.Subscription, error) {
+    return r.findOne(ctx, userID, entities.SubscriptionStatusScheduled)
+}
+
+func (r *SubscriptionRepository) FindPending(ctx context.Context, userID int) (*entities.Subscription, error) {
+    return r.findOne(ctx, userID, entities.SubscriptionStatusPending)
+}
+
+const planColumns = `id, name, description, price, duration_days, created_at, updated_at`
+
+func scanPlan(row rowScanner) (*entities.SubscriptionPlan, error) {
+    var plan entities.SubscriptionPlan
+    var description sql.NullString
+    err := row.Scan(&plan.ID, &plan.Name, &description, &plan.Price, &plan.DurationDays, &plan.CreatedAt, &plan.UpdatedAt)
+    if err != nil {
+        return nil, err
+    }
+    plan.Description = description.String
+    return &plan, nil
+}
+
+func (r *SubscriptionRepository) FindPlans(ctx context.Context) ([]*entities.SubscriptionPlan, error) {
+    query := `SELECT ` + planColumns + ` FROM subscription_plans ORDER BY price, id`
+    rows, err := r.db.QueryContext(ctx, query)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query subscription plans: %w", err)
+    }
+    defer rows.Close()
+
+    plans := []*entities.SubscriptionPlan{}
+    for rows.Next() {
+        plan, err := scanPlan(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan subscription plan: %w", err)
+        }
+        plans = append(plans, plan)
+    }
+    return plans, rows.Err()
+}
+
+func (r *SubscriptionRepository) FindPlanByID(ctx context.Context, planID int) (*entities.SubscriptionPlan, error) {
+    query := `SELECT ` + planColumns + ` FROM subscription_plans WHERE id = ?`
+    plan, err := scanPlan(r.db.QueryRowContext(ctx, query, planID))
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to query subscription plan %d: %w", planID, err)
+    }
+    return plan, nil
+}
+
+// Un cambio de plan o una cancelación dejan sin efecto el cambio que estaba programado
+const dropScheduledQuery = `UPDATE subscriptions SET status = 'canceled', canceled_at = NOW() WHERE user_id = ? AND status = 'scheduled'`
+
+func insertSubscription(ctx context.Context, tx *sql.Tx, subscription *entities.Subscription) error {
+    query := `
+        INSERT INTO subscriptions (user_id, plan_id, status, started_at, expires_at)
+        VALUES (?, ?, ?, ?, ?)
+    `
+    result, err := tx.ExecContext(ctx, query,
+        subscription.UserID,
+        subscription.PlanID,
+        subscription.Status,
+        subscription.StartedAt.Format("2006-01-02 15:04:05"),
+        subscription.ExpiresAt.Format("2006-01-02 15:04:05"),
+    )
+    if err != nil {
+        return fmt.Errorf("failed to save subscription for user %d: %w", subscription.UserID, err)
+    }
+
+    id, err := result.LastInsertId()
+    if err != nil {
+        return fmt.Errorf("failed to get subscription ID: %w", err)
+    }
+    subscription.ID = int(id)
+    return nil
+}
+
+// lockActive bloquea la fila hasta el fin de la transacción y confirma que la suscripción sigue activa
+func lockActive(ctx context.Context, tx *sql.Tx, subscriptionID int) (bool, error) {
+    var id int
+    err := tx.QueryRowContext(ctx, `SELECT id FROM subscriptions WHERE id = ? AND status = 'active' FOR UPDATE`, subscriptionID).Scan(&id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+const closeSubscriptionQuery = `UPDATE subscriptions SET status = 'canceled', canceled_at = NOW(), cancel_at_period_end = FALSE WHERE id = ?`
+
+func (r *SubscriptionRepository) Replace(ctx context.Context, current *entities.Subscription, next *entities.Subscription) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    if current != nil {
+        if ok, err := lockActive(ctx, tx, current.ID); err != nil || !ok {
+            return false, err
+        }
+        if _, err := tx.ExecContext(ctx, closeSubscriptionQuery, current.ID); err != nil {
+            return false, err
+        }
+    }
+    if _, err := tx.ExecContext(ctx, dropScheduledQuery, next.UserID); err != nil {
+        return false, err
+    }
+    if err := insertSubscription(ctx, tx, next); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+func (r *SubscriptionRepository) ScheduleChange(ctx context.Context, current *entities.Subscription, next *entities.Subscription) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    if ok, err := lockActive(ctx, tx, current.ID); err != nil || !ok {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE subscriptions SET cancel_at_period_end = TRUE WHERE id = ?`, current.ID); err != nil {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, dropScheduledQuery, current.UserID); err != nil {
+        return false, err
+    }
+    if err := insertSubscription(ctx, tx, next); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+func (r *SubscriptionRepository) SetCancelAtPeriodEnd(ctx context.Context, current *entities.Subscription, cancel bool) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    if ok, err := lockActive(ctx, tx, current.ID); err != nil || !ok {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE subscriptions SET cancel_at_period_end = ? WHERE id = ?`, cancel, current.ID); err != nil {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, dropScheduledQuery, current.UserID); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+func (r *SubscriptionRepository) Cancel(ctx context.Context, current *entities.Subscription) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    if ok, err := lockActive(ctx, tx, current.ID); err != nil || !ok {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, closeSubscriptionQuery, current.ID); err != nil {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, dropScheduledQuery, current.UserID); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+func (r *SubscriptionRepository) SetCheckout(ctx context.Context, subscriptionID int, provider, checkoutID string) error {
+    query := `UPDATE subscriptions SET payment_provider = ?, checkout_id = ? WHERE id = ?`
+    if _, err := r.db.ExecContext(ctx, query, provider, checkoutID, subscriptionID); err != nil {
+        return fmt.Errorf("failed to set checkout for subscription %d: %w", subscriptionID, err)
+    }
+    return nil
+}
+
+func (r *SubscriptionRepository) FindByCheckout(ctx context.Context, provider, checkoutID string) (*entities.Subscription, error) {
+    query := subscriptionSelect + `WHERE s.payment_provider = ? AND s.checkout_id = ?`
+    sub, err := scanSubscription(r.db.QueryRowContext(ctx, query, provider, checkoutID))
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to query subscription for checkout %s: %w", checkoutID, err)
+    }
+    return sub, nil
+}
+
+// lockPending es lockActive para las suscripciones que esperan el pago
+func lockPending(ctx context.Context, tx *sql.Tx, subscriptionID int) (bool, error) {
+    var id int
+    err := tx.QueryRowContext(ctx, `SELECT id FROM subscriptions WHERE id = ? AND status = 'pending' FOR UPDATE`, subscriptionID).Scan(&id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+func (r *SubscriptionRepository) Activate(ctx context.Context, pending *entities.Subscription) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    if ok, err := lockPending(ctx, tx, pending.ID); err != nil || !ok {
+        return false, err
+    }
+    closeActive := `UPDATE subscriptions SET status = 'canceled', canceled_at = NOW(), cancel_at_period_end = FALSE WHERE user_id = ? AND status = 'active'`
+    if _, err := tx.ExecContext(ctx, closeActive, pending.UserID); err != nil {
+        return false, err
+    }
+    if _, err := tx.ExecContext(ctx, dropScheduledQuery, pending.UserID); err != nil {
+        return false, err
+    }
+    activate := `UPDATE subscriptions SET status = 'active', started_at = ?, expires_at = ? WHERE id = ?`
+    if _, err := tx.ExecContext(ctx, activate,
+        pending.StartedAt.Format("2006-01-02 15:04:05"),
+        pending.ExpiresAt.Format("2006-01-02 15:04:05"),
+        pending.ID,
+    ); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+func (r *SubscriptionRepository) CancelPending(ctx context.Context, pending *entities.Subscription) (bool, error) {
+    query := `UPDATE subscriptions SET status = 'canceled', canceled_at = NOW() WHERE id = ? AND status = 'pending'`
+    result, err := r.db.ExecContext(ctx, query, pending.ID)
+    if err != nil {
+        return false, fmt.Errorf("failed to cancel pending subscription %d: %w", pending.ID, err)
+    }
+    affected, err := result.RowsAffected()
+    if err != nil {
+        return false, err
+    }
+    return affected > 0, nil
+}
+
+func (r *SubscriptionRepository) FindActiveExpiringBefore(ctx context.Context, before time.Time) ([]*entities.Subscription, error) {
+    query := subscriptionSelect + `
+        WHERE s.status = 'active' AND s.expires_at <= ?
+        ORDER BY s.expires_at
+    `
+    rows, err := r.db.QueryContext(ctx, query, before.Format("2006-01-02 15:04:05"))
+    if err != nil {
+        return nil, fmt.Errorf("failed to query expiring subscriptions: %w", err)
+    }
+    defer rows.Close()
+
+    subscriptions := []*entities.Subscription{}
+    for rows.Next() {
+        sub, err := scanSubscription(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan subscription: %w", err)
+        }
+        subscriptions = append(subscriptions, sub)
+    }
+    return subscriptions, rows.Err()
+}
+
+// claim marca la columna solo si estaba vacía, así entre varias instancias una sola se queda con la tarea
+func (r *SubscriptionRepository) claim(ctx context.Context, subscriptionID int, column string) (bool, error) {
+    query := `UPDATE subscriptions SET ` + column + ` = NOW() WHERE id = ? AND status = 'active' AND ` + column + ` IS NULL`
+    result, err := r.db.ExecContext(ctx, query, subscriptionID)
+    if err != nil {
+        return false, fmt.Errorf("failed to update subscription %d: %w", subscriptionID, err)
+    }
+    affected, err := result.RowsAffected()
+    if err != nil {
+        return false, err
+    }
+    return affected > 0, nil
+}
+
+func (r *SubscriptionRepository) MarkReminderSent(ctx context.Context, subscriptionID int) (bool, error) {
+    return r.claim(ctx, subscriptionID, "expiry_reminder_sent_at")
+}
+
+func (r *SubscriptionRepository) MarkRenewalRequested(ctx context.Context, subscriptionID int) (bool, error) {
+    return r.claim(ctx, subscriptionID, "renewal_requested_at")
+}
+
+func (r *SubscriptionRepository) ClearRenewalRequested(ctx context.Context, subscriptionID int) error {
+    query := `UPDATE subscriptions SET renewal_requested_at = NULL WHERE id = ?`
+    if _, err := r.db.ExecContext(ctx, query, subscriptionID); err != nil {
+        return fmt.Errorf("failed to update subscription %d: %w", subscriptionID, err)
+    }
+    return nil
+}
+
+func (r *SubscriptionRepository) FindPlanFeatures(ctx context.Context, planID int) (*entities.PlanFeatures, error) {
+    query := `
+        SELECT max_active_repair_orders, max_clients, storage_quota_mb, staff_seats, pdf_branding
+        FROM plan_features
+        WHERE plan_id = ?
+    `
+    var maxOrders, maxClients, storage, seats sql.NullInt64
+    var features entities.PlanFeatures
+    err := r.db.QueryRowContext(ctx, query, planID).Scan(&maxOrders, &maxClients, &storage, &seats, &features.PDFBranding)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to query features of plan %d: %w", planID, err)
+    }
+
+    features.MaxActiveRepairOrders = nullableLimit(maxOrders)
+    features.MaxClients = nullableLimit(maxClients)
+    features.StorageQuotaMB = nullableLimit(storage)
+    features.StaffSeats = nullableLimit(seats)
+    return &features, nil
+}
+
+func nullableLimit(value sql.NullInt64) *int {
+    if !value.Valid {
+        return nil
+    }
+    limit := int(value.Int64)
+    return &limit
+}
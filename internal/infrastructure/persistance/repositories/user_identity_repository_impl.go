@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type userIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewUserIdentityRepository(db *sql.DB) repository.UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+const identityColumns = `id, user_id, provider, subject, email, created_at, updated_at`
+
+func scanIdentity(row rowScanner) (*entities.UserIdentity, error) {
+	var identity entities.UserIdentity
+	var subject sql.NullString
+	err := row.Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	identity.Subject = subject.String
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *entities.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query,
+		identity.UserID,
+		identity.Provider,
+		sql.NullString{String: identity.Subject, Valid: identity.Subject != ""},
+		identity.Email,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	identity.ID = int(id)
+	return nil
+}
+
+func (r *userIdentityRepository) findOne(ctx context.Context, query string, args ...any) (*entities.UserIdentity, error) {
+	identity, err := scanIdentity(r.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return identity, err
+}
+
+func (r *userIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*entities.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE provider = ? AND subject = ?`
+	return r.findOne(ctx, query, provider, subject)
+}
+
+func (r *userIdentityRepository) FindByUserAndProvider(ctx context.Context, userID int, provider string) (*entities.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE user_id = ? AND provider = ?`
+	return r.findOne(ctx, query, userID, provider)
+}
+
+func (r *userIdentityRepository) FindByUserID(ctx context.Context, userID int) ([]*entities.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE user_id = ? ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*entities.UserIdentity{}
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+func (r *userIdentityRepository) UpdateSubject(ctx context.Context, id int, subject, email string) error {
+	query := `UPDATE user_identities SET subject = ?, email = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, subject, email, id)
+	return err
+}
+
+func (r *userIdentityRepository) Delete(ctx context.Context, userID int, provider string) error {
+	query := `DELETE FROM user_identities WHERE user_id = ? AND provider = ?`
+	_, err := r.db.ExecContext(ctx, query, userID, provider)
+	return err
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+	"strings"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -24,12 +26,12 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 func (r *UserRepository) Save(user *entities.User) (int, error) {
 	query := `
         INSERT INTO users (
-            email, password, login_method, role, first_name, last_name, phone, address, country,
+            email, password, role, first_name, last_name, phone, address, country,
             workshop_name, is_active, deleted, verified, last_login
-        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
 	res, err := r.db.Exec(query,
-		user.Email, user.Password, user.LoginMethod, user.Role, user.FirstName, user.LastName,
+		user.Email, user.Password, user.Role, user.FirstName, user.LastName,
 		user.Phone, user.Address, user.Country, user.WorkshopName, user.IsActive,
 		user.Deleted, user.Verified, sql.NullString{String: user.LastLogin, Valid: user.LastLogin != ""},
 	)
@@ -49,12 +51,12 @@ func (r *UserRepository) Save(user *entities.User) (int, error) {
 	return int(id), nil
 }
 
-func (r *UserRepository) CreateEmailVerification(userID int, code string, expiresAt time.Time) error {
+func (r *UserRepository) CreateEmailVerification(userID int, codeHash string, expiresAt time.Time) error {
 	query := `
-        INSERT INTO email_verifications (user_id, verification_code, expires_at)
+        INSERT INTO email_verifications (user_id, code_hash, expires_at)
         VALUES (?, ?, ?)
     `
-	_, err := r.db.Exec(query, userID, code, expiresAt)
+	_, err := r.db.Exec(query, userID, codeHash, expiresAt)
 	return err
 }
 
@@ -67,37 +69,53 @@ func (r *UserRepository) UpdateEmailVerified(userID int, verified bool) error {
 	return err
 }
 
-func (r *UserRepository) FindByID(id int) (*entities.User, error) {
-    query := `
+// rowScanner permite reutilizar el scan tanto con *sql.Row como con *sql.Rows
+type rowScanner interface {
+    Scan(dest ...any) error
+}
+
+const userSelect = `
         SELECT 
             u.id, u.email, u.password, u.role, u.first_name, u.last_name, u.phone, 
-            u.address, u.country, u.workshop_name, u.is_active, u.deleted, u.last_login, u.verified, u.login_method,
-            s.id, s.user_id, s.plan_id, sp.name, s.status, s.started_at, s.expires_at
+            u.address, u.country, u.workshop_name, u.is_active, u.deleted, u.deleted_at, u.purge_after, u.last_login, u.verified,
+            u.mfa_enabled, u.mfa_secret, u.password_reset_required, u.created_at,
+            (SELECT GROUP_CONCAT(ui.provider ORDER BY ui.provider) FROM user_identities ui WHERE ui.user_id = u.id),
+            s.id, s.user_id, s.plan_id, sp.name, s.status, s.started_at, s.expires_at, s.cancel_at_period_end
         FROM users u
         LEFT JOIN subscriptions s ON u.id = s.user_id AND s.status = 'active'
         LEFT JOIN subscription_plans sp ON s.plan_id = sp.id
-        WHERE u.id = ?
     `
+
+func scanUser(row rowScanner) (*entities.User, error) {
     var user entities.User
     var lastLogin sql.NullString
+    var mfaSecret sql.NullString
+    var deletedAt, purgeAfter sql.NullTime
+    var providers sql.NullString
     var subID, subUserID, subPlanID sql.NullInt64
     var subPlanName, subStatus sql.NullString
     var subStartedAt, subExpiresAt sql.NullTime
+    var subCancelAtPeriodEnd sql.NullBool
 
-    err := r.db.QueryRow(query, id).Scan(
+    err := row.Scan(
         &user.ID, &user.Email, &user.Password, &user.Role, &user.FirstName, &user.LastName,
         &user.Phone, &user.Address, &user.Country, &user.WorkshopName, &user.IsActive,
-        &user.Deleted, &lastLogin, &user.Verified, &user.LoginMethod,
-        &subID, &subUserID, &subPlanID, &subPlanName, &subStatus, &subStartedAt, &subExpiresAt,
+        &user.Deleted, &deletedAt, &purgeAfter, &lastLogin, &user.Verified,
+        &user.MFAEnabled, &mfaSecret, &user.PasswordResetRequired, &user.CreatedAt, &providers,
+        &subID, &subUserID, &subPlanID, &subPlanName, &subStatus, &subStartedAt, &subExpiresAt, &subCancelAtPeriodEnd,
     )
-    if err == sql.ErrNoRows {
-        return nil, nil
-    }
     if err != nil {
-        return nil, fmt.Errorf("failed to query user by ID %d: %w", id, err)
+        return nil, err
     }
 
     user.LastLogin = lastLogin.String
+    user.MFASecret = mfaSecret.String
+    user.DeletedAt = deletedAt.Time
+    user.PurgeAfter = purgeAfter.Time
+    user.Providers = []string{}
+    if providers.Valid && providers.String != "" {
+        user.Providers = strings.Split(providers.String, ",")
+    }
     if subID.Valid {
         user.Subscription = &entities.Subscription{
             ID:        int(subID.Int64),
@@ -107,69 +125,43 @@ func (r *UserRepository) FindByID(id int) (*entities.User, error) {
             Status:    subStatus.String,
             StartedAt: subStartedAt.Time,
             ExpiresAt: subExpiresAt.Time,
+            CancelAtPeriodEnd: subCancelAtPeriodEnd.Bool,
         }
     }
 
     return &user, nil
 }
 
-func (r *UserRepository) FindByEmail(email string) (*entities.User, error) {
-    query := `
-        SELECT 
-            u.id, u.email, u.password, u.role, u.first_name, u.last_name, u.phone, 
-            u.address, u.country, u.workshop_name, u.is_active, u.deleted, u.last_login, u.verified, u.login_method,
-            s.id, s.user_id, s.plan_id, sp.name, s.status, s.started_at, s.expires_at
-        FROM users u
-        LEFT JOIN subscriptions s ON u.id = s.user_id AND s.status = 'active'
-        LEFT JOIN subscription_plans sp ON s.plan_id = sp.id
-        WHERE u.email = ?
-    `
-    var user entities.User
-    var lastLogin sql.NullString
-    var subID, subUserID, subPlanID sql.NullInt64
-    var subPlanName, subStatus sql.NullString
-    var subStartedAt, subExpiresAt sql.NullTime
+func (r *UserRepository) FindByID(id int) (*entities.User, error) {
+    query := userSelect + ` WHERE u.id = ?`
 
-    err := r.db.QueryRow(query, email).Scan(
-        &user.ID, &user.Email, &user.Password, &user.Role, &user.FirstName, &user.LastName,
-        &user.Phone, &user.Address, &user.Country, &user.WorkshopName, &user.IsActive,
-        &user.Deleted, &lastLogin, &user.Verified, &user.LoginMethod,
-        &subID, &subUserID, &subPlanID, &subPlanName, &subStatus, &subStartedAt, &subExpiresAt,
-    )
+    user, err := scanUser(r.db.QueryRow(query, id))
     if err == sql.ErrNoRows {
         return nil, nil
     }
     if err != nil {
-        return nil, fmt.Errorf("failed to query user by email %q: %w", email, err)
+        return nil, fmt.Errorf("failed to query user by ID %d: %w", id, err)
     }
 
-    user.LastLogin = lastLogin.String
-    if subID.Valid {
-        user.Subscription = &entities.Subscription{
-            ID:        int(subID.Int64),
-            UserID:    int(subUserID.Int64),
-            PlanID:    int(subPlanID.Int64),
-            PlanName:  subPlanName.String,
-            Status:    subStatus.String,
-            StartedAt: subStartedAt.Time,
-            ExpiresAt: subExpiresAt.Time,
-        }
+    return user, nil
+}
+
+func (r *UserRepository) FindByEmail(email string) (*entities.User, error) {
+    query := userSelect + ` WHERE u.email = ?`
+
+    user, err := scanUser(r.db.QueryRow(query, email))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to query user by email %q: %w", email, err)
     }
 
-    return &user, nil
+    return user, nil
 }
 
 func (r *UserRepository) FindAll() ([]*entities.User, error) {
-    query := `
-        SELECT 
-            u.id, u.email, u.password, u.role, u.first_name, u.last_name, u.phone, 
-            u.address, u.country, u.workshop_name, u.is_active, u.deleted, u.last_login, u.verified, u.login_method,
-            s.id, s.user_id, s.plan_id, sp.name, s.status, s.started_at, s.expires_at
-        FROM users u
-        LEFT JOIN subscriptions s ON u.id = s.user_id AND s.status = 'active'
-        LEFT JOIN subscription_plans sp ON s.plan_id = sp.id
-    `
-    rows, err := r.db.Query(query)
+    rows, err := r.db.Query(userSelect)
     if err != nil {
         return nil, fmt.Errorf("failed to query all users: %w", err)
     }
@@ -177,38 +169,82 @@ func (r *UserRepository) FindAll() ([]*entities.User, error) {
 
     var users []*entities.User
     for rows.Next() {
-        var user entities.User
-        var lastLogin sql.NullString
-        var subID, subUserID, subPlanID sql.NullInt64
-        var subPlanName, subStatus sql.NullString
-        var subStartedAt, subExpiresAt sql.NullTime
-
-        if err := rows.Scan(
-            &user.ID, &user.Email, &user.Password, &user.Role, &user.FirstName, &user.LastName,
-            &user.Phone, &user.Address, &user.Country, &user.WorkshopName, &user.IsActive,
-            &user.Deleted, &lastLogin, &user.Verified, &user.LoginMethod,
-            &subID, &subUserID, &subPlanID, &subPlanName, &subStatus, &subStartedAt, &subExpiresAt,
-        ); err != nil {
+        user, err := scanUser(rows)
+        if err != nil {
             return nil, fmt.Errorf("failed to scan user: %w", err)
         }
+        users = append(users, user)
+    }
 
-        user.LastLogin = lastLogin.String
-        if subID.Valid {
-            user.Subscription = &entities.Subscription{
-                ID:        int(subID.Int64),
-                UserID:    int(subUserID.Int64),
-                PlanID:    int(subPlanID.Int64),
-                PlanName:  subPlanName.String,
-                Status:    subStatus.String,
-                StartedAt: subStartedAt.Time,
-                ExpiresAt: subExpiresAt.Time,
-            }
-        }
+    return users, nil
+}
 
-        users = append(users, &user)
+func (r *UserRepository) Search(ctx context.Context, filter repository.UserFilter) ([]*entities.User, int, error) {
+    var conditions []string
+    var args []any
+
+    if filter.Email != "" {
+        conditions = append(conditions, "u.email LIKE ?")
+        args = append(args, "%"+filter.Email+"%")
+    }
+    if filter.Verified != nil {
+        conditions = append(conditions, "u.verified = ?")
+        args = append(args, *filter.Verified)
+    }
+    if filter.Deleted != nil {
+        conditions = append(conditions, "u.deleted = ?")
+        args = append(args, *filter.Deleted)
+    }
+    if filter.Active != nil {
+        conditions = append(conditions, "u.is_active = ?")
+        args = append(args, *filter.Active)
+    }
+    if filter.Plan != "" {
+        conditions = append(conditions, "sp.name = ?")
+        args = append(args, filter.Plan)
+    }
+    if !filter.CreatedFrom.IsZero() {
+        conditions = append(conditions, "u.created_at >= ?")
+        args = append(args, filter.CreatedFrom)
+    }
+    if !filter.CreatedTo.IsZero() {
+        conditions = append(conditions, "u.created_at <= ?")
+        args = append(args, filter.CreatedTo)
     }
 
-    return users, nil
+    where := ""
+    if len(conditions) > 0 {
+        where = " WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    countQuery := `
+        SELECT COUNT(*)
+        FROM users u
+        LEFT JOIN subscriptions s ON u.id = s.user_id AND s.status = 'active'
+        LEFT JOIN subscription_plans sp ON s.plan_id = sp.id
+    ` + where
+    var total int
+    if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+        return nil, 0, fmt.Errorf("failed to count users: %w", err)
+    }
+
+    query := userSelect + where + ` ORDER BY u.id DESC LIMIT ? OFFSET ?`
+    rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to search users: %w", err)
+    }
+    defer rows.Close()
+
+    users := []*entities.User{}
+    for rows.Next() {
+        user, err := scanUser(rows)
+        if err != nil {
+            return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+        }
+        users = append(users, user)
+    }
+
+    return users, total, rows.Err()
 }
 
 func (r *UserRepository) EmailExists(email string) (bool, error) {
@@ -228,7 +264,8 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID int, lastLo
 }
 
 func (r *UserRepository) UpdatePassword(userID int, newPassword string) error {
-    query := "UPDATE users SET password = ? WHERE id = ?"
+    // Cualquier cambio de contraseña cumple con el restablecimiento obligatorio
+    query := "UPDATE users SET password = ?, password_reset_required = FALSE WHERE id = ?"
     
     result, err := r.db.Exec(query, newPassword, userID)
     if err != nil {
@@ -245,4 +282,139 @@ func (r *UserRepository) UpdatePassword(userID int, newPassword string) error {
     }
 
     return nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepository) UpdateEmail(userID int, email string) error {
+    query := `UPDATE users SET email = ? WHERE id = ?`
+    _, err := r.db.Exec(query, email, userID)
+    if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+        return ErrEmailAlreadyExists
+    }
+    return err
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, update repository.ProfileUpdate) error {
+    columns := []struct {
+        name  string
+        value *string
+    }{
+        {"first_name", update.FirstName},
+        {"last_name", update.LastName},
+        {"phone", update.Phone},
+        {"address", update.Address},
+        {"country", update.Country},
+        {"workshop_name", update.WorkshopName},
+    }
+
+    var sets []string
+    var args []any
+    for _, column := range columns {
+        if column.value == nil {
+            continue
+        }
+        sets = append(sets, column.name+" = ?")
+        args = append(args, *column.value)
+    }
+    if len(sets) == 0 {
+        return nil
+    }
+
+    query := `UPDATE users SET ` + strings.Join(sets, ", ") + ` WHERE id = ?`
+    if _, err := r.db.ExecContext(ctx, query, append(args, userID)...); err != nil {
+        return fmt.Errorf("failed to update profile of user %d: %w", userID, err)
+    }
+    return nil
+}
+
+func (r *UserRepository) UpdateMFASecret(userID int, secret string) error {
+    query := `UPDATE users SET mfa_secret = ? WHERE id = ?`
+    _, err := r.db.Exec(query, sql.NullString{String: secret, Valid: secret != ""}, userID)
+    return err
+}
+
+func (r *UserRepository) UpdateMFAEnabled(userID int, enabled bool) error {
+    query := `UPDATE users SET mfa_enabled = ? WHERE id = ?`
+    _, err := r.db.Exec(query, enabled, userID)
+    return err
+}
+
+
+func (r *UserRepository) UpdateActive(userID int, active bool) error {
+    query := `UPDATE users SET is_active = ? WHERE id = ?`
+    _, err := r.db.Exec(query, active, userID)
+    return err
+}
+
+// UpdateDeleted es el borrado lógico del panel de administración, no programa el borrado definitivo
+func (r *UserRepository) UpdateDeleted(userID int, deleted bool) error {
+    query := `
+        UPDATE users
+        SET deleted = ?, deleted_at = IF(?, NOW(), NULL), purge_after = NULL
+        WHERE id = ?
+    `
+    _, err := r.db.Exec(query, deleted, deleted, userID)
+    return err
+}
+
+func (r *UserRepository) ScheduleDeletion(userID int, purgeAfter time.Time) error {
+    query := `UPDATE users SET deleted = TRUE, deleted_at = NOW(), purge_after = ? WHERE id = ?`
+    _, err := r.db.Exec(query, purgeAfter, userID)
+    return err
+}
+
+func (r *UserRepository) RestoreDeleted(userID int) error {
+    query := `UPDATE users SET deleted = FALSE, deleted_at = NULL, purge_after = NULL WHERE id = ?`
+    _, err := r.db.Exec(query, userID)
+    return err
+}
+
+func (r *UserRepository) RequirePasswordReset(ctx context.Context, userID int) error {
+    query := `UPDATE users SET password_reset_required = TRUE WHERE id = ?`
+    _, err := r.db.ExecContext(ctx, query, userID)
+    return err
+}
+
+func (r *UserRepository) FindPendingPurge(ctx context.Context, before time.Time) ([]int, error) {
+    query := `SELECT id FROM users WHERE deleted = TRUE AND purge_after IS NOT NULL AND purge_after <= ?`
+    rows, err := r.db.QueryContext(ctx, query, before)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query users pending purge: %w", err)
+    }
+    defer rows.Close()
+
+    ids := []int{}
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("failed to scan user id: %w", err)
+        }
+        ids = append(ids, id)
+    }
+    return ids, rows.Err()
+}
+
+// Purge vuelve a comprobar el plazo en el DELETE por si el usuario recuperó la cuenta mientras tanto.
+// Las suscripciones se borran a mano en la misma transacción: la tabla se creó con IF NOT EXISTS y en
+// las bases donde ya existía no tiene la clave foránea con ON DELETE CASCADE.
+func (r *UserRepository) Purge(ctx context.Context, userID int, before time.Time) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    query := `DELETE FROM users WHERE id = ? AND deleted = TRUE AND purge_after IS NOT NULL AND purge_after <= ?`
+    result, err := tx.ExecContext(ctx, query, userID, before)
+    if err != nil {
+        return false, err
+    }
+    affected, err := result.RowsAffected()
+    if err != nil || affected == 0 {
+        return false, err
+    }
+
+    if _, err := tx.ExecContext(ctx, `DELETE FROM subscriptions WHERE user_id = ?`, userID); err != nil {
+        return false, err
+    }
+    return true, tx.Commit()
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/repository"
+)
+
+type webAuthnCredentialRepository struct {
+	db *sql.DB
+}
+
+func NewWebAuthnCredentialRepository(db *sql.DB) repository.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{db: db}
+}
+
+const webAuthnCredentialColumns = `id, user_id, name, credential_id, public_key, attestation_type, aaguid, sign_count,
+	transports, backup_eligible, backup_state, last_used_at, created_at`
+
+func scanWebAuthnCredential(row rowScanner) (*entities.WebAuthnCredential, error) {
+	var credential entities.WebAuthnCredential
+	var transports string
+	var lastUsedAt sql.NullTime
+	err := row.Scan(
+		&credential.ID,
+		&credential.UserID,
+		&credential.Name,
+		&credential.CredentialID,
+		&credential.PublicKey,
+		&credential.AttestationType,
+		&credential.AAGUID,
+		&credential.SignCount,
+		&transports,
+		&credential.BackupEligible,
+		&credential.BackupState,
+		&lastUsedAt,
+		&credential.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if transports != "" {
+		credential.Transports = strings.Split(transports, ",")
+	}
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = lastUsedAt.Time
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnCredentialRepository) Create(ctx context.Context, credential *entities.WebAuthnCredential) error {
+	query := `INSERT INTO webauthn_credentials
+		(user_id, name, credential_id, public_key, attestation_type, aaguid, sign_count, transports, backup_eligible, backup_state)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query,
+		credential.UserID,
+		credential.Name,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		credential.AAGUID,
+		credential.SignCount,
+		strings.Join(credential.Transports, ","),
+		credential.BackupEligible,
+		credential.BackupState,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	credential.ID = int(id)
+	return nil
+}
+
+func (r *webAuthnCredentialRepository) FindByCredentialID(ctx context.Context, credentialID []byte) (*entities.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + ` FROM webauthn_credentials WHERE credential_id = ?`
+	credential, err := scanWebAuthnCredential(r.db.QueryRowContext(ctx, query, credentialID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return credential, err
+}
+
+func (r *webAuthnCredentialRepository) FindByUserID(ctx context.Context, userID int) ([]*entities.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + ` FROM webauthn_credentials WHERE user_id = ? ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []*entities.WebAuthnCredential{}
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+func (r *webAuthnCredentialRepository) UpdateUsage(ctx context.Context, id int, signCount uint32, backupState bool, usedAt time.Time) error {
+	query := `UPDATE webauthn_credentials SET sign_count = ?, backup_state = ?, last_used_at = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, signCount, backupState, usedAt, id)
+	return err
+}
+
+// Delete devuelve false si la passkey no existe o pertenece a otro usuario
+func (r *webAuthnCredentialRepository) Delete(ctx context.Context, userID, id int) (bool, error) {
+	query := `DELETE FROM webauthn_credentials WHERE id = ? AND user_id = ?`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Job es una tarea que se repite cada Interval. Run tiene que ser idempotente: si hay varias
+// instancias de la API, cada una corre sus propias tareas.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler corre las tareas periódicas del proceso, como el borrado definitivo de cuentas
+type Scheduler struct {
+	jobs   []Job
+	logger *zerolog.Logger
+}
+
+func New(logger *zerolog.Logger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+// Every registra una tarea, hay que llamarlo antes de Start
+func (s *Scheduler) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Start lanza una goroutine por tarea. Cada una corre apenas arranca y después en cada intervalo,
+// hasta que se cancela el contexto.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run aísla cada ejecución: un error o un panic no detiene las siguientes
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error().
+				Str("job", job.Name).
+				Interface("panic", r).
+				Msg("Scheduled job panicked")
+		}
+	}()
+
+	startedAt := time.Now()
+	if err := job.Run(ctx); err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("job", job.Name).
+			Msg("Scheduled job failed")
+		return
+	}
+
+	s.logger.Debug().
+		Str("job", job.Name).
+		Dur("duration", time.Since(startedAt)).
+		Msg("Scheduled job finished")
+}
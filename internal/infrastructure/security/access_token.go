@@ -0,0 +1,23 @@
+package security
+
+import "strings"
+
+// AccessTokenPrefix distingue los tokens personales de los JWT y permite detectarlos si se filtran en un repositorio
+const AccessTokenPrefix = "lst_"
+
+// accessTokenDisplayLength es cuánto del token se guarda en claro para mostrarlo en el listado
+const accessTokenDisplayLength = len(AccessTokenPrefix) + 6
+
+// GenerateAccessToken devuelve el token completo, que se muestra una sola vez, y el prefijo visible
+func GenerateAccessToken() (string, string, error) {
+	secret, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	token := AccessTokenPrefix + secret
+	return token, token[:accessTokenDisplayLength], nil
+}
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
@@ -2,7 +2,9 @@ package security
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"math/big"
+	"strings"
 )
 
 const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -18,3 +20,38 @@ func GenerateVerificationCode(length int) (string, error) {
 	}
 	return string(code), nil
 }
+
+// HashVerificationCode normaliza el código como lo tipea el usuario y devuelve su SHA-256 en hex
+func HashVerificationCode(code string) string {
+	hash, _ := HashToken(strings.ToUpper(strings.TrimSpace(code)))
+	return hash
+}
+
+// GenerateSecureToken devuelve un token aleatorio url-safe de length bytes de entropía
+func GenerateSecureToken(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateRecoveryCodes genera códigos de recuperación de MFA con formato XXXXX-XXXXX
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		code, err := GenerateVerificationCode(10)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode permite que el usuario ingrese el código en minúsculas, sin guion o con espacios
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -3,6 +3,7 @@ package security
 import (
 	"errors"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,8 +13,18 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 )
 
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+)
+
 type Claims struct {
 	UserID int `json:"user_id"`
+	Role   string `json:"role,omitempty"`
+	// TokenUse distingue access de refresh, ahora que ambos se firman con la misma clave
+	TokenUse string `json:"token_use,omitempty"`
+	// ImpersonatorID es el administrador que actúa en nombre del usuario, solo en sesiones de impersonación
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -24,34 +35,61 @@ type VerificationClaims struct {
 	jwt.RegisteredClaims
 }
 
-func CreateAccessToken(userID int) (string, error) {
-	secret := os.Getenv("JWT_ACCESS_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_ACCESS_SECRET not set")
+func CreateAccessToken(userID int, role string) (string, error) {
+	return createSignedToken(userID, role, TokenUseAccess, 15*time.Minute)
+}
+
+// El refresh token no lleva rol, al rotar se lee el rol actual del usuario
+func CreateRefreshToken(userID int) (string, error) {
+	return createSignedToken(userID, "", TokenUseRefresh, 7*24*time.Hour)
+}
+
+// CreateImpersonationToken emite un access token a nombre del usuario que lleva el id del administrador.
+// No tiene refresh token: cuando vence, el administrador tiene que volver a iniciar la impersonación.
+func CreateImpersonationToken(userID int, role string, impersonatorID int, ttl time.Duration) (string, error) {
+	return signClaims(&Claims{
+		UserID:         userID,
+		Role:           role,
+		TokenUse:       TokenUseAccess,
+		ImpersonatorID: impersonatorID,
+	}, ttl)
+}
+
+func createSignedToken(userID int, role string, tokenUse string, ttl time.Duration) (string, error) {
+	return signClaims(&Claims{
+		UserID:   userID,
+		Role:     role,
+		TokenUse: tokenUse,
+	}, ttl)
+}
+
+func signClaims(claims *Claims, ttl time.Duration) (string, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return "", err
 	}
 
-	claims := &Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    ring.issuer,
+		Subject:   strconv.Itoa(claims.UserID),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return ring.sign(claims)
 }
 
-func CreateRefreshToken(userID int) (string, error) {
-	secret := os.Getenv("JWT_REFRESH_SECRET")
+func CreateVerificationToken(userID int, email string, verificationExpiresAt time.Time) (string, error) {
+	secret := os.Getenv("JWT_VERIFICATION_SECRET") 
 	if secret == "" {
-		return "", errors.New("JWT_REFRESH_SECRET not set")
+		return "", errors.New("JWT_VERIFICATION_SECRET not set")
 	}
 
-	claims := &Claims{
-		UserID: userID,
+	claims := &VerificationClaims{
+		UserID:                userID,
+		Email:                 email, 
+		VerificationExpiresAt: verificationExpiresAt,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -60,17 +98,17 @@ func CreateRefreshToken(userID int) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
-func CreateVerificationToken(userID int, email string, verificationExpiresAt time.Time) (string, error) {
-	secret := os.Getenv("JWT_VERIFICATION_SECRET") 
+// CreateMFAToken emite un token de corta duración que solo sirve para completar el segundo paso del login
+func CreateMFAToken(userID int, expiresAt time.Time) (string, error) {
+	secret := os.Getenv("JWT_MFA_SECRET")
 	if secret == "" {
-		return "", errors.New("JWT_VERIFICATION_SECRET not set")
+		return "", errors.New("JWT_MFA_SECRET not set")
 	}
 
-	claims := &VerificationClaims{
-		UserID:                userID,
-		Email:                 email, 
-		VerificationExpiresAt: verificationExpiresAt,
+	claims := &Claims{
+		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -80,11 +118,67 @@ func CreateVerificationToken(userID int, email string, verificationExpiresAt tim
 }
 
 func ValidateAccessToken(tokenStr string) (int, error) {
-	return validateToken(tokenStr, os.Getenv("JWT_ACCESS_SECRET"))
+	claims, err := ParseAccessToken(tokenStr)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// ParseAccessToken devuelve todos los claims, incluido el rol
+func ParseAccessToken(tokenStr string) (*Claims, error) {
+	return validateSignedToken(tokenStr, TokenUseAccess, os.Getenv("JWT_ACCESS_SECRET"))
 }
 
 func ValidateRefreshToken(tokenStr string) (int, error) {
-	return validateToken(tokenStr, os.Getenv("JWT_REFRESH_SECRET"))
+	claims, err := validateSignedToken(tokenStr, TokenUseRefresh, os.Getenv("JWT_REFRESH_SECRET"))
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// validateSignedToken verifica tokens firmados por el keyring. Los tokens HS256 sin kid,
+// emitidos antes de pasar a claves asimétricas, se siguen aceptando mientras legacySecret esté configurado.
+func validateSignedToken(tokenStr, tokenUse, legacySecret string) (*Claims, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(tokenStr, &Claims{})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if _, hasKid := parsed.Header["kid"]; !hasKid && parsed.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		if legacySecret == "" {
+			return nil, ErrInvalidToken
+		}
+		userID, err := validateToken(tokenStr, legacySecret)
+		if err != nil {
+			return nil, err
+		}
+		return &Claims{UserID: userID, TokenUse: tokenUse}, nil
+	}
+
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, ring.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(ring.issuer),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.TokenUse != tokenUse {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func ValidateMFAToken(tokenStr string) (int, error) {
+	return validateToken(tokenStr, os.Getenv("JWT_MFA_SECRET"))
 }
 
 func ValidateVerificationToken(tokenStr string) (int, string, time.Time, error) {
@@ -0,0 +1,229 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+)
+
+// signingKey es una clave del keyring. Las claves retiradas solo tienen la parte pública
+// y se mantienen para verificar tokens emitidos antes de la rotación.
+type signingKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+type KeyRing struct {
+	activeID string
+	issuer   string
+	keys     map[string]*signingKey
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	keyRingMu sync.RWMutex
+	keyRing   *KeyRing
+)
+
+// InitKeyRing carga las claves de keysDir (un archivo <kid>.pem por clave, privada o pública)
+// y elige activeID para firmar. Sin directorio solo arranca con allowEphemeral (desarrollo), generando
+// una clave que se pierde al reiniciar: en producción cerraría todas las sesiones en cada deploy y
+// cada instancia firmaría con una clave distinta.
+func InitKeyRing(keysDir, activeID, issuer string, allowEphemeral bool, logger *zerolog.Logger) error {
+	ring := &KeyRing{issuer: issuer, keys: map[string]*signingKey{}}
+
+	if keysDir == "" {
+		if !allowEphemeral {
+			return errors.New("JWT_KEYS_DIR is required outside APP_ENV=development")
+		}
+		logger.Warn().Msg("JWT_KEYS_DIR not set, generating an ephemeral Ed25519 signing key (tokens will not survive restarts)")
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return fmt.Errorf("failed to generate ephemeral key: %w", err)
+		}
+		key, err := newSigningKey("dev", privateKey)
+		if err != nil {
+			return err
+		}
+		ring.keys[key.ID] = key
+		ring.activeID = key.ID
+		setKeyRing(ring)
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read key %s: %w", kid, err)
+		}
+
+		parsed, err := parsePEMKey(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse key %s: %w", kid, err)
+		}
+
+		key, err := newSigningKey(kid, parsed)
+		if err != nil {
+			return fmt.Errorf("invalid key %s: %w", kid, err)
+		}
+		ring.keys[kid] = key
+	}
+
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return fmt.Errorf("active signing key %q not found in %s", activeID, keysDir)
+	}
+	if active.PrivateKey == nil {
+		return fmt.Errorf("active signing key %q has no private key", activeID)
+	}
+	ring.activeID = activeID
+
+	setKeyRing(ring)
+	return nil
+}
+
+func setKeyRing(ring *KeyRing) {
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing = ring
+}
+
+func currentKeyRing() (*KeyRing, error) {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	if keyRing == nil {
+		return nil, errors.New("jwt keyring not initialized")
+	}
+	return keyRing, nil
+}
+
+func newSigningKey(kid string, parsed any) (*signingKey, error) {
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		return &signingKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: k, PublicKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &signingKey{ID: kid, Method: jwt.SigningMethodEdDSA, PublicKey: k}, nil
+	case *rsa.PrivateKey:
+		return &signingKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: k, PublicKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &signingKey{ID: kid, Method: jwt.SigningMethodRS256, PublicKey: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+func parsePEMKey(data []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+}
+
+func (r *KeyRing) sign(claims jwt.Claims) (string, error) {
+	key := r.keys[r.activeID]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// keyFunc elige la clave pública según el kid del header y exige que el algoritmo coincida
+func (r *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.PublicKey, nil
+}
+
+func (r *KeyRing) JWKS() JWKSet {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		key := r.keys[id]
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch pub := key.PublicKey.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// PublicJWKS expone las claves públicas para que otros servicios verifiquen nuestros tokens
+func PublicJWKS() (JWKSet, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return JWKSet{}, err
+	}
+	return ring.JWKS(), nil
+}
@@ -0,0 +1,145 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"luthierSaas/internal/infrastructure/cache"
+	"strconv"
+	"time"
+)
+
+const (
+	// Los intentos fallidos se cuentan en una ventana que arranca con el primer fallo
+	loginFailureWindow = 15 * time.Minute
+	// A partir de este fallo cada intento espera el doble que el anterior, hasta loginMaxDelay
+	loginDelayAfter = 3
+	loginMaxDelay   = time.Minute
+	// Al llegar a este fallo la cuenta queda bloqueada durante loginLockDuration
+	loginLockAfter    = 10
+	loginLockDuration = 30 * time.Minute
+)
+
+// LoginBlockedError indica que la cuenta no acepta intentos de contraseña por ahora
+type LoginBlockedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return "account temporarily locked"
+	}
+	return "too many failed attempts, try again later"
+}
+
+// LoginLockStatus es lo que ve un administrador sobre los intentos fallidos de una cuenta
+type LoginLockStatus struct {
+	Locked         bool
+	LockedUntil    time.Time
+	FailedAttempts int
+}
+
+// LoginLockout cuenta los intentos fallidos por cuenta en Redis. El rate limiter por IP no frena
+// un ataque distribuido contra un mismo email, esto sí.
+type LoginLockout struct {
+	cache *cache.Cache
+}
+
+func NewLoginLockout(cacheService *cache.Cache) *LoginLockout {
+	return &LoginLockout{cache: cacheService}
+}
+
+func loginFailuresKey(userID int) string {
+	return fmt.Sprintf("login:failures:%d", userID)
+}
+
+func loginDelayKey(userID int) string {
+	return fmt.Sprintf("login:delay:%d", userID)
+}
+
+func loginLockKey(userID int) string {
+	return fmt.Sprintf("login:lock:%d", userID)
+}
+
+// Check devuelve un *LoginBlockedError si la cuenta está bloqueada o todavía no pasó la espera
+func (l *LoginLockout) Check(ctx context.Context, userID int) error {
+	lockTTL, err := l.cache.TTL(ctx, loginLockKey(userID))
+	if err != nil {
+		return err
+	}
+	if lockTTL > 0 {
+		return &LoginBlockedError{Locked: true, RetryAfter: lockTTL}
+	}
+
+	delayTTL, err := l.cache.TTL(ctx, loginDelayKey(userID))
+	if err != nil {
+		return err
+	}
+	if delayTTL > 0 {
+		return &LoginBlockedError{RetryAfter: delayTTL}
+	}
+	return nil
+}
+
+// RegisterFailure suma un intento fallido y devuelve true si con este la cuenta quedó bloqueada
+func (l *LoginLockout) RegisterFailure(ctx context.Context, userID int) (bool, error) {
+	failures, err := l.cache.Incr(ctx, loginFailuresKey(userID), loginFailureWindow)
+	if err != nil {
+		return false, err
+	}
+
+	if failures >= loginLockAfter {
+		lockedUntil := time.Now().Add(loginLockDuration)
+		if err := l.cache.Set(ctx, loginLockKey(userID), lockedUntil.Format(time.RFC3339), loginLockDuration); err != nil {
+			return false, err
+		}
+		_ = l.cache.Delete(ctx, loginFailuresKey(userID))
+		_ = l.cache.Delete(ctx, loginDelayKey(userID))
+		return true, nil
+	}
+
+	if failures >= loginDelayAfter {
+		delay := time.Second << (failures - loginDelayAfter)
+		if delay > loginMaxDelay {
+			delay = loginMaxDelay
+		}
+		if err := l.cache.Set(ctx, loginDelayKey(userID), strconv.FormatInt(failures, 10), delay); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Reset limpia los intentos fallidos después de un login correcto
+func (l *LoginLockout) Reset(ctx context.Context, userID int) error {
+	if err := l.cache.Delete(ctx, loginFailuresKey(userID)); err != nil {
+		return err
+	}
+	return l.cache.Delete(ctx, loginDelayKey(userID))
+}
+
+// Unlock levanta el bloqueo y descarta los intentos acumulados
+func (l *LoginLockout) Unlock(ctx context.Context, userID int) error {
+	if err := l.cache.Delete(ctx, loginLockKey(userID)); err != nil {
+		return err
+	}
+	return l.Reset(ctx, userID)
+}
+
+func (l *LoginLockout) Status(ctx context.Context, userID int) (LoginLockStatus, error) {
+	var status LoginLockStatus
+
+	lockTTL, err := l.cache.TTL(ctx, loginLockKey(userID))
+	if err != nil {
+		return status, err
+	}
+	if lockTTL > 0 {
+		status.Locked = true
+		status.LockedUntil = time.Now().Add(lockTTL)
+	}
+
+	if failures, err := l.cache.Get(ctx, loginFailuresKey(userID)); err == nil {
+		status.FailedAttempts, _ = strconv.Atoi(failures)
+	}
+	return status, nil
+}
@@ -0,0 +1,61 @@
+package security
+
+import (
+	"testing"
+	"time"
+)
+
+// Secreto de los vectores de prueba de RFC 6238 (SHA1): "12345678901234567890" en base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Los vectores del RFC son de 8 dígitos, con 6 dígitos son los últimos 6
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		code, err := GenerateTOTPCode(rfc6238Secret, TOTPStep(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("t=%d: %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("t=%d: got %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		now := time.Unix(v.unix, 0)
+		step, ok := ValidateTOTP(rfc6238Secret, v.code, now)
+		if !ok || step != TOTPStep(now) {
+			t.Errorf("t=%d: got (%d, %v), want (%d, true)", v.unix, step, ok, TOTPStep(now))
+		}
+	}
+
+	now := time.Unix(1111111111, 0)
+	code, _ := GenerateTOTPCode(rfc6238Secret, TOTPStep(now)-TOTPSkew)
+	if step, ok := ValidateTOTP(rfc6238Secret, code, now); !ok || step != TOTPStep(now)-TOTPSkew {
+		t.Errorf("previous step within skew: got (%d, %v)", step, ok)
+	}
+
+	code, _ = GenerateTOTPCode(rfc6238Secret, TOTPStep(now)+TOTPSkew+1)
+	if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+		t.Error("code outside the skew window was accepted")
+	}
+
+	for _, code := range []string{"", "12345", "1234567", "000000"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("invalid code %q was accepted", code)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package dtos
+
+import "time"
+
+type CreateAccessTokenInput struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+type AccessTokenResponse struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Scopes     []string  `json:"scopes"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string    `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateAccessTokenResponse es la única respuesta que incluye el token completo
+type CreateAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
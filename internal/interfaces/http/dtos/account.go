@@ -0,0 +1,26 @@
+package dtos
+
+import (
+	"luthierSaas/internal/domain/entities"
+	"time"
+)
+
+// DeleteAccountInput pide la contraseña actual; las cuentas sin contraseña tienen que haber iniciado sesión hace poco
+type DeleteAccountInput struct {
+	Password string `json:"password"`
+}
+
+type DeleteAccountResponse struct {
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+// AccountExport reúne los datos personales que guardamos del usuario. Cada sección es un archivo del ZIP.
+type AccountExport struct {
+	ExportedAt    time.Time                `json:"exported_at"`
+	Profile       *ProfileResponse         `json:"profile"`
+	Sessions      []SessionResponse        `json:"sessions"`
+	Subscriptions []*entities.Subscription `json:"subscriptions"`
+	Identities    []IdentityResponse       `json:"identities"`
+	Passkeys      []PasskeyResponse        `json:"passkeys"`
+	AccessTokens  []AccessTokenResponse    `json:"access_tokens"`
+}
@@ -0,0 +1,72 @@
+package dtos
+
+import (
+	"luthierSaas/internal/domain/entities"
+	"time"
+)
+
+// AdminUserQuery son los filtros que acepta el listado de usuarios del panel de administración
+type AdminUserQuery struct {
+	Email       string     `form:"email"`
+	Verified    *bool      `form:"verified"`
+	Deleted     *bool      `form:"deleted"`
+	Active      *bool      `form:"active"`
+	Plan        string     `form:"plan"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02"`
+	Page        int        `form:"page" binding:"omitempty,min=1"`
+	PageSize    int        `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type AdminUserResponse struct {
+	ID           int                    `json:"id"`
+	Email        string                 `json:"email"`
+	FirstName    string                 `json:"first_name"`
+	LastName     string                 `json:"last_name"`
+	Role         string                 `json:"role"`
+	Providers    []string               `json:"providers"`
+	IsActive     bool                   `json:"is_active"`
+	Deleted      bool                   `json:"deleted"`
+	Verified     bool                   `json:"verified"`
+	MFAEnabled   bool                   `json:"mfa_enabled"`
+	CreatedAt    time.Time              `json:"created_at"`
+	LastLogin    string                 `json:"last_login"`
+	Subscription *entities.Subscription `json:"subscription,omitempty"`
+}
+
+type AdminUserListResponse struct {
+	Users    []AdminUserResponse `json:"users"`
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+type AdminUserDetailResponse struct {
+	AdminUserResponse
+	Phone        string            `json:"phone"`
+	Address      string            `json:"address"`
+	Country      string            `json:"country"`
+	WorkshopName string            `json:"workshop_name"`
+	Sessions     []SessionResponse `json:"sessions"`
+	LoginLock    AdminLoginLockResponse `json:"login_lock"`
+}
+
+// AdminLoginLockResponse muestra los intentos de contraseña fallidos y si la cuenta está bloqueada
+type AdminLoginLockResponse struct {
+	Locked         bool      `json:"locked"`
+	LockedUntil    time.Time `json:"locked_until,omitempty"`
+	FailedAttempts int       `json:"failed_attempts"`
+}
+
+// ImpersonationInput pide el motivo para que quede en el registro de auditoría
+type ImpersonationInput struct {
+	Reason string `json:"reason" binding:"required,min=3,max=255"`
+}
+
+// ImpersonationResponse: el access token viaja solo en la cookie
+type ImpersonationResponse struct {
+	AccessToken    string    `json:"-"`
+	UserID         int       `json:"user_id"`
+	ImpersonatorID int       `json:"impersonator_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
@@ -0,0 +1,22 @@
+package dtos
+
+import "time"
+
+// ChangeEmailInput pide la contraseña actual; las cuentas sin contraseña tienen que haber iniciado sesión hace poco
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password"`
+}
+
+type ChangeEmailResponse struct {
+	NewEmail  string    `json:"new_email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ConfirmEmailChangeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type CancelEmailChangeInput struct {
+	Token string `json:"token" binding:"required"`
+}
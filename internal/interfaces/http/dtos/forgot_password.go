@@ -0,0 +1,5 @@
+package dtos
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
@@ -0,0 +1,17 @@
+package dtos
+
+import "time"
+
+type IdentityResponse struct {
+	Provider string    `json:"provider"`
+	Email    string    `json:"email,omitempty"`
+	LinkedAt time.Time `json:"linked_at,omitempty"`
+}
+
+type SetPasswordInput struct {
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type LinkProviderResponse struct {
+	URL string `json:"url"`
+}
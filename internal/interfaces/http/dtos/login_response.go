@@ -10,4 +10,8 @@ type LoginResponse struct {
 	VerificationToken     string           `json:"verificationToken,omitempty"`
 	VerificationExpiresAt time.Time        `json:"verificationCodeExpiresAt,omitempty"`
 	Redirect              string           `json:"redirect,omitempty"`
+	MFARequired           bool             `json:"mfaRequired,omitempty"`
+	MFAToken              string           `json:"mfaToken,omitempty"`
+	MFAExpiresAt          time.Time        `json:"mfaTokenExpiresAt,omitempty"`
+	IdentityLinked        bool             `json:"identityLinked,omitempty"`
 }
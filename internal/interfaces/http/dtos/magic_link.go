@@ -0,0 +1,9 @@
+package dtos
+
+type MagicLinkInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkLoginInput struct {
+	Token string `json:"token" binding:"required"`
+}
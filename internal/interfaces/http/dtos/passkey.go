@@ -0,0 +1,33 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+type PasskeyResponse struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	BackupEligible bool      `json:"backup_eligible"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastUsedAt     time.Time `json:"last_used_at,omitempty"`
+}
+
+// PasskeyRegistrationInput lleva la respuesta de navigator.credentials.create() tal como la arma el navegador
+type PasskeyRegistrationInput struct {
+	Name       string          `json:"name" binding:"required,max=100"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyLoginOptionsResponse se pasa a navigator.credentials.get(), el ceremony_id vuelve en el finish
+type PasskeyLoginOptionsResponse struct {
+	CeremonyID string                        `json:"ceremony_id"`
+	Options    *protocol.CredentialAssertion `json:"options"`
+}
+
+type PasskeyLoginInput struct {
+	CeremonyID string          `json:"ceremony_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
@@ -12,7 +12,19 @@ type ProfileResponse struct {
 	Country      string            `json:"country"`
 	WorkshopName string            `json:"workshop_name"`
 	LastLogin    string            `json:"last_login"`
-	LoginMethod  string            `json:"login_method"`
+	Providers    []string          `json:"providers"`
 	HasPassword  bool			   `json:"has_password"`
+	MFAEnabled   bool              `json:"mfa_enabled"`
+	Role         string            `json:"role"`
 	Subscription *entities.Subscription `json:"subscription,omitempty"`
-}
\ No newline at end of file
+}
+// UpdateProfileInput solo modifica los campos presentes en el JSON. Teléfono, dirección y nombre del
+// taller se pueden vaciar mandando "", nombre y apellido no.
+type UpdateProfileInput struct {
+	FirstName    *string `json:"first_name" binding:"omitempty,max=100"`
+	LastName     *string `json:"last_name" binding:"omitempty,max=100"`
+	Phone        *string `json:"phone" binding:"omitempty,max=20"`
+	Address      *string `json:"address" binding:"omitempty,max=255"`
+	Country      *string `json:"country" binding:"omitempty,max=2"`
+	WorkshopName *string `json:"workshop_name" binding:"omitempty,max=100"`
+}
@@ -0,0 +1,14 @@
+package dtos
+
+import "time"
+
+type SessionResponse struct {
+	ID           int       `json:"id"`
+	DeviceInfo   string    `json:"device_info"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Current      bool      `json:"current"`
+	// Impersonated marca las sesiones abiertas por un administrador desde el panel
+	Impersonated bool `json:"impersonated"`
+}
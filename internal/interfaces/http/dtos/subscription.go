@@ -0,0 +1,52 @@
+package dtos
+
+import "luthierSaas/internal/domain/entities"
+
+type PlanResponse struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Price        float64 `json:"price"`
+	DurationDays int     `json:"duration_days"`
+	// Trial marca la prueba gratuita, que solo se asigna al registrarse
+	Trial    bool                   `json:"trial"`
+	Features *entities.PlanFeatures `json:"features"`
+}
+
+// EntitlementsResponse: sin suscripción activa Subscription es null y Features son los límites gratuitos
+type EntitlementsResponse struct {
+	Subscription *entities.Subscription `json:"subscription"`
+	Features     *entities.PlanFeatures `json:"features"`
+}
+
+// SubscriptionResponse separa la suscripción vigente, el cambio programado y el que espera el pago
+// del historial completo. Checkout solo viene cuando el cambio de plan hay que pagarlo.
+type SubscriptionResponse struct {
+	Current   *entities.Subscription   `json:"current"`
+	Scheduled *entities.Subscription   `json:"scheduled,omitempty"`
+	Pending   *entities.Subscription   `json:"pending,omitempty"`
+	Checkout  *CheckoutResponse        `json:"checkout,omitempty"`
+	History   []*entities.Subscription `json:"history"`
+}
+
+// CheckoutResponse es la página de pago de la pasarela a la que el frontend redirige
+type CheckoutResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// FakeCheckoutInput cierra un checkout de la pasarela simulada. Con event_id se reenvía un
+// evento ya entregado, para probar que los webhooks repetidos no se aplican dos veces.
+type FakeCheckoutInput struct {
+	Outcome string `json:"outcome" binding:"required,oneof=paid failed expired"`
+	EventID string `json:"event_id"`
+}
+
+type ChangePlanInput struct {
+	PlanID int `json:"plan_id" binding:"required,min=1"`
+}
+
+// CancelSubscriptionInput: sin at_period_end la suscripción sigue hasta que vence
+type CancelSubscriptionInput struct {
+	AtPeriodEnd *bool `json:"at_period_end"`
+}
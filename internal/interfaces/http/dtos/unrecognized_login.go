@@ -0,0 +1,6 @@
+package dtos
+
+// UnrecognizedLoginInput trae el token del link "no fui yo" del aviso de dispositivo nuevo
+type UnrecognizedLoginInput struct {
+	Token string `json:"token" binding:"required"`
+}
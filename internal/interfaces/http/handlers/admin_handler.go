@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"errors"
+	"luthierSaas/internal/application/usecases/admin"
+	"luthierSaas/internal/interfaces/http/dtos"
+	customErr "luthierSaas/internal/interfaces/http/errors"
+	"luthierSaas/internal/interfaces/http/middlewares"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	listUsersUC          *admin.ListUsersUseCase
+	getUserUC            *admin.GetUserUseCase
+	setUserActiveUC      *admin.SetUserActiveUseCase
+	setUserDeletedUC     *admin.SetUserDeletedUseCase
+	forceVerifyEmailUC   *admin.ForceVerifyEmailUseCase
+	forceLogoutUC        *admin.ForceLogoutUseCase
+	unlockLoginUC        *admin.UnlockLoginUseCase
+	startImpersonationUC *admin.StartImpersonationUseCase
+	stopImpersonationUC  *admin.StopImpersonationUseCase
+}
+
+func NewAdminHandler(
+	listUsers *admin.ListUsersUseCase,
+	getUser *admin.GetUserUseCase,
+	setUserActive *admin.SetUserActiveUseCase,
+	setUserDeleted *admin.SetUserDeletedUseCase,
+	forceVerifyEmail *admin.ForceVerifyEmailUseCase,
+	forceLogout *admin.ForceLogoutUseCase,
+	unlockLogin *admin.UnlockLoginUseCase,
+	startImpersonation *admin.StartImpersonationUseCase,
+	stopImpersonation *admin.StopImpersonationUseCase,
+) *AdminHandler {
+	return &AdminHandler{
+		listUsersUC:          listUsers,
+		getUserUC:            getUser,
+		setUserActiveUC:      setUserActive,
+		setUserDeletedUC:     setUserDeleted,
+		forceVerifyEmailUC:   forceVerifyEmail,
+		forceLogoutUC:        forceLogout,
+		unlockLoginUC:        unlockLogin,
+		startImpersonationUC: startImpersonation,
+		stopImpersonationUC:  stopImpersonation,
+	}
+}
+
+// targetUserID lee el id del usuario sobre el que actúa el administrador
+func targetUserID(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid user id", err.Error()))
+		return 0, false
+	}
+	return userID, true
+}
+
+// adminError traduce los errores de los casos de uso de administración a respuestas HTTP
+func adminError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, admin.ErrUserNotFound):
+		c.Error(customErr.New(http.StatusNotFound, message, err.Error()))
+	case errors.Is(err, admin.ErrCannotModifySelf):
+		c.Error(customErr.New(http.StatusConflict, message, err.Error()))
+	case errors.Is(err, admin.ErrCannotImpersonate), errors.Is(err, admin.ErrInsufficientRole):
+		c.Error(customErr.New(http.StatusForbidden, message, err.Error()))
+	case errors.Is(err, admin.ErrNotImpersonating):
+		c.Error(customErr.New(http.StatusBadRequest, message, err.Error()))
+	default:
+		c.Error(customErr.New(http.StatusInternalServerError, message, err.Error()))
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var query dtos.AdminUserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid query parameters", err.Error()))
+		return
+	}
+
+	result, err := h.listUsersUC.Execute(c.Request.Context(), query)
+	if err != nil {
+		adminError(c, "Error to list users", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.getUserUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		adminError(c, "Error to get user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false, "user deactivated successfully")
+}
+
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	h.setActive(c, true, "user reactivated successfully")
+}
+
+func (h *AdminHandler) setActive(c *gin.Context, active bool, message string) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.setUserActiveUC.Execute(c.Request.Context(), adminID, userID, active); err != nil {
+		adminError(c, "Error to update user status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	h.setDeleted(c, true, "user deleted successfully")
+}
+
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	h.setDeleted(c, false, "user restored successfully")
+}
+
+func (h *AdminHandler) setDeleted(c *gin.Context, deleted bool, message string) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.setUserDeletedUC.Execute(c.Request.Context(), adminID, userID, deleted); err != nil {
+		adminError(c, "Error to update user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func (h *AdminHandler) ForceVerifyEmail(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.forceVerifyEmailUC.Execute(c.Request.Context(), adminID, userID); err != nil {
+		adminError(c, "Error to verify email", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified successfully"})
+}
+
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.forceLogoutUC.Execute(c.Request.Context(), adminID, userID); err != nil {
+		adminError(c, "Error to logout user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user sessions revoked successfully"})
+}
+
+func (h *AdminHandler) UnlockLogin(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.unlockLoginUC.Execute(c.Request.Context(), adminID, userID); err != nil {
+		adminError(c, "Error to unlock user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user login unlocked successfully"})
+}
+
+// StartImpersonation reemplaza la cookie de acceso por la de la sesión del usuario. El refresh token
+// del administrador queda guardado aparte para devolvérselo al terminar.
+func (h *AdminHandler) StartImpersonation(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.ImpersonationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid request data", err.Error()))
+		return
+	}
+
+	result, err := h.startImpersonationUC.Execute(c.Request.Context(), adminID, userID, input, deviceInfoFromRequest(c), c.ClientIP())
+	if err != nil {
+		adminError(c, "Error to impersonate user", err)
+		return
+	}
+
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil {
+		c.SetCookie("admin_refresh_token", refreshToken, 604800, "/", "", false, true)
+	}
+	c.SetCookie("access_token", result.AccessToken, 1800, "/", "", false, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// StopImpersonation cierra la sesión impersonada y devuelve el refresh token del administrador,
+// el frontend llama a /auth/refresh para recuperar su sesión
+func (h *AdminHandler) StopImpersonation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.stopImpersonationUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey), c.ClientIP()); err != nil {
+		adminError(c, "Error to stop impersonation", err)
+		return
+	}
+
+	c.SetCookie("access_token", "", -1, "/", "", false, true)
+	if refreshToken, err := c.Cookie("admin_refresh_token"); err == nil {
+		c.SetCookie("refresh_token", refreshToken, 604800, "/", "", false, true)
+		c.SetCookie("admin_refresh_token", "", -1, "/", "", false, true)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "impersonation stopped successfully"})
+}
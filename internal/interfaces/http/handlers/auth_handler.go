@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"luthierSaas/internal/application/usecases/auth"
+	"luthierSaas/internal/infrastructure/security"
 	"luthierSaas/internal/interfaces/http/dtos"
 
 	customErr "luthierSaas/internal/interfaces/http/errors"
@@ -24,9 +27,17 @@ type AuthHandler struct {
 	verifyEmailUC *auth.VerifyEmailUseCase
 	resendVerificationCodeUC *auth.ResendVerificationCodeUseCase
 	refreshTokenUC *auth.RefreshTokenUseCase
-	googleLoginUC *auth.LoginGoogleUseCase
-	googleCallbackUC *auth.GoogleCallbackUseCase
+	oauthLoginUC *auth.OAuthLoginUseCase
+	oauthCallbackUC *auth.OAuthCallbackUseCase
 	logoutUC *auth.LogoutUseCase
+	forgotPasswordUC *auth.ForgotPasswordUseCase
+	resetPasswordUC *auth.ResetPasswordUseCase
+	verifyMFAUC *auth.VerifyMFAUseCase
+	requestMagicLinkUC *auth.RequestMagicLinkUseCase
+	magicLinkLoginUC *auth.MagicLinkLoginUseCase
+	beginPasskeyLoginUC *auth.BeginPasskeyLoginUseCase
+	passkeyLoginUC *auth.PasskeyLoginUseCase
+	reportUnrecognizedLoginUC *auth.ReportUnrecognizedLoginUseCase
 }
 
 
@@ -37,9 +48,17 @@ func NewAuthHandler(
 	verifyEmail *auth.VerifyEmailUseCase, 
 	resendVerificationCode *auth.ResendVerificationCodeUseCase, 
 	refreshToken *auth.RefreshTokenUseCase, 
-	googleLoginUC *auth.LoginGoogleUseCase, 
-	googleCallbackUC *auth.GoogleCallbackUseCase, 
+	oauthLoginUC *auth.OAuthLoginUseCase, 
+	oauthCallbackUC *auth.OAuthCallbackUseCase, 
 	logoutUC *auth.LogoutUseCase,
+	forgotPasswordUC *auth.ForgotPasswordUseCase,
+	resetPasswordUC *auth.ResetPasswordUseCase,
+	verifyMFAUC *auth.VerifyMFAUseCase,
+	requestMagicLinkUC *auth.RequestMagicLinkUseCase,
+	magicLinkLoginUC *auth.MagicLinkLoginUseCase,
+	beginPasskeyLoginUC *auth.BeginPasskeyLoginUseCase,
+	passkeyLoginUC *auth.PasskeyLoginUseCase,
+	reportUnrecognizedLoginUC *auth.ReportUnrecognizedLoginUseCase,
 	) *AuthHandler {
     
 	return &AuthHandler{
@@ -49,9 +68,17 @@ func NewAuthHandler(
 		verifyEmailUC:      verifyEmail,
 		resendVerificationCodeUC: resendVerificationCode,
 		refreshTokenUC: refreshToken,
-		googleLoginUC: googleLoginUC,
-		googleCallbackUC: googleCallbackUC,
+		oauthLoginUC: oauthLoginUC,
+		oauthCallbackUC: oauthCallbackUC,
 		logoutUC: logoutUC,
+		forgotPasswordUC: forgotPasswordUC,
+		resetPasswordUC: resetPasswordUC,
+		verifyMFAUC: verifyMFAUC,
+		requestMagicLinkUC: requestMagicLinkUC,
+		magicLinkLoginUC: magicLinkLoginUC,
+		beginPasskeyLoginUC: beginPasskeyLoginUC,
+		passkeyLoginUC: passkeyLoginUC,
+		reportUnrecognizedLoginUC: reportUnrecognizedLoginUC,
     }
 }
 
@@ -61,19 +88,42 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
 		return
 	}
-	ua := useragent.New(c.GetHeader("User-Agent"))
-    browser, version := ua.Browser()
-    deviceType := "Desktop"
-    if ua.Mobile() {
-        deviceType = "Mobile"
-    }
-    deviceInfo := fmt.Sprintf("%s %s, %s, %s", browser, version, ua.OS(), deviceType)
-	
-	result, err := h.loginUC.Execute(input, deviceInfo)
+	result, err := h.loginUC.Execute(input, deviceInfoFromRequest(c), c.ClientIP())
 	if err != nil {
+		var blocked *security.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+			status := http.StatusTooManyRequests
+			if blocked.Locked {
+				status = http.StatusLocked
+			}
+			c.Error(customErr.New(status, "Error to login", err.Error()))
+			return
+		}
+		if errors.Is(err, auth.ErrPasswordResetRequired) {
+			c.Error(customErr.New(http.StatusForbidden, "Error to login", gin.H{"code": "password_reset_required", "message": err.Error()}))
+			return
+		}
 		c.Error(customErr.New(http.StatusBadRequest, "Error to login", err.Error()))
 		return
 	}
+
+	writeLoginResponse(c, result)
+}
+
+// deviceInfoFromRequest describe el navegador y sistema operativo para identificar la sesión
+func deviceInfoFromRequest(c *gin.Context) string {
+	ua := useragent.New(c.GetHeader("User-Agent"))
+	browser, version := ua.Browser()
+	deviceType := "Desktop"
+	if ua.Mobile() {
+		deviceType = "Mobile"
+	}
+	return fmt.Sprintf("%s %s, %s, %s", browser, version, ua.OS(), deviceType)
+}
+
+// writeLoginResponse responde igual para cualquier forma de login: verificación pendiente, mfa o cookies de sesión
+func writeLoginResponse(c *gin.Context, result *dtos.LoginResponse) {
 	if result.VerificationRequired {
         c.JSON(http.StatusOK, gin.H{
             "verificationRequired": true,
@@ -83,6 +133,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
         })
         return
     }
+	if result.MFARequired {
+		c.JSON(http.StatusOK, gin.H{
+			"mfaRequired":       true,
+			"mfaToken":          result.MFAToken,
+			"mfaTokenExpiresAt": result.MFAExpiresAt,
+		})
+		return
+	}
 
 	// set domain to cookie with secure and httpOnly flags
 	// c.SetCookie("access_token", result.AccessToken, 3600, "/", "", true, true) 
@@ -139,8 +197,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 
     _, err := h.verifyEmailUC.Execute(input.VerificationToken, input.VerificationCode)
     if err != nil {
-		status := http.StatusBadRequest
-        c.Error(customErr.New(status, "error to verify email", err.Error()))
+        verificationError(c, "error to verify email", err)
         return
     }
 
@@ -156,13 +213,43 @@ func (h *AuthHandler) ResendVerificationCode(c *gin.Context) {
 
 	result, err := h.resendVerificationCodeUC.Execute(input.VerificationToken)
 	if err != nil {
-		c.Error(customErr.New(http.StatusInternalServerError, "Failed to resend verification code", err.Error()))
+		verificationError(c, "Failed to resend verification code", err)
 		return
 	}
 	
 	c.JSON(http.StatusOK, result)
 }
 
+// verificationError responde con el código estable de la verificación para que el frontend sepa
+// si mostrar los intentos restantes, pedir un reenvío o volver al login
+func verificationError(c *gin.Context, message string, err error) {
+	var verifyErr *auth.VerificationError
+	if !errors.As(err, &verifyErr) {
+		c.Error(customErr.New(http.StatusInternalServerError, message, err.Error()))
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch verifyErr.Code {
+	case auth.VerificationInvalidToken:
+		status = http.StatusUnauthorized
+	case auth.VerificationNotFound:
+		status = http.StatusNotFound
+	case auth.VerificationAlreadyVerified:
+		status = http.StatusConflict
+	case auth.VerificationCodeExpired:
+		status = http.StatusGone
+	case auth.VerificationTooManyAttempts:
+		status = http.StatusTooManyRequests
+	}
+
+	details := gin.H{"code": verifyErr.Code, "message": verifyErr.Error()}
+	if verifyErr.Code == auth.VerificationCodeIncorrect {
+		details["attempts_remaining"] = verifyErr.AttemptsRemaining
+	}
+	c.Error(customErr.New(status, message, details))
+}
+
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	refreshToken, err := c.Cookie("refresh_token")
     if err != nil || refreshToken == "" {
@@ -179,8 +266,12 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
     deviceInfo := fmt.Sprintf("%s %s, %s, %s", browser, version, ua.OS(), deviceType)
 
     ctx := c.Request.Context()
-    result, err := h.refreshTokenUC.Execute(ctx, refreshToken, deviceInfo)
+    result, err := h.refreshTokenUC.Execute(ctx, refreshToken, deviceInfo, c.ClientIP())
     if err != nil {
+        if errors.Is(err, auth.ErrRefreshTokenReused) {
+            c.SetCookie("access_token", "", -1, "/", "", false, true)
+            c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+        }
         c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
         return
     }
@@ -215,22 +306,88 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Session logout success"})
 }
 
-func (h *AuthHandler) GoogleLogin(c *gin.Context) {
-	googleUrl, err := h.googleLoginUC.Execute(c.Request.Context())
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input dtos.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.forgotPasswordUC.Execute(c.Request.Context(), input.Email); err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Failed to request password reset", err.Error()))
+		return
+	}
+
+	// Misma respuesta exista o no la cuenta
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input dtos.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.resetPasswordUC.Execute(c.Request.Context(), input); err != nil {
+		if errors.Is(err, auth.ErrInvalidResetToken) {
+			c.Error(customErr.New(http.StatusBadRequest, "Error to reset password", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to reset password", err.Error()))
+		return
+	}
+
+	c.SetCookie("access_token", "", -1, "/", "", false, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var input dtos.VerifyMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	ua := useragent.New(c.GetHeader("User-Agent"))
+	browser, version := ua.Browser()
+	deviceType := "Desktop"
+	if ua.Mobile() {
+		deviceType = "Mobile"
+	}
+	deviceInfo := fmt.Sprintf("%s %s, %s, %s", browser, version, ua.OS(), deviceType)
+
+	result, err := h.verifyMFAUC.Execute(c.Request.Context(), input, deviceInfo, c.ClientIP())
+	if err != nil {
+		c.Error(customErr.New(http.StatusUnauthorized, "Error to verify mfa", err.Error()))
+		return
+	}
+
+	c.SetCookie("access_token", result.AccessToken, 3600, "/", "", false, true)
+	c.SetCookie("refresh_token", result.RefreshToken, 604800, "/", "", false, true)
+
+	c.JSON(http.StatusOK, result.Profile)
+}
+
+func (h *AuthHandler) OAuthLogin(c *gin.Context) {
+	provider := c.Param("provider")
+	providerURL, err := h.oauthLoginUC.Execute(c.Request.Context(), provider)
 	if err != nil {
         redirectURL, _ := url.Parse("http://localhost:5173/auth/login")
         query := redirectURL.Query()
-        query.Set("error", "failed_to_generate_google_url")
-        query.Set("message", "No se pudo generar la URL de inicio de sesión con Google")
+        query.Set("error", "failed_to_generate_provider_url")
+        query.Set("message", "No se pudo generar la URL de inicio de sesión con "+provider)
         redirectURL.RawQuery = query.Encode()
         c.Redirect(http.StatusTemporaryRedirect, redirectURL.String())
         return
     }
 
-	c.Redirect(http.StatusTemporaryRedirect, googleUrl)
+	c.Redirect(http.StatusTemporaryRedirect, providerURL)
 }
 
-func (h *AuthHandler) GoogleCallback(c *gin.Context) {
+func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 	redirectErrorURL, err := url.Parse("http://localhost:5173/auth/login")
 	if err != nil {
         log.Printf("Failed to parse error redirect URL: %v", err)
@@ -238,32 +395,55 @@ func (h *AuthHandler) GoogleCallback(c *gin.Context) {
         return
     }
 
+	// Apple responde con form_post, el resto de los proveedores con query string
+	provider := c.Param("provider")
 	code := c.Query("code")
 	state := c.Query("state")
+	if c.Request.Method == http.MethodPost {
+		code = c.PostForm("code")
+		state = c.PostForm("state")
+	}
 	if code == "" || state == "" {
 		c.Error(customErr.New(http.StatusBadRequest, "Missing code or state", ""))
 		return
 	}
 
-	ua := useragent.New(c.GetHeader("User-Agent"))
-	browser, version := ua.Browser()
-	deviceType := "Desktop"
-	if ua.Mobile() {
-		deviceType = "Mobile"
-	}
-	deviceInfo := fmt.Sprintf("%s %s, %s, %s", browser, version, ua.OS(), deviceType)
+	deviceInfo := deviceInfoFromRequest(c)
+
+	// Al vincular desde el perfil, el caso de uso exige que la sesión sea la del usuario que lo inició
+	accessToken, _ := c.Cookie("access_token")
 
-	result, err := h.googleCallbackUC.Execute(c.Request.Context(), code, state, deviceInfo)
+	result, err := h.oauthCallbackUC.Execute(c.Request.Context(), provider, code, state, accessToken, deviceInfo, c.ClientIP())
 	if err != nil {
         query := redirectErrorURL.Query()
-        query.Set("error", "google_callback_failed")
-        query.Set("message", "Error al procesar la autenticación con Google")
+        switch {
+        case errors.Is(err, auth.ErrIdentityNotLinked):
+            query.Set("error", "account_not_linked")
+            query.Set("message", "Ya existe una cuenta con ese email. Iniciá sesión y vinculá "+provider+" desde tu perfil")
+        case errors.Is(err, auth.ErrEmailNotVerified):
+            query.Set("error", "email_not_verified")
+            query.Set("message", "Confirmá el email de tu cuenta y el de "+provider+" antes de vincularlos")
+        case errors.Is(err, auth.ErrLinkSessionRequired):
+            query.Set("error", "link_session_required")
+            query.Set("message", "Iniciá sesión con tu cuenta y volvé a vincular "+provider+" desde tu perfil")
+        case errors.Is(err, auth.ErrIdentityAlreadyLinked), errors.Is(err, auth.ErrProviderAlreadyLinked):
+            query.Set("error", "identity_already_linked")
+            query.Set("message", "Esa cuenta de "+provider+" ya está vinculada a otro usuario")
+        default:
+            query.Set("error", "provider_callback_failed")
+            query.Set("message", "Error al procesar la autenticación con "+provider)
+        }
         redirectErrorURL.RawQuery = query.Encode()
         c.Redirect(http.StatusTemporaryRedirect, redirectErrorURL.String())
         return
     }
 
-	redirectURL, err := url.Parse("http://localhost:5173/auth/google/callback")
+	if result.IdentityLinked {
+		c.Redirect(http.StatusTemporaryRedirect, "http://localhost:5173/profile?linked="+url.QueryEscape(provider))
+		return
+	}
+
+	redirectURL, err := url.Parse("http://localhost:5173/auth/" + url.PathEscape(provider) + "/callback")
 	if err != nil {
         query := redirectErrorURL.Query()
         query.Set("error", "invalid_redirect_url")
@@ -278,6 +458,10 @@ func (h *AuthHandler) GoogleCallback(c *gin.Context) {
 		query.Set("verificationRequired", "true")
 		query.Set("verificationToken", result.VerificationToken)
 		query.Set("verificationCodeExpiresAt", result.VerificationExpiresAt.Format("2006-01-02T15:04:05Z07:00"))
+	} else if result.MFARequired {
+		query.Set("mfaRequired", "true")
+		query.Set("mfaToken", result.MFAToken)
+		query.Set("mfaTokenExpiresAt", result.MFAExpiresAt.Format("2006-01-02T15:04:05Z07:00"))
 	} else {
 		c.SetCookie("access_token", result.AccessToken, 3600, "/", "", false, true)
 		c.SetCookie("refresh_token", result.RefreshToken, 604800, "/", "", false, true)
@@ -295,4 +479,95 @@ func (h *AuthHandler) GoogleCallback(c *gin.Context) {
 	redirectURL.RawQuery = query.Encode()
 
 	c.Redirect(http.StatusTemporaryRedirect, redirectURL.String())
-}
\ No newline at end of file
+}
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var input dtos.MagicLinkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.requestMagicLinkUC.Execute(c.Request.Context(), input.Email); err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Failed to request magic link", err.Error()))
+		return
+	}
+
+	// Misma respuesta exista o no la cuenta
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a sign-in link has been sent"})
+}
+
+func (h *AuthHandler) MagicLinkLogin(c *gin.Context) {
+	var input dtos.MagicLinkLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.magicLinkLoginUC.Execute(c.Request.Context(), input.Token, deviceInfoFromRequest(c), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidMagicLink) {
+			c.Error(customErr.New(http.StatusUnauthorized, "Error to login", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusBadRequest, "Error to login", err.Error()))
+		return
+	}
+
+	writeLoginResponse(c, result)
+}
+
+// BeginPasskeyLogin devuelve el challenge para navigator.credentials.get()
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	result, err := h.beginPasskeyLoginUC.Execute(c.Request.Context())
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Failed to begin passkey login", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AuthHandler) PasskeyLogin(c *gin.Context) {
+	var input dtos.PasskeyLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.passkeyLoginUC.Execute(c.Request.Context(), input, deviceInfoFromRequest(c), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPasskey) {
+			c.Error(customErr.New(http.StatusUnauthorized, "Error to login", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusBadRequest, "Error to login", err.Error()))
+		return
+	}
+
+	writeLoginResponse(c, result)
+}
+
+// ReportUnrecognizedLogin recibe el "no fui yo" del email de dispositivo nuevo. Responde igual haya
+// o no contraseña, el email con el link para restablecerla sale desde el caso de uso.
+func (h *AuthHandler) ReportUnrecognizedLogin(c *gin.Context) {
+	var input dtos.UnrecognizedLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.reportUnrecognizedLoginUC.Execute(c.Request.Context(), input.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidUnrecognizedLoginLink) {
+			c.Error(customErr.New(http.StatusBadRequest, "Error to report login", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to report login", err.Error()))
+		return
+	}
+
+	// Si el reporte lo hace el mismo navegador, su sesión también quedó cerrada
+	c.SetCookie("access_token", "", -1, "/", "", false, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions were closed, check your email to reset your password"})
+}
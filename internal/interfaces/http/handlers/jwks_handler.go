@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"luthierSaas/internal/infrastructure/security"
+	customErr "luthierSaas/internal/interfaces/http/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct{}
+
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	jwks, err := security.PublicJWKS()
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Failed to load signing keys", err.Error()))
+		return
+	}
+
+	// Los verificadores pueden cachear, durante una rotación la clave nueva se publica antes de usarse
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"luthierSaas/internal/application/usecases/subscription"
+	"luthierSaas/internal/infrastructure/payment"
+	"luthierSaas/internal/interfaces/http/dtos"
+	customErr "luthierSaas/internal/interfaces/http/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Los webhooks de la pasarela pesan unos pocos KB, cualquier cosa más grande no es de ella
+const maxWebhookSize = 1 << 20
+
+type PaymentHandler struct {
+	handleWebhookUC *subscription.HandlePaymentWebhookUseCase
+	// fakeGateway solo está cuando PAYMENT_PROVIDER es fake
+	fakeGateway *payment.FakeGateway
+}
+
+func NewPaymentHandler(handleWebhook *subscription.HandlePaymentWebhookUseCase, fakeGateway *payment.FakeGateway) *PaymentHandler {
+	return &PaymentHandler{
+		handleWebhookUC: handleWebhook,
+		fakeGateway:     fakeGateway,
+	}
+}
+
+func (h *PaymentHandler) FakeGatewayEnabled() bool {
+	return h.fakeGateway != nil
+}
+
+// Webhook recibe los eventos de la pasarela. Hay que leer el body crudo, la firma se calcula sobre él.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize))
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid webhook", err.Error()))
+		return
+	}
+
+	if err := h.handleWebhookUC.Execute(c.Request.Context(), c.Request.Header, payload); err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature), errors.Is(err, payment.ErrInvalidPayload):
+			c.Error(customErr.New(http.StatusBadRequest, "Invalid webhook", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusInternalServerError, "Error to process webhook", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+// ownFakeCheckout busca el checkout simulado del usuario. El de otro usuario responde igual que uno
+// inexistente.
+func (h *PaymentHandler) ownFakeCheckout(c *gin.Context, message string) (payment.FakeCheckout, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return payment.FakeCheckout{}, false
+	}
+
+	checkout, ok := h.fakeGateway.Checkout(c.Param("id"))
+	if !ok || checkout.UserID != userID {
+		c.Error(customErr.New(http.StatusNotFound, message, payment.ErrUnknownCheckout.Error()))
+		return payment.FakeCheckout{}, false
+	}
+	return checkout, true
+}
+
+// GetFakeCheckout muestra un checkout de la pasarela simulada, es la página a la que apunta su URL
+func (h *PaymentHandler) GetFakeCheckout(c *gin.Context) {
+	checkout, ok := h.ownFakeCheckout(c, "Error to get checkout")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"checkout": checkout,
+		"outcomes": []string{payment.FakeOutcomePaid, payment.FakeOutcomeFailed, payment.FakeOutcomeExpired},
+	})
+}
+
+// CompleteFakeCheckout cierra un checkout simulado y manda el webhook firmado al endpoint real
+func (h *PaymentHandler) CompleteFakeCheckout(c *gin.Context) {
+	if _, ok := h.ownFakeCheckout(c, "Error to complete checkout"); !ok {
+		return
+	}
+
+	var input dtos.FakeCheckoutInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	eventID, err := h.fakeGateway.Complete(c.Request.Context(), c.Param("id"), input.Outcome, input.EventID)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrUnknownCheckout):
+			c.Error(customErr.New(http.StatusNotFound, "Error to complete checkout", err.Error()))
+		case errors.Is(err, payment.ErrUnknownOutcome):
+			c.Error(customErr.New(http.StatusBadRequest, "Error to complete checkout", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusBadGateway, "Error to complete checkout", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event_id": eventID})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"luthierSaas/internal/application/usecases/subscription"
+	"luthierSaas/internal/interfaces/http/dtos"
+	customErr "luthierSaas/internal/interfaces/http/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionHandler struct {
+	listPlansUC          *subscription.ListPlansUseCase
+	getSubscriptionUC    *subscription.GetSubscriptionUseCase
+	changePlanUC         *subscription.ChangePlanUseCase
+	cancelSubscriptionUC *subscription.CancelSubscriptionUseCase
+	resumeSubscriptionUC *subscription.ResumeSubscriptionUseCase
+	entitlementsUC       *subscription.EntitlementsUseCase
+}
+
+func NewSubscriptionHandler(
+	listPlans *subscription.ListPlansUseCase,
+	getSubscription *subscription.GetSubscriptionUseCase,
+	changePlan *subscription.ChangePlanUseCase,
+	cancelSubscription *subscription.CancelSubscriptionUseCase,
+	resumeSubscription *subscription.ResumeSubscriptionUseCase,
+	entitlements *subscription.EntitlementsUseCase,
+) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		listPlansUC:          listPlans,
+		getSubscriptionUC:    getSubscription,
+		changePlanUC:         changePlan,
+		cancelSubscriptionUC: cancelSubscription,
+		resumeSubscriptionUC: resumeSubscription,
+		entitlementsUC:       entitlements,
+	}
+}
+
+// subscriptionError traduce los errores de los cambios de plan a su status HTTP
+func subscriptionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, subscription.ErrPlanNotFound):
+		c.Error(customErr.New(http.StatusNotFound, message, err.Error()))
+	case errors.Is(err, subscription.ErrNoActiveSubscription):
+		c.Error(customErr.New(http.StatusNotFound, message, err.Error()))
+	case errors.Is(err, subscription.ErrPlanNotSelectable), errors.Is(err, subscription.ErrSamePlan):
+		c.Error(customErr.New(http.StatusBadRequest, message, err.Error()))
+	case errors.Is(err, subscription.ErrAlreadyCanceled):
+		c.Error(customErr.New(http.StatusConflict, message, err.Error()))
+	case errors.Is(err, subscription.ErrSubscriptionChanged):
+		c.Error(customErr.New(http.StatusConflict, message, gin.H{"code": "subscription_changed", "message": err.Error()}))
+	case errors.Is(err, subscription.ErrCheckoutUnavailable):
+		c.Error(customErr.New(http.StatusServiceUnavailable, message, err.Error()))
+	default:
+		c.Error(customErr.New(http.StatusInternalServerError, message, err.Error()))
+	}
+}
+
+func (h *SubscriptionHandler) ListPlans(c *gin.Context) {
+	plans, err := h.listPlansUC.Execute(c.Request.Context())
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to list plans", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.getSubscriptionUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to get subscription", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.ChangePlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.changePlanUC.Execute(c.Request.Context(), userID, input)
+	if err != nil {
+		subscriptionError(c, "Error to change plan", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CancelSubscription acepta el body vacío, en ese caso cancela al final del período
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.CancelSubscriptionInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+			return
+		}
+	}
+
+	result, err := h.cancelSubscriptionUC.Execute(c.Request.Context(), userID, input)
+	if err != nil {
+		subscriptionError(c, "Error to cancel subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.resumeSubscriptionUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		subscriptionError(c, "Error to resume subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetEntitlements le permite al frontend deshabilitar lo que el plan no incluye, el control real lo
+// hacen EntitlementGuard y los casos de uso
+func (h *SubscriptionHandler) GetEntitlements(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.entitlementsUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to get entitlements", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
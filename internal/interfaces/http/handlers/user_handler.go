@@ -1,27 +1,128 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"luthierSaas/internal/application/usecases/auth"
 	"luthierSaas/internal/application/usecases/user"
+	idp "luthierSaas/internal/infrastructure/auth"
 	"luthierSaas/internal/interfaces/http/dtos"
 	customErr "luthierSaas/internal/interfaces/http/errors"
 	"luthierSaas/internal/interfaces/http/middlewares"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
 	profileUC   *user.ProfileUseCase
+    updateProfileUC *user.UpdateProfileUseCase
     changePasswordUC *user.ChangePasswordUseCase
+    setupMFAUC *user.SetupMFAUseCase
+    confirmMFAUC *user.ConfirmMFAUseCase
+    disableMFAUC *user.DisableMFAUseCase
+    listSessionsUC *user.ListSessionsUseCase
+    revokeSessionUC *user.RevokeSessionUseCase
+    revokeOtherSessionsUC *user.RevokeOtherSessionsUseCase
+    permissionsUC *user.PermissionsUseCase
+    listIdentitiesUC *user.ListIdentitiesUseCase
+    setPasswordUC *user.SetPasswordUseCase
+    unlinkIdentityUC *user.UnlinkIdentityUseCase
+    linkProviderUC *auth.LinkProviderUseCase
+    beginPasskeyRegistrationUC *user.BeginPasskeyRegistrationUseCase
+    finishPasskeyRegistrationUC *user.FinishPasskeyRegistrationUseCase
+    listPasskeysUC *user.ListPasskeysUseCase
+    deletePasskeyUC *user.DeletePasskeyUseCase
+    requestEmailChangeUC *user.RequestEmailChangeUseCase
+    confirmEmailChangeUC *user.ConfirmEmailChangeUseCase
+    cancelEmailChangeUC *user.CancelEmailChangeUseCase
+    deleteAccountUC *user.DeleteAccountUseCase
+    exportAccountUC *user.ExportAccountUseCase
+    createAccessTokenUC *user.CreateAccessTokenUseCase
+    listAccessTokensUC *user.ListAccessTokensUseCase
+    revokeAccessTokenUC *user.RevokeAccessTokenUseCase
 }
 
-func NewUserHandler(profile *user.ProfileUseCase, changePassword *user.ChangePasswordUseCase) *UserHandler {
+func NewUserHandler(
+	profile *user.ProfileUseCase,
+	updateProfile *user.UpdateProfileUseCase,
+	changePassword *user.ChangePasswordUseCase,
+	setupMFA *user.SetupMFAUseCase,
+	confirmMFA *user.ConfirmMFAUseCase,
+	disableMFA *user.DisableMFAUseCase,
+	listSessions *user.ListSessionsUseCase,
+	revokeSession *user.RevokeSessionUseCase,
+	revokeOtherSessions *user.RevokeOtherSessionsUseCase,
+	permissions *user.PermissionsUseCase,
+	listIdentities *user.ListIdentitiesUseCase,
+	setPassword *user.SetPasswordUseCase,
+	unlinkIdentity *user.UnlinkIdentityUseCase,
+	linkProvider *auth.LinkProviderUseCase,
+	beginPasskeyRegistration *user.BeginPasskeyRegistrationUseCase,
+	finishPasskeyRegistration *user.FinishPasskeyRegistrationUseCase,
+	listPasskeys *user.ListPasskeysUseCase,
+	deletePasskey *user.DeletePasskeyUseCase,
+	requestEmailChange *user.RequestEmailChangeUseCase,
+	confirmEmailChange *user.ConfirmEmailChangeUseCase,
+	cancelEmailChange *user.CancelEmailChangeUseCase,
+	deleteAccount *user.DeleteAccountUseCase,
+	exportAccount *user.ExportAccountUseCase,
+	createAccessToken *user.CreateAccessTokenUseCase,
+	listAccessTokens *user.ListAccessTokensUseCase,
+	revokeAccessToken *user.RevokeAccessTokenUseCase,
+	) *UserHandler {
     return &UserHandler{
 		profileUC:          profile,
+		updateProfileUC:    updateProfile,
         changePasswordUC:    changePassword,
+		setupMFAUC:         setupMFA,
+		confirmMFAUC:       confirmMFA,
+		disableMFAUC:       disableMFA,
+		listSessionsUC:     listSessions,
+		revokeSessionUC:    revokeSession,
+		revokeOtherSessionsUC: revokeOtherSessions,
+		permissionsUC:      permissions,
+		listIdentitiesUC:   listIdentities,
+		setPasswordUC:      setPassword,
+		unlinkIdentityUC:   unlinkIdentity,
+		linkProviderUC:     linkProvider,
+		beginPasskeyRegistrationUC: beginPasskeyRegistration,
+		finishPasskeyRegistrationUC: finishPasskeyRegistration,
+		listPasskeysUC:     listPasskeys,
+		deletePasskeyUC:    deletePasskey,
+		requestEmailChangeUC: requestEmailChange,
+		confirmEmailChangeUC: confirmEmailChange,
+		cancelEmailChangeUC: cancelEmailChange,
+		deleteAccountUC:    deleteAccount,
+		exportAccountUC:    exportAccount,
+		createAccessTokenUC: createAccessToken,
+		listAccessTokensUC: listAccessTokens,
+		revokeAccessTokenUC: revokeAccessToken,
 	}
 }
 
+// currentUserID obtiene el id que dejó AuthMiddleware en el contexto
+func currentUserID(c *gin.Context) (int, bool) {
+	userIDVal, exists := c.Get(middlewares.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, false
+	}
+
+	userID, ok := userIDVal.(int)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID type"})
+		return 0, false
+	}
+
+	return userID, true
+}
+
 func (h *UserHandler) GetProfile(c *gin.Context) {
     userIDVal, exists := c.Get(middlewares.UserIDKey)
     if !exists {
@@ -44,6 +145,35 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
     c.JSON(http.StatusOK, result)
 }
 
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.updateProfileUC.Execute(c.Request.Context(), userID, input)
+	if err != nil {
+		var validationErr *user.ProfileValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.Error(customErr.New(http.StatusBadRequest, "Invalid profile data", gin.H{"fields": validationErr.Fields}))
+		case errors.Is(err, user.ErrNoProfileChanges):
+			c.Error(customErr.New(http.StatusBadRequest, "Invalid profile data", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusInternalServerError, "Error to update profile", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *UserHandler) ChangePassword(c *gin.Context) {
     userIDVal, exists := c.Get(middlewares.UserIDKey)
     if !exists {
@@ -70,4 +200,553 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
     }
 
     c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
-}
\ No newline at end of file
+}
+
+func (h *UserHandler) SetupMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.setupMFAUC.Execute(userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Error to setup mfa", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) ConfirmMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.ConfirmMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.confirmMFAUC.Execute(userID, input)
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Error to confirm mfa", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) DisableMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.DisableMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.disableMFAUC.Execute(userID, input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Error to disable mfa", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "mfa disabled successfully"})
+}
+
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.listSessionsUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey))
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to list sessions", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid session id", err.Error()))
+		return
+	}
+
+	if err := h.revokeSessionUC.Execute(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, user.ErrSessionNotFound) {
+			c.Error(customErr.New(http.StatusNotFound, "Error to revoke session", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to revoke session", err.Error()))
+		return
+	}
+
+	// Revocar la sesión actual equivale a cerrar sesión
+	if sessionID == c.GetInt(middlewares.SessionIDKey) {
+		c.SetCookie("access_token", "", -1, "/", "", false, true)
+		c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.revokeOtherSessionsUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey))
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to revoke sessions", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked successfully", "revoked": revoked})
+}
+
+func (h *UserHandler) GetPermissions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.permissionsUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Error to get permissions", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) ListIdentities(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.listIdentitiesUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Error to list identities", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) LinkProvider(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	providerURL, err := h.linkProviderUC.Execute(c.Request.Context(), c.Param("provider"), userID)
+	if err != nil {
+		if errors.Is(err, idp.ErrUnknownProvider) {
+			c.Error(customErr.New(http.StatusNotFound, "Error to link provider", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to link provider", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.LinkProviderResponse{URL: providerURL})
+}
+
+func (h *UserHandler) SetPassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.SetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.setPasswordUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey), input); err != nil {
+		switch {
+		case errors.Is(err, user.ErrPasswordAlreadyLinked):
+			c.Error(customErr.New(http.StatusConflict, "Error to set password", err.Error()))
+		case errors.Is(err, user.ErrReauthRequired):
+			c.Error(customErr.New(http.StatusForbidden, "Error to set password", gin.H{"code": "reauth_required", "message": err.Error()}))
+		default:
+			c.Error(customErr.New(http.StatusBadRequest, "Error to set password", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password linked successfully"})
+}
+
+func (h *UserHandler) UnlinkIdentity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.unlinkIdentityUC.Execute(c.Request.Context(), userID, c.Param("provider")); err != nil {
+		switch {
+		case errors.Is(err, user.ErrIdentityNotFound):
+			c.Error(customErr.New(http.StatusNotFound, "Error to unlink identity", err.Error()))
+		case errors.Is(err, user.ErrLastCredential):
+			c.Error(customErr.New(http.StatusConflict, "Error to unlink identity", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusBadRequest, "Error to unlink identity", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "identity unlinked successfully"})
+}
+
+func (h *UserHandler) ListPasskeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.listPasskeysUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to list passkeys", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BeginPasskeyRegistration devuelve las opciones para navigator.credentials.create()
+func (h *UserHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	options, err := h.beginPasskeyRegistrationUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to begin passkey registration", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+func (h *UserHandler) FinishPasskeyRegistration(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.PasskeyRegistrationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.finishPasskeyRegistrationUC.Execute(c.Request.Context(), userID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrPasskeyAlreadyRegistered):
+			c.Error(customErr.New(http.StatusConflict, "Error to register passkey", err.Error()))
+		case errors.Is(err, user.ErrInvalidPasskeyRegistration):
+			c.Error(customErr.New(http.StatusBadRequest, "Error to register passkey", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusInternalServerError, "Error to register passkey", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *UserHandler) DeletePasskey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	passkeyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid passkey id", err.Error()))
+		return
+	}
+
+	if err := h.deletePasskeyUC.Execute(c.Request.Context(), userID, passkeyID); err != nil {
+		switch {
+		case errors.Is(err, user.ErrPasskeyNotFound):
+			c.Error(customErr.New(http.StatusNotFound, "Error to delete passkey", err.Error()))
+		case errors.Is(err, user.ErrLastCredential):
+			c.Error(customErr.New(http.StatusConflict, "Error to delete passkey", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusBadRequest, "Error to delete passkey", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "passkey deleted successfully"})
+}
+
+// emailChangeError traduce los errores del cambio de email a respuestas HTTP
+func emailChangeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, user.ErrInvalidCurrentPassword):
+		c.Error(customErr.New(http.StatusUnauthorized, message, err.Error()))
+	case errors.Is(err, user.ErrReauthRequired):
+		c.Error(customErr.New(http.StatusForbidden, message, gin.H{"code": "reauth_required", "message": err.Error()}))
+	case errors.Is(err, user.ErrEmailInUse):
+		c.Error(customErr.New(http.StatusConflict, message, err.Error()))
+	case errors.Is(err, user.ErrNoPendingEmailChange), errors.Is(err, user.ErrInvalidEmailChangeToken):
+		c.Error(customErr.New(http.StatusNotFound, message, err.Error()))
+	case errors.Is(err, user.ErrTooManyEmailChangeAttempts):
+		c.Error(customErr.New(http.StatusTooManyRequests, message, err.Error()))
+	case errors.Is(err, user.ErrSameEmail), errors.Is(err, user.ErrInvalidEmailChangeCode):
+		c.Error(customErr.New(http.StatusBadRequest, message, err.Error()))
+	default:
+		c.Error(customErr.New(http.StatusInternalServerError, message, err.Error()))
+	}
+}
+
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.ChangeEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.requestEmailChangeUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey), input)
+	if err != nil {
+		emailChangeError(c, "Error to change email", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, result)
+}
+
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.ConfirmEmailChangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.confirmEmailChangeUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey), input); err != nil {
+		emailChangeError(c, "Error to confirm email change", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email changed successfully"})
+}
+
+// CancelEmailChange no requiere sesión, el token llega por el link enviado a la dirección anterior
+func (h *UserHandler) CancelEmailChange(c *gin.Context) {
+	var input dtos.CancelEmailChangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	if err := h.cancelEmailChangeUC.Execute(c.Request.Context(), input.Token); err != nil {
+		emailChangeError(c, "Error to cancel email change", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email change canceled successfully"})
+}
+
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.deleteAccountUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidCurrentPassword):
+			c.Error(customErr.New(http.StatusUnauthorized, "Error to delete account", err.Error()))
+		case errors.Is(err, user.ErrReauthRequired):
+			c.Error(customErr.New(http.StatusForbidden, "Error to delete account", gin.H{"code": "reauth_required", "message": err.Error()}))
+		case errors.Is(err, user.ErrAccountAlreadyDeleted):
+			c.Error(customErr.New(http.StatusConflict, "Error to delete account", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusInternalServerError, "Error to delete account", err.Error()))
+		}
+		return
+	}
+
+	// Las sesiones ya se revocaron, se limpian también las cookies del navegador
+	c.SetCookie("access_token", "", -1, "/", "", false, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportAccount descarga los datos personales del usuario, por defecto como ZIP con un JSON por
+// sección; con ?format=json devuelve un único documento.
+func (h *UserHandler) ExportAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid export format", "format must be zip or json"))
+		return
+	}
+
+	export, err := h.exportAccountUC.Execute(c.Request.Context(), userID, c.GetInt(middlewares.SessionIDKey))
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to export account", err.Error()))
+		return
+	}
+
+	filename := fmt.Sprintf("luthier-export-%d-%s", userID, export.ExportedAt.Format("20060102"))
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	var archive bytes.Buffer
+	if err := writeAccountExportZip(&archive, export); err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to export account", err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// writeAccountExportZip escribe cada sección del export en su propio archivo JSON
+func writeAccountExportZip(w io.Writer, export *dtos.AccountExport) error {
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"subscriptions.json", export.Subscriptions},
+		{"identities.json", export.Identities},
+		{"passkeys.json", export.Passkeys},
+		{"access_tokens.json", export.AccessTokens},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (h *UserHandler) ListAccessTokens(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.listAccessTokensUC.Execute(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to list access tokens", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateAccessToken devuelve el token completo una sola vez, después solo se ve el prefijo
+func (h *UserHandler) CreateAccessToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input dtos.CreateAccessTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid input data", err.Error()))
+		return
+	}
+
+	result, err := h.createAccessTokenUC.Execute(c.Request.Context(), userID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidAccessTokenScope):
+			c.Error(customErr.New(http.StatusBadRequest, "Error to create access token", err.Error()))
+		case errors.Is(err, user.ErrTooManyAccessTokens):
+			c.Error(customErr.New(http.StatusConflict, "Error to create access token", err.Error()))
+		default:
+			c.Error(customErr.New(http.StatusInternalServerError, "Error to create access token", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *UserHandler) RevokeAccessToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(customErr.New(http.StatusBadRequest, "Invalid access token id", err.Error()))
+		return
+	}
+
+	if err := h.revokeAccessTokenUC.Execute(c.Request.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, user.ErrAccessTokenNotFound) {
+			c.Error(customErr.New(http.StatusNotFound, "Error to revoke access token", err.Error()))
+			return
+		}
+		c.Error(customErr.New(http.StatusInternalServerError, "Error to revoke access token", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "access token revoked successfully"})
+}
@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenIDKey solo está en el contexto cuando el request se autenticó con un token personal
+const AccessTokenIDKey = "accessTokenID"
+
+// El último uso se guarda como mucho una vez por minuto, no en cada request del script
+const accessTokenTouchInterval = time.Minute
+
+// TokenAuthenticator acepta, además de la sesión, tokens personales en Authorization: Bearer.
+// Solo se usa en los grupos de rutas pensados para integraciones; el resto sigue con AuthMiddleware.
+type TokenAuthenticator struct {
+    sessionRepo repository.SessionRepository
+    tokenRepo   repository.PersonalAccessTokenRepository
+    userRepo    repository.UserRepository
+}
+
+func NewTokenAuthenticator(
+    sessionRepo repository.SessionRepository,
+    tokenRepo repository.PersonalAccessTokenRepository,
+    userRepo repository.UserRepository,
+) *TokenAuthenticator {
+    return &TokenAuthenticator{sessionRepo: sessionRepo, tokenRepo: tokenRepo, userRepo: userRepo}
+}
+
+// RequireScope autentica el request y, si vino con un token personal, exige que tenga el scope.
+// La sesión del navegador tiene acceso completo.
+func (a *TokenAuthenticator) RequireScope(scope string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        header := c.GetHeader("Authorization")
+        if header == "" {
+            if authenticateSession(c, a.sessionRepo) {
+                c.Next()
+            }
+            return
+        }
+
+        plain, found := strings.CutPrefix(header, "Bearer ")
+        if !found || !security.IsAccessToken(plain) {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+            return
+        }
+
+        tokenHash, err := security.HashToken(plain)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+            return
+        }
+
+        ctx := c.Request.Context()
+        token, err := a.tokenRepo.FindByHash(ctx, tokenHash)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate access token"})
+            return
+        }
+        if token == nil {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+            return
+        }
+        if token.Expired() {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Access token expired"})
+            return
+        }
+
+        // Una cuenta borrada o desactivada no tiene sesiones, tampoco puede usar sus tokens
+        user, err := a.userRepo.FindByID(token.UserID)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate access token"})
+            return
+        }
+        if user == nil || user.Deleted || !user.IsActive {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+            return
+        }
+
+        if !token.HasScope(scope) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing scope", "scope": scope})
+            return
+        }
+
+        if now := time.Now(); now.Sub(token.LastUsedAt) >= accessTokenTouchInterval {
+            _ = a.tokenRepo.UpdateLastUsed(ctx, token.ID, now, c.ClientIP())
+        }
+
+        c.Set(UserIDKey, user.ID)
+        c.Set(RoleKey, user.Role)
+        c.Set(AccessTokenIDKey, token.ID)
+        c.Next()
+    }
+}
@@ -2,29 +2,79 @@ package middlewares
 
 import (
 	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 const UserIDKey = "userID"
+const SessionIDKey = "sessionID"
+const RoleKey = "role"
+const ImpersonatorIDKey = "impersonatorID"
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware valida el JWT y además que la sesión siga vigente en la base,
+// para que un logout o una revocación tengan efecto inmediato
+func AuthMiddleware(sessionRepo repository.SessionRepository) gin.HandlerFunc {
     return func(c *gin.Context) {
-        cookie, err := c.Cookie("access_token") 
-        if err != nil {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid cookie"})
-            return
+        if authenticateSession(c, sessionRepo) {
+            c.Next()
         }
+    }
+}
+
+// authenticateSession deja el usuario de la cookie en el contexto o corta el request con 401
+func authenticateSession(c *gin.Context, sessionRepo repository.SessionRepository) bool {
+    cookie, err := c.Cookie("access_token") 
+    if err != nil {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid cookie"})
+        return false
+    }
+
+    claims, err := security.ParseAccessToken(cookie)
+
+    if err != nil {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+        return false
+    }
+
+    accessTokenHash, err := security.HashToken(cookie)
+    if err != nil {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+        return false
+    }
+
+    session, err := sessionRepo.FindByAccessTokenHash(c.Request.Context(), accessTokenHash)
+    if err != nil {
+        c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+        return false
+    }
+
+    if session == nil || !session.IsValid || session.UserID != claims.UserID || session.ImpersonatorID != claims.ImpersonatorID {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session revoked or expired"})
+        return false
+    }
 
-        userID, err := security.ValidateAccessToken(cookie)
+    c.Set(UserIDKey, claims.UserID)
+    c.Set(SessionIDKey, session.ID)
+    c.Set(RoleKey, claims.Role)
+    if session.ImpersonatorID != 0 {
+        // El frontend usa el header para mostrar que un administrador está navegando como el usuario
+        c.Set(ImpersonatorIDKey, session.ImpersonatorID)
+        c.Header("X-Impersonator-ID", strconv.Itoa(session.ImpersonatorID))
+    }
+    return true
+}
 
-        if err != nil {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+// BlockImpersonation corta con 403 las acciones sensibles (credenciales, sesiones, borrado de la
+// cuenta) cuando quien las pide es un administrador impersonando al usuario
+func BlockImpersonation() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if c.GetInt(ImpersonatorIDKey) != 0 {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Action not allowed while impersonating"})
             return
         }
-
-        c.Set(UserIDKey, userID)
         c.Next()
     }
-}
\ No newline at end of file
+}
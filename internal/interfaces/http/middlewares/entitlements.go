@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"luthierSaas/internal/application/usecases/subscription"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageCounter devuelve cuánto quedaría usado de una función si el request sigue, por ejemplo las
+// órdenes activas más la que se está creando
+type UsageCounter func(ctx context.Context, c *gin.Context, userID int) (int, error)
+
+// EntitlementGuard corta con 402 los requests que el plan del usuario no permite. Va después del
+// middleware de autenticación, que es quien deja el usuario en el contexto.
+type EntitlementGuard struct {
+    entitlements *subscription.EntitlementsUseCase
+}
+
+func NewEntitlementGuard(entitlements *subscription.EntitlementsUseCase) *EntitlementGuard {
+    return &EntitlementGuard{entitlements: entitlements}
+}
+
+// RequireFeature es para las funciones que el plan incluye o no, como pdf_branding
+func (g *EntitlementGuard) RequireFeature(feature string) gin.HandlerFunc {
+    return g.RequireQuota(feature, func(context.Context, *gin.Context, int) (int, error) { return 0, nil })
+}
+
+// RequireQuota compara el uso que calcula usage contra el cupo del plan
+func (g *EntitlementGuard) RequireQuota(feature string, usage UsageCounter) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        userID := c.GetInt(UserIDKey)
+        if userID == 0 {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+            return
+        }
+
+        ctx := c.Request.Context()
+        used, err := usage(ctx, c, userID)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check plan usage"})
+            return
+        }
+
+        if err := g.entitlements.Check(ctx, userID, feature, used); err != nil {
+            var upgrade *subscription.UpgradeRequiredError
+            if errors.As(err, &upgrade) {
+                c.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{
+                    "success": false,
+                    "error":   "Upgrade required",
+                    "details": UpgradeRequiredDetails(upgrade),
+                })
+                return
+            }
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plan entitlements"})
+            return
+        }
+        c.Next()
+    }
+}
+
+// UpgradeRequiredDetails es el detalle del 402, igual venga del middleware o de un handler que
+// recibió el error de Check desde un caso de uso
+func UpgradeRequiredDetails(err *subscription.UpgradeRequiredError) gin.H {
+    return gin.H{
+        "code":    "upgrade_required",
+        "message": err.Error(),
+        "feature": err.Feature,
+        "limit":   err.Limit,
+        "usage":   err.Usage,
+        "plan":    err.Plan,
+    }
+}
@@ -1,52 +1,205 @@
 package middlewares
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"luthierSaas/internal/application/usecases/subscription"
+	"luthierSaas/internal/domain/entities"
 	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/infrastructure/config"
+	"luthierSaas/internal/infrastructure/security"
+	"luthierSaas/internal/interfaces/repository"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 	"github.com/ulule/limiter/v3"
-	mgin "github.com/ulule/limiter/v3/drivers/middleware/gin"
 	"github.com/ulule/limiter/v3/drivers/store/redis"
 )
 
-type RateLimiterConfig struct {
-    Rate   limiter.Rate 
-    Prefix string    
+// Cuánto se recuerda a qué usuario pertenece un token personal, para no ir a la base en cada request
+const rateLimitTokenCacheTTL = 5 * time.Minute
+
+// RateLimiter arma los limitadores de cada grupo de rutas. Los grupos con ByUser cuentan por usuario
+// cuando el request trae sesión o token personal, con el límite de su plan; el resto, por IP.
+//
+// Va antes de la autenticación, así que identifica al usuario sin validarlo del todo (la firma del JWT
+// sí, la sesión no): solo elige el contador, el acceso lo siguen decidiendo los middlewares de auth.
+type RateLimiter struct {
+    cache        *cache.Cache
+    tokenRepo    repository.PersonalAccessTokenRepository
+    entitlements *subscription.EntitlementsUseCase
+    limits       map[string]config.RateLimitTiers
+    logger       *zerolog.Logger
+}
+
+func NewRateLimiter(
+    cacheService *cache.Cache,
+    tokenRepo repository.PersonalAccessTokenRepository,
+    entitlements *subscription.EntitlementsUseCase,
+    limits map[string]config.RateLimitTiers,
+    logger *zerolog.Logger,
+) *RateLimiter {
+    return &RateLimiter{cache: cacheService, tokenRepo: tokenRepo, entitlements: entitlements, limits: limits, logger: logger}
 }
 
-func NewRateLimiterMiddleware(cacheService *cache.Cache, config RateLimiterConfig) (gin.HandlerFunc, error) {
-    if cacheService.Client() == nil {
+// Limit devuelve el middleware del grupo name (api, signin, ...) con los límites de la configuración
+func (l *RateLimiter) Limit(name string) (gin.HandlerFunc, error) {
+    tiers, ok := l.limits[name]
+    if !ok {
+        return nil, fmt.Errorf("rate limit %q is not configured", name)
+    }
+
+    if l.cache.Client() == nil {
         return nil, fmt.Errorf("redis client is nil")
     }
 
-    store, err := redis.NewStoreWithOptions(cacheService.Client(), limiter.StoreOptions{
-        Prefix: config.Prefix,
+    store, err := redis.NewStoreWithOptions(l.cache.Client(), limiter.StoreOptions{
+        Prefix: "rate:" + name,
     })
     if err != nil {
         return nil, fmt.Errorf("failed to create redis store: %w", err)
     }
 
-    rateLimiter := limiter.New(store, config.Rate)
+    limiters := make(map[string]*limiter.Limiter, 3)
+    for tier, formatted := range map[string]string{
+        entities.PlanTierFree:    tiers.Free,
+        entities.PlanTierBasic:   tiers.Basic,
+        entities.PlanTierPremium: tiers.Premium,
+    } {
+        rate, err := limiter.NewRateFromFormatted(formatted)
+        if err != nil {
+            return nil, fmt.Errorf("invalid %s rate limit for %q: %w", tier, name, err)
+        }
+        limiters[tier] = limiter.New(store, rate)
+    }
+
+    return func(c *gin.Context) {
+        ctx := c.Request.Context()
+        key, tier := "ip:"+c.ClientIP(), entities.PlanTierFree
+        if tiers.ByUser {
+            key, tier = l.identify(ctx, c)
+        }
 
-    middleware := mgin.NewMiddleware(rateLimiter,
-        mgin.WithKeyGetter(func(c *gin.Context) string {
-            ip := c.ClientIP()
-            log.Printf("Rate limiting for IP: %s, Endpoint: %s", ip, c.FullPath())
-            return ip
-        }),
-        mgin.WithErrorHandler(func(c *gin.Context, err error) {
-            log.Printf("Rate limit exceeded for IP: %s, Endpoint: %s, Error: %v", c.ClientIP(), c.FullPath(), err)
-            c.JSON(http.StatusTooManyRequests, gin.H{
+        // El contador de cada plan es distinto, al cambiar de plan se arranca de cero
+        rateLimiter := limiters[tier]
+        result, err := rateLimiter.Get(ctx, tier+":"+key)
+        if err != nil {
+            l.logger.Error().
+                Err(err).
+                Str("limiter", name).
+                Str("key", key).
+                Str("endpoint", c.FullPath()).
+                Msg("Rate limiter unavailable")
+            // Sin Redis la API sigue funcionando, pero las rutas de auth no quedan expuestas a fuerza bruta
+            if tiers.ByUser {
+                c.Next()
+                return
+            }
+            c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
                 "error":   "Límite de tasa excedido",
                 "message": "Demasiadas solicitudes, intenta de nuevo más tarde",
             })
-            c.Abort()
-        }),
-    )
+            return
+        }
+
+        reset := int64(math.Ceil(time.Until(time.Unix(result.Reset, 0)).Seconds()))
+        if reset < 0 {
+            reset = 0
+        }
+        c.Header("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+        c.Header("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+        c.Header("RateLimit-Reset", strconv.FormatInt(reset, 10))
+        c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rateLimiter.Rate.Limit, int64(rateLimiter.Rate.Period.Seconds())))
+
+        if result.Reached {
+            l.logger.Warn().
+                Str("limiter", name).
+                Str("key", key).
+                Str("tier", tier).
+                Str("endpoint", c.FullPath()).
+                Msg("Rate limit exceeded")
+            c.Header("Retry-After", strconv.FormatInt(reset, 10))
+            c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+                "error":   "Límite de tasa excedido",
+                "message": "Demasiadas solicitudes, intenta de nuevo más tarde",
+            })
+            return
+        }
+        c.Next()
+    }, nil
+}
+
+// identify devuelve la clave del contador y el nivel del plan. Un usuario que no se puede identificar,
+// o cuyo plan no se pudo consultar, cuenta como free.
+func (l *RateLimiter) identify(ctx context.Context, c *gin.Context) (string, string) {
+    userID := c.GetInt(UserIDKey)
+    if userID == 0 {
+        userID = l.requestUserID(ctx, c)
+    }
+    if userID == 0 {
+        return "ip:" + c.ClientIP(), entities.PlanTierFree
+    }
+
+    key := "user:" + strconv.Itoa(userID)
+    tier, err := l.entitlements.PlanTier(ctx, userID)
+    if err != nil {
+        l.logger.Error().
+            Err(err).
+            Int("user_id", userID).
+            Msg("Failed to resolve plan tier")
+        return key, entities.PlanTierFree
+    }
+    return key, tier
+}
+
+// requestUserID lee el usuario del token personal o de la cookie de sesión, 0 si no hay ninguno válido
+func (l *RateLimiter) requestUserID(ctx context.Context, c *gin.Context) int {
+    if plain, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+        if !security.IsAccessToken(plain) {
+            return 0
+        }
+        return l.tokenUserID(ctx, plain)
+    }
+
+    cookie, err := c.Cookie("access_token")
+    if err != nil {
+        return 0
+    }
+    claims, err := security.ParseAccessToken(cookie)
+    if err != nil {
+        return 0
+    }
+    return claims.UserID
+}
 
-    return middleware, nil
-}
\ No newline at end of file
+// tokenUserID cachea el dueño de cada token personal, también los inválidos (como 0)
+func (l *RateLimiter) tokenUserID(ctx context.Context, plain string) int {
+    tokenHash, err := security.HashToken(plain)
+    if err != nil {
+        return 0
+    }
+
+    cacheKey := "rate:token:" + tokenHash
+    if cached, err := l.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+        userID, _ := strconv.Atoi(cached)
+        return userID
+    }
+
+    userID := 0
+    token, err := l.tokenRepo.FindByHash(ctx, tokenHash)
+    if err != nil {
+        return 0
+    }
+    if token != nil && !token.Expired() {
+        userID = token.UserID
+    }
+
+    _ = l.cache.Set(ctx, cacheKey, strconv.Itoa(userID), rateLimitTokenCacheTTL)
+    return userID
+}
@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"luthierSaas/internal/infrastructure/cache"
+	"luthierSaas/internal/interfaces/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const permissionsCacheTTL = 5 * time.Minute
+
+// RequireRole debe ir después de AuthMiddleware, que es quien deja el rol en el contexto
+func RequireRole(roles ...string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        role := c.GetString(RoleKey)
+        if !slices.Contains(roles, role) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+            return
+        }
+        c.Next()
+    }
+}
+
+// Authorizer resuelve los permisos de cada rol desde la tabla role_permissions, cacheados en Redis
+type Authorizer struct {
+    permissionRepo repository.PermissionRepository
+    cacheService   *cache.Cache
+}
+
+func NewAuthorizer(permissionRepo repository.PermissionRepository, cacheService *cache.Cache) *Authorizer {
+    return &Authorizer{permissionRepo: permissionRepo, cacheService: cacheService}
+}
+
+func (a *Authorizer) RequirePermission(permission string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        role := c.GetString(RoleKey)
+        if role == "" {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing role"})
+            return
+        }
+
+        permissions, err := a.PermissionsForRole(c.Request.Context(), role)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load permissions"})
+            return
+        }
+
+        if !slices.Contains(permissions, permission) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing permission", "permission": permission})
+            return
+        }
+        c.Next()
+    }
+}
+
+func (a *Authorizer) PermissionsForRole(ctx context.Context, role string) ([]string, error) {
+    cacheKey := fmt.Sprintf("permissions:role:%s", role)
+
+    cached, err := a.cacheService.Get(ctx, cacheKey)
+    if err == nil && cached != "" {
+        var permissions []string
+        if err := json.Unmarshal([]byte(cached), &permissions); err == nil {
+            return permissions, nil
+        }
+    }
+
+    permissions, err := a.permissionRepo.FindByRole(ctx, role)
+    if err != nil {
+        return nil, err
+    }
+
+    if data, err := json.Marshal(permissions); err == nil {
+        if err := a.cacheService.Set(ctx, cacheKey, string(data), permissionsCacheTTL); err != nil {
+            fmt.Printf("Failed to set cache for %s: %v\n", cacheKey, err)
+        }
+    }
+
+    return permissions, nil
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/http/handlers"
+	"luthierSaas/internal/interfaces/http/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAdminRoutes(api *gin.RouterGroup, adminHandler *handlers.AdminHandler, authMiddleware gin.HandlerFunc, authorizer *middlewares.Authorizer) {
+
+    adminGroup := api.Group("/admin", authMiddleware, middlewares.RequireRole(entities.RoleAdmin, entities.RoleSuperAdmin))
+
+    canRead := authorizer.RequirePermission(entities.PermissionUsersRead)
+    canWrite := authorizer.RequirePermission(entities.PermissionUsersWrite)
+    canImpersonate := authorizer.RequirePermission(entities.PermissionUsersImpersonate)
+
+    users := adminGroup.Group("/users")
+    {
+        users.GET("", canRead, adminHandler.ListUsers)
+        users.GET(":id", canRead, adminHandler.GetUser)
+        users.POST(":id/deactivate", canWrite, adminHandler.DeactivateUser)
+        users.POST(":id/reactivate", canWrite, adminHandler.ReactivateUser)
+        users.DELETE(":id", canWrite, adminHandler.DeleteUser)
+        users.POST(":id/restore", canWrite, adminHandler.RestoreUser)
+        users.POST(":id/verify-email", canWrite, adminHandler.ForceVerifyEmail)
+        users.POST(":id/logout", canWrite, adminHandler.ForceLogout)
+        users.POST(":id/unlock", canWrite, adminHandler.UnlockLogin)
+        users.POST(":id/impersonate", canImpersonate, adminHandler.StartImpersonation)
+    }
+
+    // Mientras impersona, el administrador tiene el rol del usuario, por eso esta ruta queda fuera de /admin
+    api.POST("/impersonation/stop", authMiddleware, adminHandler.StopImpersonation)
+}
@@ -2,82 +2,112 @@ package routes
 
 import (
 	"log"
-	"luthierSaas/internal/infrastructure/cache"
 	"luthierSaas/internal/interfaces/http/handlers"
 	"luthierSaas/internal/interfaces/http/middlewares"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/ulule/limiter/v3"
 )
 
-func SetupAuthRoutes(api *gin.RouterGroup, authHandler *handlers.AuthHandler, cacheService *cache.Cache) {
-    signinLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 5},
-        Prefix: "rate:auth:signin:free",
-    })
+func SetupAuthRoutes(api *gin.RouterGroup, authHandler *handlers.AuthHandler, rateLimiter *middlewares.RateLimiter) {
+    signinLimiter, err := rateLimiter.Limit("signin")
     if err != nil {
         log.Fatalf("Failed to initialize signin rate limiter: %v", err)
     }
 
-    signupLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 5},
-        Prefix: "rate:auth:signup:free",
-    })
+    signupLimiter, err := rateLimiter.Limit("signup")
     if err != nil {
         log.Fatalf("Failed to initialize signup rate limiter: %v", err)
     }
 
-    checkEmailLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 10},
-        Prefix: "rate:auth:check-email:free",
-    })
+    checkEmailLimiter, err := rateLimiter.Limit("check-email")
     if err != nil {
         log.Fatalf("Failed to initialize check-email rate limiter: %v", err)
     }
 
-    verifyEmailLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 10},
-        Prefix: "rate:auth:verify-email:free",
-    })
+    verifyEmailLimiter, err := rateLimiter.Limit("verify-email")
     if err != nil {
         log.Fatalf("Failed to initialize verify-email rate limiter: %v", err)
     }
 
-    resendCodeLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 3},
-        Prefix: "rate:auth:resend-code:free",
-    })
+    resendCodeLimiter, err := rateLimiter.Limit("resend-code")
     if err != nil {
         log.Fatalf("Failed to initialize resend-code rate limiter: %v", err)
     }
 
-    refreshLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 10},
-        Prefix: "rate:auth:refresh:free",
-    })
+    refreshLimiter, err := rateLimiter.Limit("refresh")
     if err != nil {
         log.Fatalf("Failed to initialize refresh rate limiter: %v", err)
     }
 
-    logoutLimiter, err := middlewares.NewRateLimiterMiddleware(cacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 20},
-        Prefix: "rate:auth:logout:free",
-    })
+    logoutLimiter, err := rateLimiter.Limit("logout")
     if err != nil {
         log.Fatalf("Failed to initialize logout rate limiter: %v", err)
     }
 
+    forgotPasswordLimiter, err := rateLimiter.Limit("forgot-password")
+    if err != nil {
+        log.Fatalf("Failed to initialize forgot-password rate limiter: %v", err)
+    }
+
+    resetPasswordLimiter, err := rateLimiter.Limit("reset-password")
+    if err != nil {
+        log.Fatalf("Failed to initialize reset-password rate limiter: %v", err)
+    }
+
+    verifyMFALimiter, err := rateLimiter.Limit("mfa-verify")
+    if err != nil {
+        log.Fatalf("Failed to initialize mfa-verify rate limiter: %v", err)
+    }
+
+    magicLinkLimiter, err := rateLimiter.Limit("magic-link")
+    if err != nil {
+        log.Fatalf("Failed to initialize magic-link rate limiter: %v", err)
+    }
+
+    unrecognizedLoginLimiter, err := rateLimiter.Limit("not-me")
+    if err != nil {
+        log.Fatalf("Failed to initialize not-me rate limiter: %v", err)
+    }
+
+    magicLinkVerifyLimiter, err := rateLimiter.Limit("magic-link-verify")
+    if err != nil {
+        log.Fatalf("Failed to initialize magic-link-verify rate limiter: %v", err)
+    }
+
+    passkeyBeginLimiter, err := rateLimiter.Limit("passkey-begin")
+    if err != nil {
+        log.Fatalf("Failed to initialize passkey-begin rate limiter: %v", err)
+    }
+
+    passkeyFinishLimiter, err := rateLimiter.Limit("passkey-finish")
+    if err != nil {
+        log.Fatalf("Failed to initialize passkey-finish rate limiter: %v", err)
+    }
+
+    oauthLoginLimiter, err := rateLimiter.Limit("oauth-login")
+    if err != nil {
+        log.Fatalf("Failed to initialize oauth-login rate limiter: %v", err)
+    }
+
     auth := api.Group("/auth")
     {
         auth.POST("/signin", signinLimiter, authHandler.Login)
         auth.POST("/signup", signupLimiter, authHandler.Register)
+        auth.POST("/magic-link", magicLinkLimiter, authHandler.RequestMagicLink)
+        auth.POST("/magic-link/verify", magicLinkVerifyLimiter, authHandler.MagicLinkLogin)
+        auth.POST("/passkey/begin", passkeyBeginLimiter, authHandler.BeginPasskeyLogin)
+        auth.POST("/passkey/finish", passkeyFinishLimiter, authHandler.PasskeyLogin)
         auth.POST("/check-email", checkEmailLimiter, authHandler.CheckEmail)
         auth.POST("/verify-email", verifyEmailLimiter, authHandler.VerifyEmail)
         auth.POST("/resend-code", resendCodeLimiter, authHandler.ResendVerificationCode)
         auth.POST("/refresh", middlewares.RefreshMiddleware(), refreshLimiter, authHandler.RefreshToken)
         auth.POST("/logout", logoutLimiter, authHandler.Logout)
-        auth.GET("/google/login", authHandler.GoogleLogin)
-        auth.GET("/google/callback", authHandler.GoogleCallback)
+        auth.POST("/forgot-password", forgotPasswordLimiter, authHandler.ForgotPassword)
+        auth.POST("/reset-password", resetPasswordLimiter, authHandler.ResetPassword)
+        auth.POST("/mfa/verify", verifyMFALimiter, authHandler.VerifyMFA)
+        auth.POST("/not-me", unrecognizedLoginLimiter, authHandler.ReportUnrecognizedLogin)
+        auth.GET("/:provider/login", oauthLoginLimiter, authHandler.OAuthLogin)
+        auth.GET("/:provider/callback", authHandler.OAuthCallback)
+        auth.POST("/:provider/callback", authHandler.OAuthCallback)
     }
 }
\ No newline at end of file
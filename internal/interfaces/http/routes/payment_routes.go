@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"luthierSaas/internal/interfaces/http/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPaymentRoutes: el webhook queda fuera del rate limit de /v1, la pasarela reintenta ante un 429
+// y se atrasarían las activaciones. La pasarela simulada solo se expone cuando está configurada y
+// cada usuario ve y cierra únicamente sus checkouts.
+func SetupPaymentRoutes(r *gin.Engine, api *gin.RouterGroup, paymentHandler *handlers.PaymentHandler, authMiddleware gin.HandlerFunc) {
+
+    r.POST("/v1/payments/webhook", paymentHandler.Webhook)
+
+    if paymentHandler.FakeGatewayEnabled() {
+        fake := api.Group("/payments/fake", authMiddleware)
+        {
+            fake.GET("checkouts/:id", paymentHandler.GetFakeCheckout)
+            fake.POST("checkouts/:id", paymentHandler.CompleteFakeCheckout)
+        }
+    }
+}
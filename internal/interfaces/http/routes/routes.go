@@ -5,21 +5,19 @@ import (
 	"log"
 	"luthierSaas/internal/di"
 	"luthierSaas/internal/interfaces/http/middlewares"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/ulule/limiter/v3"
 )
 
 func SetupRoutes(r *gin.Engine, db *sql.DB, container *di.Container) {
-	generalLimiter, err := middlewares.NewRateLimiterMiddleware(container.CacheService, middlewares.RateLimiterConfig{
-        Rate:   limiter.Rate{Period: time.Minute, Limit: 100},
-        Prefix: "rate:api:v1:free",
-    })
+	generalLimiter, err := container.RateLimiter.Limit("api")
     if err != nil {
         log.Fatalf("Failed to initialize general rate limiter: %v", err)
     }
 	
+	// Claves públicas para que otros servicios verifiquen nuestros access tokens
+	r.GET("/.well-known/jwks.json", container.JWKSHandler.GetJWKS)
+
     api := r.Group("/v1", generalLimiter)
 
 	api.GET("/ping", func(c *gin.Context) {
@@ -28,8 +26,19 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, container *di.Container) {
 		})
 	})
     // auth routes
-    SetupAuthRoutes(api, container.AuthHandler, container.CacheService)
+    SetupAuthRoutes(api, container.AuthHandler, container.RateLimiter)
+
+	authMiddleware := middlewares.AuthMiddleware(container.SessionRepo)
 
 	// user routes
-    SetupUserRoutes(api, container.UserHandler)
+    SetupUserRoutes(api, container.UserHandler, authMiddleware, container.TokenAuth)
+
+	// subscription routes
+	SetupSubscriptionRoutes(api, container.SubscriptionHandler, authMiddleware, container.TokenAuth)
+
+	// payment routes
+	SetupPaymentRoutes(r, api, container.PaymentHandler, authMiddleware)
+
+	// admin routes
+	SetupAdminRoutes(api, container.AdminHandler, authMiddleware, container.Authorizer)
 }
\ No newline at end of file
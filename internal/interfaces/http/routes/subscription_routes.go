@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"luthierSaas/internal/domain/entities"
+	"luthierSaas/internal/interfaces/http/handlers"
+	"luthierSaas/internal/interfaces/http/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSubscriptionRoutes: el catálogo de planes es público; consultar la suscripción acepta tokens
+// personales, cambiarla exige la sesión del navegador y no se puede mientras se impersona.
+func SetupSubscriptionRoutes(api *gin.RouterGroup, subscriptionHandler *handlers.SubscriptionHandler, authMiddleware gin.HandlerFunc, tokenAuth *middlewares.TokenAuthenticator) {
+
+    profileRead := tokenAuth.RequireScope(entities.ScopeProfileRead)
+    noImpersonation := middlewares.BlockImpersonation()
+
+    api.GET("/plans", subscriptionHandler.ListPlans)
+    api.GET("/users/entitlements", profileRead, subscriptionHandler.GetEntitlements)
+
+    subscriptions := api.Group("/users/subscription")
+    {
+        subscriptions.GET("", profileRead, subscriptionHandler.GetSubscription)
+        subscriptions.POST("change", authMiddleware, noImpersonation, subscriptionHandler.ChangePlan)
+        subscriptions.POST("cancel", authMiddleware, noImpersonation, subscriptionHandler.CancelSubscription)
+        subscriptions.POST("resume", authMiddleware, noImpersonation, subscriptionHandler.ResumeSubscription)
+    }
+}
@@ -1,17 +1,49 @@
 package routes
 
 import (
+	"luthierSaas/internal/domain/entities"
 	"luthierSaas/internal/interfaces/http/handlers"
 	"luthierSaas/internal/interfaces/http/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupUserRoutes(api *gin.RouterGroup, userHandler *handlers.UserHandler) {
+// SetupUserRoutes: solo las rutas con RequireScope aceptan tokens personales, las que administran
+// credenciales, sesiones o la cuenta misma exigen la sesión del navegador y no se pueden usar
+// mientras un administrador impersona al usuario.
+func SetupUserRoutes(api *gin.RouterGroup, userHandler *handlers.UserHandler, authMiddleware gin.HandlerFunc, tokenAuth *middlewares.TokenAuthenticator) {
+
+    profileRead := tokenAuth.RequireScope(entities.ScopeProfileRead)
+    profileWrite := tokenAuth.RequireScope(entities.ScopeProfileWrite)
+    noImpersonation := middlewares.BlockImpersonation()
 
     users := api.Group("/users")
     {
-        users.GET("profile", middlewares.AuthMiddleware(), userHandler.GetProfile)
-        users.POST("change-password", middlewares.AuthMiddleware(), userHandler.ChangePassword)
+        users.GET("profile", profileRead, userHandler.GetProfile)
+        users.PATCH("profile", profileWrite, userHandler.UpdateProfile)
+        users.DELETE("me", authMiddleware, noImpersonation, userHandler.DeleteAccount)
+        users.GET("me/export", authMiddleware, noImpersonation, userHandler.ExportAccount)
+        users.POST("change-password", authMiddleware, noImpersonation, userHandler.ChangePassword)
+        users.POST("email/change", authMiddleware, noImpersonation, userHandler.RequestEmailChange)
+        users.POST("email/confirm", authMiddleware, noImpersonation, userHandler.ConfirmEmailChange)
+        users.POST("email/cancel", userHandler.CancelEmailChange)
+        users.POST("mfa/setup", authMiddleware, noImpersonation, userHandler.SetupMFA)
+        users.POST("mfa/confirm", authMiddleware, noImpersonation, userHandler.ConfirmMFA)
+        users.POST("mfa/disable", authMiddleware, noImpersonation, userHandler.DisableMFA)
+        users.GET("permissions", profileRead, userHandler.GetPermissions)
+        users.GET("sessions", authMiddleware, userHandler.ListSessions)
+        users.DELETE("sessions/:id", authMiddleware, noImpersonation, userHandler.RevokeSession)
+        users.POST("sessions/revoke-others", authMiddleware, noImpersonation, userHandler.RevokeOtherSessions)
+        users.GET("identities", authMiddleware, userHandler.ListIdentities)
+        users.POST("identities/password", authMiddleware, noImpersonation, userHandler.SetPassword)
+        users.POST("identities/:provider", authMiddleware, noImpersonation, userHandler.LinkProvider)
+        users.DELETE("identities/:provider", authMiddleware, noImpersonation, userHandler.UnlinkIdentity)
+        users.GET("passkeys", authMiddleware, userHandler.ListPasskeys)
+        users.POST("passkeys/register/begin", authMiddleware, noImpersonation, userHandler.BeginPasskeyRegistration)
+        users.POST("passkeys/register/finish", authMiddleware, noImpersonation, userHandler.FinishPasskeyRegistration)
+        users.DELETE("passkeys/:id", authMiddleware, noImpersonation, userHandler.DeletePasskey)
+        users.GET("tokens", authMiddleware, userHandler.ListAccessTokens)
+        users.POST("tokens", authMiddleware, noImpersonation, userHandler.CreateAccessToken)
+        users.DELETE("tokens/:id", authMiddleware, noImpersonation, userHandler.RevokeAccessToken)
     }
 }
\ No newline at end of file
@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"context"
+	"luthierSaas/internal/domain/entities"
+)
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *entities.AuditLog) error
+}
@@ -12,5 +12,6 @@ type EmailVerificationRepository interface {
 	GetByUserID(ctx context.Context, userID int) (*entities.EmailVerification, error)
 	MarkAsVerified(ctx context.Context, userID int) error
 	DeleteByUserID(ctx context.Context, userID int) error
-	UpdateCode(ctx context.Context, id int, newCode string, newExpiresAt time.Time) error
+	UpdateCode(ctx context.Context, id int, newCodeHash string, newExpiresAt time.Time) error
+	IncrementAttempts(ctx context.Context, id int) (int, error)
 }
\ No newline at end of file
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+type MFARecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID int, codeHashes []string) error
+	Consume(ctx context.Context, userID int, codeHash string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID int) error
+}
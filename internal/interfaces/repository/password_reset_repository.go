@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"luthierSaas/internal/domain/entities"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset *entities.PasswordReset) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entities.PasswordReset, error)
+	// MarkAsUsed devuelve false si el token ya estaba usado, por ejemplo por otro request simultáneo
+	MarkAsUsed(ctx context.Context, id int) (bool, error)
+	InvalidateByUserID(ctx context.Context, userID int) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"luthierSaas/internal/domain/entities"
+)
+
+type PaymentEventRepository interface {
+	Exists(ctx context.Context, provider, eventID string) (bool, error)
+	// Create ignora el evento si ya estaba registrado, por una entrega simultánea del mismo webhook
+	Create(ctx context.Context, event *entities.PaymentEvent) error
+}
@@ -0,0 +1,7 @@
+package repository
+
+import "context"
+
+type PermissionRepository interface {
+	FindByRole(ctx context.Context, role string) ([]string, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"luthierSaas/internal/domain/entities"
+	"time"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *entities.PersonalAccessToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*entities.PersonalAccessToken, error)
+	FindByUserID(ctx context.Context, userID int) ([]*entities.PersonalAccessToken, error)
+	CountByUserID(ctx context.Context, userID int) (int, error)
+	UpdateLastUsed(ctx context.Context, id int, usedAt time.Time, ip string) error
+	Delete(ctx context.Context, userID, id int) (bool, error)
+	DeleteByUserID(ctx context.Context, userID int) error
+}
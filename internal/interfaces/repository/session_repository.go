@@ -3,14 +3,24 @@ package repository
 import (
 	"context"
 	"luthierSaas/internal/domain/entities"
+	"time"
 )
 
 type SessionRepository interface {
     Create(ctx context.Context, session *entities.Session) error
     FindByAccessTokenHash(ctx context.Context, accessTokenHash string) (*entities.Session, error)
     FindByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entities.Session, error)
+    // FindAnyByRefreshTokenHash también devuelve sesiones rotadas o invalidadas, para detectar reuso
+    FindAnyByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*entities.Session, error)
+    FindByFamilyID(ctx context.Context, familyID string) ([]*entities.Session, error)
     Update(ctx context.Context, session *entities.Session) error
     Invalidate(ctx context.Context, accessTokenHash string) error
+    InvalidateByUserID(ctx context.Context, userID int) error
+    InvalidateFamily(ctx context.Context, familyID string) error
+    // MarkConsumed devuelve false si otro pedido ya había consumido la sesión
+    MarkConsumed(ctx context.Context, accessTokenHash string) (bool, error)
     Delete(ctx context.Context, accessTokenHash string) error
     FindByUserID(ctx context.Context, userID int64) ([]*entities.Session, error)
-}
\ No newline at end of file
+    // FindHistoryByUserID incluye las sesiones cerradas o rotadas, para reconocer dispositivos ya usados
+    FindHistoryByUserID(ctx context.Context, userID int, since time.Time) ([]*entities.Session, error)
+}
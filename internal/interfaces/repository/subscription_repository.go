@@ -1,11 +1,44 @@
 package repository
 
 import (
+	"context"
 	"luthierSaas/internal/domain/entities"
+	"time"
 )
 
 type SubscriptionRepository interface {
 	Save(subscription *entities.Subscription) (int, error)
 	GetFreeTierPlanID()(int, error)
 	GetFreeTierPlan()(*entities.SubscriptionPlan, error)
-}
\ No newline at end of file
+	// FindByUserID devuelve el historial completo de suscripciones, la más reciente primero
+	FindByUserID(ctx context.Context, userID int) ([]*entities.Subscription, error)
+	FindPlans(ctx context.Context) ([]*entities.SubscriptionPlan, error)
+	FindPlanByID(ctx context.Context, planID int) (*entities.SubscriptionPlan, error)
+	// FindPlanFeatures devuelve nil si el plan no tiene límites cargados
+	FindPlanFeatures(ctx context.Context, planID int) (*entities.PlanFeatures, error)
+	FindActive(ctx context.Context, userID int) (*entities.Subscription, error)
+	FindScheduled(ctx context.Context, userID int) (*entities.Subscription, error)
+	// FindPending devuelve la suscripción pendiente de pago más reciente
+	FindPending(ctx context.Context, userID int) (*entities.Subscription, error)
+	// Replace cierra la suscripción activa current (nil si no hay) y guarda next en la misma transacción.
+	// Devuelve false si current ya no estaba activa, por ejemplo por un cambio simultáneo.
+	Replace(ctx context.Context, current, next *entities.Subscription) (bool, error)
+	// ScheduleChange deja next programada para cuando venza current, que pasa a no renovarse
+	ScheduleChange(ctx context.Context, current, next *entities.Subscription) (bool, error)
+	// SetCancelAtPeriodEnd también descarta cualquier cambio de plan programado
+	SetCancelAtPeriodEnd(ctx context.Context, current *entities.Subscription, cancel bool) (bool, error)
+	// Cancel cierra la suscripción en el momento, sin abrir otra
+	Cancel(ctx context.Context, current *entities.Subscription) (bool, error)
+	SetCheckout(ctx context.Context, subscriptionID int, provider, checkoutID string) error
+	FindByCheckout(ctx context.Context, provider, checkoutID string) (*entities.Subscription, error)
+	// Activate pasa a activa una suscripción pendiente con las fechas de pending y cierra la que
+	// estaba activa. Devuelve false si pending ya no estaba pendiente.
+	Activate(ctx context.Context, pending *entities.Subscription) (bool, error)
+	CancelPending(ctx context.Context, pending *entities.Subscription) (bool, error)
+	// FindActiveExpiringBefore devuelve las suscripciones activas que vencen antes de before, incluidas las ya vencidas
+	FindActiveExpiringBefore(ctx context.Context, before time.Time) ([]*entities.Subscription, error)
+	// MarkReminderSent y MarkRenewalRequested devuelven false si otra ejecución ya lo hizo
+	MarkReminderSent(ctx context.Context, subscriptionID int) (bool, error)
+	MarkRenewalRequested(ctx context.Context, subscriptionID int) (bool, error)
+	ClearRenewalRequested(ctx context.Context, subscriptionID int) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"luthierSaas/internal/domain/entities"
+)
+
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *entities.UserIdentity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*entities.UserIdentity, error)
+	FindByUserAndProvider(ctx context.Context, userID int, provider string) (*entities.UserIdentity, error)
+	FindByUserID(ctx context.Context, userID int) ([]*entities.UserIdentity, error)
+	UpdateSubject(ctx context.Context, id int, subject, email string) error
+	Delete(ctx context.Context, userID int, provider string) error
+}
@@ -6,9 +6,32 @@ import (
 	"time"
 )
 
+// UserFilter agrupa los criterios de búsqueda del panel de administración, los punteros nil no filtran
+type UserFilter struct {
+    Email       string
+    Verified    *bool
+    Deleted     *bool
+    Active      *bool
+    Plan        string
+    CreatedFrom time.Time
+    CreatedTo   time.Time
+    Limit       int
+    Offset      int
+}
+
+// ProfileUpdate lleva solo los campos a modificar, los punteros nil quedan como están
+type ProfileUpdate struct {
+    FirstName    *string
+    LastName     *string
+    Phone        *string
+    Address      *string
+    Country      *string
+    WorkshopName *string
+}
+
 type UserRepository interface {
     Save(user *entities.User) (int, error)
-    CreateEmailVerification(userID int, code string, expiresAt time.Time) error
+    CreateEmailVerification(userID int, codeHash string, expiresAt time.Time) error
     FindByID(id int) (*entities.User, error)
     FindByEmail(email string) (*entities.User, error)
     FindAll() ([]*entities.User, error)
@@ -16,4 +39,19 @@ type UserRepository interface {
     EmailExists(email string) (bool, error)
     UpdateLastLogin(ctx context.Context, userID int, lastLogin time.Time ) error
     UpdatePassword(userID int, newPassword string ) error
+    UpdateEmail(userID int, email string) error
+    UpdateProfile(ctx context.Context, userID int, update ProfileUpdate) error
+    UpdateMFASecret(userID int, secret string) error
+    UpdateMFAEnabled(userID int, enabled bool) error
+    Search(ctx context.Context, filter UserFilter) ([]*entities.User, int, error)
+    UpdateActive(userID int, active bool) error
+    UpdateDeleted(userID int, deleted bool) error
+    ScheduleDeletion(userID int, purgeAfter time.Time) error
+    RestoreDeleted(userID int) error
+    // FindPendingPurge devuelve los ids de las cuentas cuyo plazo de recuperación venció antes de before
+    FindPendingPurge(ctx context.Context, before time.Time) ([]int, error)
+    // Purge elimina la fila y, por las claves foráneas, todo lo que cuelga de ella
+    Purge(ctx context.Context, userID int, before time.Time) (bool, error)
+    // RequirePasswordReset bloquea el login con contraseña hasta el próximo UpdatePassword
+    RequirePasswordReset(ctx context.Context, userID int) error
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"luthierSaas/internal/domain/entities"
+	"time"
+)
+
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, credential *entities.WebAuthnCredential) error
+	FindByCredentialID(ctx context.Context, credentialID []byte) (*entities.WebAuthnCredential, error)
+	FindByUserID(ctx context.Context, userID int) ([]*entities.WebAuthnCredential, error)
+	UpdateUsage(ctx context.Context, id int, signCount uint32, backupState bool, usedAt time.Time) error
+	Delete(ctx context.Context, userID, id int) (bool, error)
+}
@@ -0,0 +1,2 @@
+ALTER TABLE password_resets
+  DROP INDEX idx_reset_token;
@@ -0,0 +1,2 @@
+ALTER TABLE password_resets
+  ADD INDEX idx_reset_token (reset_token);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE users
+  DROP COLUMN mfa_enabled,
+  DROP COLUMN mfa_secret;
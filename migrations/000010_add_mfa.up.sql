@@ -0,0 +1,14 @@
+ALTER TABLE users
+  ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN mfa_secret VARCHAR(255) DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id BIGINT NOT NULL,
+  code_hash VARCHAR(255) NOT NULL,
+  used BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  INDEX idx_mfa_recovery_user_code (user_id, code_hash)
+);
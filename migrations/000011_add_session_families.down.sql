@@ -0,0 +1,4 @@
+ALTER TABLE sessions
+  DROP INDEX idx_family_id,
+  DROP COLUMN family_id,
+  DROP COLUMN consumed;
@@ -0,0 +1,4 @@
+ALTER TABLE sessions
+  ADD COLUMN family_id VARCHAR(64) DEFAULT NULL,
+  ADD COLUMN consumed BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD INDEX idx_family_id (family_id);
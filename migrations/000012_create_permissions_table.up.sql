@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS permissions (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  name VARCHAR(100) NOT NULL UNIQUE,
+  description TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role ENUM('user', 'admin', 'superadmin') NOT NULL,
+  permission_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (role, permission_id),
+  FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (name, description) VALUES
+  ('users:read', 'View any user account, its sessions and subscription'),
+  ('users:write', 'Modify, deactivate or delete any user account'),
+  ('permissions:manage', 'Grant or revoke permissions to roles');
+
+INSERT INTO role_permissions (role, permission_id)
+  SELECT 'admin', id FROM permissions WHERE name IN ('users:read', 'users:write');
+
+INSERT INTO role_permissions (role, permission_id)
+  SELECT 'superadmin', id FROM permissions;
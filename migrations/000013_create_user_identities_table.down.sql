@@ -0,0 +1,9 @@
+ALTER TABLE users
+  ADD COLUMN login_method VARCHAR(50) DEFAULT NULL;
+
+UPDATE users u
+  JOIN user_identities ui ON ui.user_id = u.id
+  SET u.login_method = ui.provider
+  WHERE u.password IS NULL OR u.password = '';
+
+DROP TABLE IF EXISTS user_identities;
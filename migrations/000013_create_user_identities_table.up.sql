@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id BIGINT NOT NULL,
+  provider VARCHAR(50) NOT NULL,
+  -- NULL solo para cuentas migradas desde login_method, se completa en el próximo login con el proveedor
+  subject VARCHAR(255) DEFAULT NULL,
+  email VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  UNIQUE KEY uq_identity_provider_subject (provider, subject),
+  UNIQUE KEY uq_identity_user_provider (user_id, provider)
+);
+
+INSERT INTO user_identities (user_id, provider, subject, email)
+SELECT id, login_method, NULL, email FROM users WHERE login_method IS NOT NULL;
+
+ALTER TABLE users
+  DROP COLUMN login_method;
@@ -0,0 +1,11 @@
+-- Los hashes no se pueden revertir, los códigos pendientes quedan vencidos y hay que reenviarlos
+UPDATE email_verifications
+  SET code_hash = '', expires_at = NOW()
+  WHERE verified = FALSE;
+
+ALTER TABLE email_verifications
+  RENAME COLUMN code_hash TO verification_code;
+
+ALTER TABLE email_verifications
+  MODIFY COLUMN verification_code VARCHAR(10) NOT NULL,
+  DROP COLUMN attempts;
@@ -0,0 +1,11 @@
+ALTER TABLE email_verifications
+  MODIFY COLUMN verification_code VARCHAR(64) NOT NULL,
+  ADD COLUMN attempts INT NOT NULL DEFAULT 0 AFTER verification_code;
+
+-- Los códigos pendientes pasan a guardarse como SHA-256 en hex, igual que security.HashVerificationCode
+UPDATE email_verifications
+  SET verification_code = SHA2(verification_code, 256)
+  WHERE verified = FALSE;
+
+ALTER TABLE email_verifications
+  RENAME COLUMN verification_code TO code_hash;
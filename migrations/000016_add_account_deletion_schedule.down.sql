@@ -0,0 +1,4 @@
+ALTER TABLE users
+  DROP INDEX idx_users_purge_after,
+  DROP COLUMN purge_after,
+  DROP COLUMN deleted_at;
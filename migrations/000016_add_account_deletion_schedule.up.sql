@@ -0,0 +1,6 @@
+-- purge_after solo se completa cuando el propio usuario borra su cuenta: hasta esa fecha puede
+-- recuperarla iniciando sesión y después el borrado definitivo elimina la fila
+ALTER TABLE users
+  ADD COLUMN deleted_at DATETIME NULL AFTER deleted,
+  ADD COLUMN purge_after DATETIME NULL AFTER deleted_at,
+  ADD INDEX idx_users_purge_after (purge_after);
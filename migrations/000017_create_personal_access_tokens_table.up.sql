@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  token_hash CHAR(64) NOT NULL,
+  token_prefix VARCHAR(16) NOT NULL,
+  scopes VARCHAR(255) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  last_used_at DATETIME NULL DEFAULT NULL,
+  last_used_ip VARCHAR(45) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  UNIQUE KEY uq_personal_access_token_hash (token_hash),
+  INDEX idx_personal_access_tokens_user (user_id)
+);
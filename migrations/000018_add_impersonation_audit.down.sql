@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE name = 'users:impersonate';
+
+DROP TABLE IF EXISTS audit_logs;
+
+ALTER TABLE sessions
+  DROP COLUMN impersonator_id;
@@ -0,0 +1,27 @@
+ALTER TABLE sessions
+  ADD COLUMN impersonator_id BIGINT NULL DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  actor_id BIGINT NULL,
+  action VARCHAR(64) NOT NULL,
+  target_user_id BIGINT NULL,
+  session_id BIGINT NULL,
+  ip VARCHAR(45) NOT NULL DEFAULT '',
+  metadata TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL,
+  FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE SET NULL,
+  INDEX idx_audit_logs_actor (actor_id),
+  INDEX idx_audit_logs_target (target_user_id),
+  INDEX idx_audit_logs_action (action, created_at)
+);
+
+INSERT INTO permissions (name, description) VALUES
+  ('users:impersonate', 'Sign in as another user to reproduce their issues');
+
+INSERT INTO role_permissions (role, permission_id)
+  SELECT 'admin', id FROM permissions WHERE name = 'users:impersonate';
+
+INSERT INTO role_permissions (role, permission_id)
+  SELECT 'superadmin', id FROM permissions WHERE name = 'users:impersonate';
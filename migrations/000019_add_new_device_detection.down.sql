@@ -0,0 +1,6 @@
+ALTER TABLE users
+  DROP COLUMN password_reset_required;
+
+ALTER TABLE sessions
+  DROP INDEX idx_sessions_user_created,
+  DROP COLUMN ip_address;
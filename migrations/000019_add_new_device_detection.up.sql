@@ -0,0 +1,6 @@
+ALTER TABLE sessions
+  ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '',
+  ADD INDEX idx_sessions_user_created (user_id, created_at);
+
+ALTER TABLE users
+  ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+UPDATE subscriptions SET status = 'canceled' WHERE status = 'scheduled';
+
+ALTER TABLE subscriptions
+  DROP INDEX idx_subscriptions_user_status,
+  DROP COLUMN canceled_at,
+  DROP COLUMN cancel_at_period_end,
+  MODIFY COLUMN status ENUM('active', 'pending', 'canceled') NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions
+  MODIFY COLUMN status ENUM('active', 'pending', 'canceled', 'scheduled') NOT NULL,
+  ADD COLUMN cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN canceled_at DATETIME NULL DEFAULT NULL,
+  ADD INDEX idx_subscriptions_user_status (user_id, status);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS payment_events;
+
+ALTER TABLE subscriptions
+  DROP INDEX idx_subscriptions_checkout,
+  DROP COLUMN checkout_id,
+  DROP COLUMN payment_provider;
@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions
+  ADD COLUMN payment_provider VARCHAR(20) NULL DEFAULT NULL,
+  ADD COLUMN checkout_id VARCHAR(255) NULL DEFAULT NULL,
+  ADD UNIQUE INDEX idx_subscriptions_checkout (payment_provider, checkout_id);
+
+CREATE TABLE IF NOT EXISTS payment_events (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  provider VARCHAR(20) NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  type VARCHAR(100) NOT NULL,
+  subscription_id BIGINT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY idx_payment_events_event (provider, event_id),
+  FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE SET NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions
+  DROP INDEX idx_subscriptions_status_expires,
+  DROP COLUMN renewal_requested_at,
+  DROP COLUMN expiry_reminder_sent_at;
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions
+  ADD COLUMN expiry_reminder_sent_at DATETIME NULL DEFAULT NULL,
+  ADD COLUMN renewal_requested_at DATETIME NULL DEFAULT NULL,
+  ADD INDEX idx_subscriptions_status_expires (status, expires_at);
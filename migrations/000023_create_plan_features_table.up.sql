@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS plan_features (
+  plan_id BIGINT PRIMARY KEY,
+  max_active_repair_orders INT NULL,
+  max_clients INT NULL,
+  storage_quota_mb INT NULL,
+  staff_seats INT NULL,
+  pdf_branding BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (plan_id) REFERENCES subscription_plans(id) ON DELETE CASCADE
+);
+
+-- NULL es sin límite
+INSERT INTO plan_features (plan_id, max_active_repair_orders, max_clients, storage_quota_mb, staff_seats, pdf_branding)
+  SELECT id, 20, 50, 500, 1, FALSE FROM subscription_plans WHERE name = 'Free Tier';
+
+INSERT INTO plan_features (plan_id, max_active_repair_orders, max_clients, storage_quota_mb, staff_seats, pdf_branding)
+  SELECT id, 50, 250, 5120, 3, FALSE FROM subscription_plans WHERE name = 'Basic';
+
+INSERT INTO plan_features (plan_id, max_active_repair_orders, max_clients, storage_quota_mb, staff_seats, pdf_branding)
+  SELECT id, NULL, NULL, 51200, 10, TRUE FROM subscription_plans WHERE name = 'Premium';